@@ -0,0 +1,57 @@
+module github.com/zeabix-cloud-native/nstda-blockchain-chaincode/combined/chaincode-go
+
+go 1.17
+
+require (
+	github.com/hyperledger/fabric-contract-api-go v1.2.1
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer v0.0.0-20240529061836-64a55bdcb7ca
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go v0.0.0-00010101000000-000000000000
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/go-openapi/jsonpointer v0.19.5 // indirect
+	github.com/go-openapi/jsonreference v0.20.0 // indirect
+	github.com/go-openapi/spec v0.20.8 // indirect
+	github.com/go-openapi/swag v0.21.1 // indirect
+	github.com/gobuffalo/envy v1.10.1 // indirect
+	github.com/gobuffalo/packd v1.0.1 // indirect
+	github.com/gobuffalo/packr v1.30.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20230228194215-b84622ba6a7a // indirect
+	github.com/hyperledger/fabric-protos-go v0.3.0 // indirect
+	github.com/joho/godotenv v1.4.0 // indirect
+	github.com/josharian/intern v1.0.0 // indirect
+	github.com/mailru/easyjson v0.7.7 // indirect
+	github.com/rogpeppe/go-internal v1.8.1 // indirect
+	github.com/xeipuuv/gojsonpointer v0.0.0-20190905194746-02993c407bfb // indirect
+	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
+	github.com/xeipuuv/gojsonschema v1.2.0 // indirect
+	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	google.golang.org/genproto v0.0.0-20230110181048-76db0878b65f // indirect
+	google.golang.org/grpc v1.53.0 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// The chaincodes are built from this tree, and the combined chaincode needs
+// the namespacing support of the issuer package in it.
+replace (
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go => ../../exporter/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go => ../../farmer/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go => ../../gap/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go => ../../gmp/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer => ../../internal/issuer
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go => ../../nstda-staff/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go => ../../packer/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go => ../../packing/chaincode-go
+	github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go => ../../regulator/chaincode-go
+)
@@ -0,0 +1,32 @@
+package combined
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	exporter "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/smart-contract"
+	farmer "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/smart-contract"
+	gap "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/smart-contract"
+	gmp "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	nstdaStaff "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/smart-contract"
+	packer "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/smart-contract"
+	packing "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/smart-contract"
+	regulator "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/smart-contract"
+)
+
+// NewChaincode deploys every contract as one chaincode, for networks that
+// would rather not run eight. Each contract is named after the chaincode it
+// replaces, so clients call "gap:CreateGAP" where they called CreateGAP on the
+// gap chaincode, and calls between contracts run in-process. Functions
+// without a contract name go to gap.
+func NewChaincode() (*issuer.NamespacedChaincode, error) {
+	return issuer.NewNamespacedChaincode(
+		&gap.SmartContract{Contract: contractapi.Contract{Name: issuer.CCGAP}},
+		&gmp.SmartContract{Contract: contractapi.Contract{Name: issuer.CCGMP}},
+		&farmer.SmartContract{Contract: contractapi.Contract{Name: issuer.CCFARMER}},
+		&packer.SmartContract{Contract: contractapi.Contract{Name: issuer.CCPACKER}},
+		&packing.SmartContract{Contract: contractapi.Contract{Name: issuer.CCPACKING}},
+		&exporter.SmartContract{Contract: contractapi.Contract{Name: issuer.CCEXPORTER}},
+		&regulator.SmartContract{Contract: contractapi.Contract{Name: issuer.CCREGULATOR}},
+		&nstdaStaff.SmartContract{Contract: contractapi.Contract{Name: issuer.CCNSTDASTAFF}},
+	)
+}
@@ -0,0 +1,134 @@
+package combined_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	combined "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/combined/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+)
+
+const chaincodeName = "nstda"
+
+func newNetwork(t *testing.T) *issuertest.Network {
+	t.Helper()
+	chaincode, err := combined.NewChaincode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(chaincodeName, chaincode)
+	return network
+}
+
+func submit(t *testing.T, network *issuertest.Network, function string, args ...string) {
+	t.Helper()
+	if _, err := network.Submit(alice, chaincodeName, function, args...); err != nil {
+		t.Fatalf("%s: %v", function, err)
+	}
+}
+
+func TestContractsKeepTheirOwnState(t *testing.T) {
+	network := newNetwork(t)
+	submit(t, network, "gap:CreateGAP", `{"id":"1","certId":"GAP-001"}`)
+	submit(t, network, "farmer:CreateFarmer", `{"id":"1"}`)
+
+	stub := network.Stub(chaincodeName)
+	if stub.State("gap:1") == nil || stub.State("farmer:1") == nil || stub.State("1") != nil {
+		t.Fatalf("state keys = %q, want one key per contract", stub.Keys())
+	}
+
+	for _, function := range []string{"gap:GetAllGAP", "farmer:GetAllFarmer"} {
+		payload, err := network.Evaluate(alice, chaincodeName, function, `{"limit":0}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var response struct {
+			Total int `json:"total"`
+		}
+		if err := json.Unmarshal(payload, &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Total != 1 {
+			t.Errorf("%s total = %d, want only its own asset", function, response.Total)
+		}
+	}
+}
+
+func TestContractsCallEachOtherInProcess(t *testing.T) {
+	network := newNetwork(t)
+	submit(t, network, "gap:CreateGAP", `{"id":"G-1","certId":"GAP-001"}`)
+	submit(t, network, "farmer:CreateFarmer", `{"id":"F-1"}`)
+	if _, err := network.Submit(admin, chaincodeName, "nstda-staff:SetRegistrationStatus", `{"targetType":"farmer","targetId":"F-1","status":"APPROVED"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := network.Submit(alice, chaincodeName, "gap:SetGapFarmer", "G-1", "", "F-1"); err == nil {
+		t.Fatal("SetGapFarmer accepted a call that did not come through farmer")
+	}
+	submit(t, network, "farmer:LinkGapToFarmer", "F-1", "G-1")
+
+	payload, err := network.Evaluate(alice, chaincodeName, "gap:ReadAsset", "G-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gap struct {
+		FarmerID string `json:"farmerId"`
+	}
+	if err := json.Unmarshal(payload, &gap); err != nil {
+		t.Fatal(err)
+	}
+	if gap.FarmerID != "F-1" {
+		t.Fatalf("gap farmerId = %q, want F-1", gap.FarmerID)
+	}
+	if event := network.LastEvent(); event == nil || event.EventName != "farmer."+issuer.EVENTUPDATED {
+		t.Errorf("event = %v, want the farmer's own", event)
+	}
+}
+
+func TestMigrateBatchContinuesInNamespace(t *testing.T) {
+	network := newNetwork(t)
+	stub := network.Stub(chaincodeName)
+	for _, id := range []string{"G-1", "G-2", "G-3"} {
+		stub.Seed("gap:"+id, []byte(`{"contractNamespace":"gap","id":"`+id+`","certId":"C-`+id+`"}`))
+	}
+	stub.Seed("farmer:F-1", []byte(`{"contractNamespace":"farmer","id":"F-1"}`))
+
+	bookmark := ""
+	for batches := 1; ; batches++ {
+		payload, err := network.Submit(admin, chaincodeName, "gap:MigrateBatch", bookmark, "2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result issuer.MigrationResult
+		if err := json.Unmarshal(payload, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Done {
+			break
+		}
+		if batches == 2 {
+			t.Fatalf("not done after %d batches", batches)
+		}
+		bookmark = result.Bookmark
+	}
+
+	for _, key := range []string{"gap:G-1", "gap:G-2", "gap:G-3"} {
+		var record struct {
+			Namespace     string `json:"contractNamespace"`
+			SchemaVersion int    `json:"schemaVersion"`
+		}
+		if err := json.Unmarshal(stub.State(key), &record); err != nil || record.Namespace != "gap" || record.SchemaVersion == 0 {
+			t.Errorf("%s = %s, want it migrated in its namespace", key, stub.State(key))
+		}
+	}
+	if got := string(stub.State("farmer:F-1")); got != `{"contractNamespace":"farmer","id":"F-1"}` {
+		t.Errorf("farmer:F-1 = %s, want it untouched", got)
+	}
+}
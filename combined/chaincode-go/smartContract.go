@@ -0,0 +1,23 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"log"
+
+	combined "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/combined/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+func main() {
+	abacSmartContract, err := combined.NewChaincode()
+	if err != nil {
+		log.Panicf("Error creating combined chaincode: %v", err)
+	}
+
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
+		log.Panicf("Error starting combined chaincode: %v", err)
+	}
+}
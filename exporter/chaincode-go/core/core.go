@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/entity"
 )
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll) ([]*entity.TransectionReponse, error) {
-	var filter = map[string]interface{}{}
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllExporter) ([]*entity.TransectionExporterReponse, error) {
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	selector := map[string]interface{}{
 		"selector": filter,
@@ -30,15 +31,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryExporter.Close()
 
-	var dataExporter []*entity.TransectionReponse
+	var dataExporter []*entity.TransectionExporterReponse
 	for queryExporter.HasNext() {
 		queryRes, err := queryExporter.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataE entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataE)
+		var dataE entity.TransectionExporterReponse
+		err = Schema.Unmarshal(ctx, queryRes.Value, &dataE)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,6 @@
+package core
+
+import "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+
+// Schema upgrades stored exporters to the current entity.TransectionExporter.
+var Schema = issuer.NewSchema()
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+func ShipmentKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(entity.SHIPMENTDOCTYPE, []string{id})
+}
+
+func GetShipment(ctx contractapi.TransactionContextInterface, id string) (*entity.Shipment, error) {
+	key, err := ShipmentKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shipmentJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if shipmentJSON == nil {
+		return nil, fmt.Errorf("the shipment %s does not exist", id)
+	}
+
+	var shipment entity.Shipment
+	if err := json.Unmarshal(shipmentJSON, &shipment); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &shipment, nil
+}
+
+func PutShipment(ctx contractapi.TransactionContextInterface, shipment *entity.Shipment) ([]byte, error) {
+	key, err := ShipmentKey(ctx, shipment.Id)
+	if err != nil {
+		return nil, err
+	}
+	shipmentJSON, err := json.Marshal(shipment)
+	if err != nil {
+		return nil, err
+	}
+	return shipmentJSON, ctx.GetStub().PutState(key, shipmentJSON)
+}
+
+func shippedWeightKey(ctx contractapi.TransactionContextInterface, packingID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(entity.SHIPPEDWEIGHTDOCTYPE, []string{packingID})
+}
+
+func GetShippedWeight(ctx contractapi.TransactionContextInterface, packingID string) (*entity.ShippedWeight, error) {
+	key, err := shippedWeightKey(ctx, packingID)
+	if err != nil {
+		return nil, err
+	}
+	shippedJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if shippedJSON == nil {
+		return &entity.ShippedWeight{DocType: entity.SHIPPEDWEIGHTDOCTYPE, PackingID: packingID, ShipmentIDs: []string{}}, nil
+	}
+
+	var shipped entity.ShippedWeight
+	if err := json.Unmarshal(shippedJSON, &shipped); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &shipped, nil
+}
+
+func PutShippedWeight(ctx contractapi.TransactionContextInterface, shipped *entity.ShippedWeight) error {
+	key, err := shippedWeightKey(ctx, shipped.PackingID)
+	if err != nil {
+		return err
+	}
+	shippedJSON, err := json.Marshal(shipped)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, shippedJSON)
+}
+
+// GetPackingOrder reads a packing order from the packing chaincode.
+func GetPackingOrder(ctx contractapi.TransactionContextInterface, packingID string) (*entity.PackingOrder, error) {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCPACKING, "ReadAsset", packingID)
+	if err != nil {
+		return nil, err
+	}
+	var packing entity.PackingOrder
+	if err := json.Unmarshal(payload, &packing); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &packing, nil
+}
+
+// AssertNoPhytoCertificate fails while the regulator holds a phytosanitary
+// certificate in force for the shipment.
+func AssertNoPhytoCertificate(ctx contractapi.TransactionContextInterface, shipmentID string) error {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCREGULATOR, "GetShipmentPhytoCertificate", shipmentID)
+	if err != nil {
+		return err
+	}
+	var cert entity.PhytoCertificate
+	if err := json.Unmarshal(payload, &cert); err != nil {
+		return fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	if cert.Status == entity.PHYTOVALID || cert.Status == entity.PHYTONOTYETVALID {
+		return fmt.Errorf("shipment %s holds phytosanitary certificate %s; the regulator must revoke it first", shipmentID, cert.CertNo)
+	}
+	return nil
+}
+
+// SumItems totals the weight per packing order, in the order the orders
+// first appear in the shipment.
+func SumItems(items []entity.ShipmentItem) ([]string, map[string]float32) {
+	var packingIDs []string
+	weights := map[string]float32{}
+	for _, item := range items {
+		if _, ok := weights[item.PackingID]; !ok {
+			packingIDs = append(packingIDs, item.PackingID)
+		}
+		weights[item.PackingID] += item.Weight
+	}
+	return packingIDs, weights
+}
@@ -1,17 +1,27 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+const ENTITYNAME string = "exporter"
 
 type TransectionExporter struct {
-	Id        string    `json:"id"`
-	CertId    string    `json:"certId"`
+	Id        string    `json:"id" validate:"required,maxlen=128"`
+	CertId    string    `json:"certId" validate:"maxlen=128"`
+	Registration issuer.Registration `json:"registration"`
 	Owner     string    `json:"owner"`
 	OrgName   string    `json:"orgName"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip  int `json:"skip"`
+type FilterGetAllExporter struct {
+	Skip  int `json:"skip" metadata:",optional"`
 	Limit int `json:"limit"`
 }
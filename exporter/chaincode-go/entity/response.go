@@ -1,16 +1,21 @@
 package entity
 
-import "time"
+import (
+	"time"
 
-type TransectionReponse struct {
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+type TransectionExporterReponse struct {
 	Id        string    `json:"id"`
 	CertId    string    `json:"certId"`
+	Registration issuer.Registration `json:"registration"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllExporterReponse struct {
+	Data  string                        `json:"data"`
+	Obj   []*TransectionExporterReponse `json:"obj"`
+	Total int                           `json:"total"`
 }
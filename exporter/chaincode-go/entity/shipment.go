@@ -0,0 +1,65 @@
+package entity
+
+import "time"
+
+const (
+	SHIPMENTDOCTYPE      string = "shipment"
+	SHIPPEDWEIGHTDOCTYPE string = "shippedWeight"
+
+	SHIPMENTCREATED  string = "CREATED"
+	SHIPMENTCANCELED string = "CANCELED"
+
+	// Approval types of packing orders whose weight may be shipped.
+	PACKINGAPPROVED string = "APPROVED"
+	PACKINGPARTIAL  string = "PARTIAL"
+
+	// Statuses of a regulator phytosanitary certificate that is in force or
+	// about to be.
+	PHYTOVALID       string = "VALID"
+	PHYTONOTYETVALID string = "NOT_YET_VALID"
+)
+
+// Shipment is one export consignment made up of lots from packing orders.
+type Shipment struct {
+	DocType            string         `json:"docType"`
+	Id                 string         `json:"id" validate:"required,maxlen=128"`
+	ExporterID         string         `json:"exporterId" validate:"required,maxlen=128"`
+	DestinationCountry string         `json:"destinationCountry" validate:"required,maxlen=64"`
+	ContainerNo        string         `json:"containerNo" validate:"maxlen=64"`
+	PhytoCertNo        string         `json:"phytoCertNo" validate:"maxlen=128"`
+	PhytoCertDate      string         `json:"phytoCertDate" validate:"date"`
+	Items              []ShipmentItem `json:"items" validate:"required,dive"`
+	Status             string         `json:"status"`
+	Owner              string         `json:"owner"`
+	OrgName            string         `json:"orgName"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	CreatedAt          time.Time      `json:"createdAt"`
+}
+
+type ShipmentItem struct {
+	PackingID string  `json:"packingId" validate:"required,maxlen=128"`
+	Weight    float32 `json:"weight" validate:"gt=0"`
+}
+
+// ShippedWeight is the running total exported from one packing order.
+type ShippedWeight struct {
+	DocType       string   `json:"docType"`
+	PackingID     string   `json:"packingId"`
+	FinalWeight   float32  `json:"finalWeight"`
+	ShippedWeight float32  `json:"shippedWeight"`
+	ShipmentIDs   []string `json:"shipmentIds"`
+}
+
+// PackingOrder is the part of a packing chaincode record a shipment needs.
+type PackingOrder struct {
+	Id           string  `json:"id"`
+	FinalWeight  float32 `json:"finalWeight"`
+	ApprovedType string  `json:"approvedType"`
+}
+
+// PhytoCertificate is the part of the regulator's answer about a shipment's
+// phytosanitary certificate a cancellation needs.
+type PhytoCertificate struct {
+	CertNo string `json:"certNo"`
+	Status string `json:"status"`
+}
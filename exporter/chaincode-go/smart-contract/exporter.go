@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/core"
@@ -15,6 +16,21 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllExporter",
+		"FilterExporter",
+		"ReadShipment",
+		"GetShippedWeight",
+		"GetTransfer",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
 func (s *SmartContract) CreateExporter(
 	ctx contractapi.TransactionContextInterface,
 	args string,
@@ -26,6 +42,11 @@ func (s *SmartContract) CreateExporter(
 	}
 	input := inputInterface.(*entity.TransectionExporter)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("exporter.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
@@ -40,7 +61,7 @@ func (s *SmartContract) CreateExporter(
 		return fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	CreatedTime := issuer.GetTimeNow()
@@ -50,13 +71,20 @@ func (s *SmartContract) CreateExporter(
 		CertId:    input.CertId,
 		Owner:     clientID,
 		OrgName:   orgName,
+		Registration: issuer.NewRegistration(),
 		UpdatedAt: CreatedTime,
 		CreatedAt: CreatedTime,
+		SchemaVersion: core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
@@ -64,17 +92,25 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 
 	entityExporter := entity.TransectionExporter{}
 	inputInterface, err := issuer.Unmarshal(args, entityExporter)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionExporter)
 
-	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	asset, err := s.ReadAsset(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
-	if clientID != asset.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
 	}
 
 	UpdatedTime := issuer.GetTimeNow()
@@ -86,42 +122,86 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	assetJSON, errE := json.Marshal(asset)
 	issuer.HandleError(errE)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetE, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDExporter, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetE.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDExporter != assetE.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetE)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetE, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetE.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
 
-	if clientID != assetE.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetE, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetE.Owner)
+	if err != nil {
+		return err
 	}
 
-	assetE.Owner = newOwner
+	assetE.Owner = transfer.AcceptedBy
+	assetE.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetE)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionExporter, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -133,7 +213,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionExporter
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -141,16 +221,9 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &asset, nil
 }
 
-func (s *SmartContract) GetAllExporter(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllExporter(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllExporter) (*entity.GetAllExporterReponse, error) {
 
-	var filterE = map[string]interface{}{}
-
-	entityGetAll := entity.FilterGetAll{}
-	interfaceE, err := issuer.Unmarshal(args, entityGetAll)
-	if err != nil {
-		return nil, err
-	}
-	input := interfaceE.(*entity.FilterGetAll)
+	var filterE = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	queryStringE, err := issuer.BuildQueryString(filterE)
 	if err != nil {
@@ -166,7 +239,7 @@ func (s *SmartContract) GetAllExporter(ctx contractapi.TransactionContextInterfa
 		return nil, fmt.Errorf(issuer.SKIPOVER)
 	}
 
-	arrExporter, err := core.FetchResultsWithPagination(ctx, input)
+	arrExporter, err := core.FetchResultsWithPagination(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -176,10 +249,10 @@ func (s *SmartContract) GetAllExporter(ctx contractapi.TransactionContextInterfa
 	})
 
 	if len(arrExporter) == 0 {
-		arrExporter = []*entity.TransectionReponse{}
+		arrExporter = []*entity.TransectionExporterReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllExporterReponse{
 		Data:  "All Exporter",
 		Obj:   arrExporter,
 		Total: total,
@@ -200,14 +273,19 @@ func (s *SmartContract) FilterExporter(ctx contractapi.TransactionContextInterfa
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionExporter
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -222,3 +300,105 @@ func (s *SmartContract) FilterExporter(ctx contractapi.TransactionContextInterfa
 
 	return assetExporter, nil
 }
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionExporter{},
+	})
+}
+
+// SetRegistrationStatus moves a exporter registration through its lifecycle:
+// PENDING to APPROVED or REJECTED, APPROVED to SUSPENDED and back. It may
+// only be called through SetRegistrationStatus in the nstda-staff chaincode,
+// which logs the action, by an administrator.
+func (s *SmartContract) SetRegistrationStatus(ctx contractapi.TransactionContextInterface, id string, status string, reason string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.SetRegistrationStatus(ctx, &asset.Registration, status, reason)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = asset.Registration.DecidedAt
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, before, asset)
+}
+
+// AdminOverride corrects fields of a exporter record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionExporter{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
+}
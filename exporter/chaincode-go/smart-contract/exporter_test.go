@@ -0,0 +1,259 @@
+package exporter_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/entity"
+	exporter "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org2MSP", "bob", nil)
+)
+
+// newNetwork deploys the exporter chaincode next to a packing chaincode
+// holding approved packing orders K-1 (final weight 100) and K-2 (not weighed
+// yet), and K-3, rejected, a regulator chaincode holding phytosanitary
+// certificate PC-4 for shipment S-4, and an nstda-staff chaincode relaying
+// registration decisions.
+func newNetwork(t *testing.T) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&exporter.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCEXPORTER, chaincode)
+	network.Deploy(issuer.CCNSTDASTAFF, issuertest.NewFake().
+		Forwards("SetRegistrationStatus", issuer.CCEXPORTER))
+	network.Deploy(issuer.CCREGULATOR, issuertest.NewFake().
+		On("GetShipmentPhytoCertificate", func(args []string) ([]byte, error) {
+			if args[0] == "S-4" {
+				return json.Marshal(entity.PhytoCertificate{CertNo: "PC-4", Status: entity.PHYTOVALID})
+			}
+			return json.Marshal(entity.PhytoCertificate{Status: "NOT_FOUND"})
+		}))
+	network.Deploy(issuer.CCPACKING, issuertest.NewFake().
+		On("ReadAsset", func(args []string) ([]byte, error) {
+			order, ok := map[string]entity.PackingOrder{
+				"K-1": {FinalWeight: 100, ApprovedType: entity.PACKINGAPPROVED},
+				"K-2": {ApprovedType: entity.PACKINGAPPROVED},
+				"K-3": {FinalWeight: 50, ApprovedType: "REJECTED"},
+			}[args[0]]
+			if !ok {
+				return nil, fmt.Errorf("the asset %s does not exist", args[0])
+			}
+			order.Id = args[0]
+			return json.Marshal(order)
+		}))
+	return network
+}
+
+// newApprovedExporter creates exporter id owned by alice and approves it.
+func newApprovedExporter(t *testing.T, network *issuertest.Network, id string) {
+	t.Helper()
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateExporter", `{"id":"`+id+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "SetRegistrationStatus", id, issuer.REGAPPROVED, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func shippedWeight(t *testing.T, network *issuertest.Network, packingID string) *entity.ShippedWeight {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCEXPORTER, "GetShippedWeight", packingID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shipped entity.ShippedWeight
+	if err := json.Unmarshal(payload, &shipped); err != nil {
+		t.Fatal(err)
+	}
+	return &shipped
+}
+
+func TestCreateExporter(t *testing.T) {
+	network := newNetwork(t)
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{name: "valid", args: `{"id":"E-1","certId":"EXP-001"}`},
+		{name: "existing id", args: `{"id":"E-1"}`, wantErr: true},
+		{name: "missing id", args: `{"certId":"EXP-003"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCEXPORTER, "CreateExporter", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(bob, issuer.CCEXPORTER, "ReadAsset", "E-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionExporter
+	if err := json.Unmarshal(payload, &asset); err != nil {
+		t.Fatal(err)
+	}
+	if asset.OrgName != alice.MSPID || asset.Registration.Status != issuer.REGPENDING {
+		t.Fatalf("exporter = %+v", asset)
+	}
+}
+
+func TestCreateShipment(t *testing.T) {
+	network := newNetwork(t)
+	newApprovedExporter(t, network, "E-1")
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateExporter", `{"id":"E-2"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		args     string
+		wantErr  bool
+		wantKg   float32
+	}{
+		{name: "another exporter's id", identity: bob, args: `{"id":"S-0","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":40}]}`, wantErr: true},
+		{name: "first lot", args: `{"id":"S-1","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":40},{"packingId":"K-1","weight":20}]}`, wantKg: 60},
+		{name: "rest of the order", args: `{"id":"S-2","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-1","weight":40}]}`, wantKg: 100},
+		{name: "more than the final weight", args: `{"id":"S-3","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-1","weight":1}]}`, wantErr: true, wantKg: 100},
+		{name: "order not weighed", args: `{"id":"S-4","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-2","weight":1}]}`, wantErr: true, wantKg: 100},
+		{name: "rejected order", args: `{"id":"S-7","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-3","weight":1}]}`, wantErr: true, wantKg: 100},
+		{name: "pending exporter", args: `{"id":"S-5","exporterId":"E-2","destinationCountry":"CN","items":[{"packingId":"K-1","weight":1}]}`, wantErr: true, wantKg: 100},
+		{name: "zero weight", args: `{"id":"S-8","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-1","weight":0}]}`, wantErr: true, wantKg: 100},
+		{name: "negative weight", args: `{"id":"S-8","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-1","weight":-5}]}`, wantErr: true, wantKg: 100},
+		{name: "no items", args: `{"id":"S-6","exporterId":"E-1","destinationCountry":"CN","items":[]}`, wantErr: true, wantKg: 100},
+		{name: "existing id", args: `{"id":"S-1","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":1}]}`, wantErr: true, wantKg: 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity := test.identity
+			if identity == nil {
+				identity = alice
+			}
+			_, err := network.Submit(identity, issuer.CCEXPORTER, "CreateShipment", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := shippedWeight(t, network, "K-1").ShippedWeight; got != test.wantKg {
+				t.Fatalf("shipped weight = %v, want %v", got, test.wantKg)
+			}
+		})
+	}
+}
+
+func TestCancelShipment(t *testing.T) {
+	network := newNetwork(t)
+	newApprovedExporter(t, network, "E-1")
+	for _, args := range []string{
+		`{"id":"S-1","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":60}]}`,
+		`{"id":"S-2","exporterId":"E-1","destinationCountry":"CN","items":[{"packingId":"K-1","weight":30}]}`,
+		`{"id":"S-4","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":10}]}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateShipment", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name     string
+		identity *issuertest.Identity
+		wantErr  bool
+	}{
+		{name: "not the owner", identity: bob, wantErr: true},
+		{name: "owner", identity: alice},
+		{name: "canceled twice", identity: alice, wantErr: true},
+	}
+	for _, step := range steps {
+		if _, err := network.Submit(step.identity, issuer.CCEXPORTER, "CancelShipment", "S-1"); (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", step.name, err, step.wantErr)
+		}
+	}
+
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CancelShipment", "S-4"); err == nil {
+		t.Fatal("canceled a shipment holding a phytosanitary certificate")
+	}
+
+	shipped := shippedWeight(t, network, "K-1")
+	if shipped.ShippedWeight != 40 || fmt.Sprint(shipped.ShipmentIDs) != "[S-2 S-4]" {
+		t.Fatalf("shipped weight = %+v", shipped)
+	}
+	payload, err := network.Evaluate(alice, issuer.CCEXPORTER, "ReadShipment", "S-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shipment entity.Shipment
+	if err := json.Unmarshal(payload, &shipment); err != nil || shipment.Status != entity.SHIPMENTCANCELED {
+		t.Fatalf("shipment = %s, %v", payload, err)
+	}
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateShipment",
+		`{"id":"S-3","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":60}]}`); err != nil {
+		t.Fatalf("released weight not reusable: %v", err)
+	}
+}
+
+func TestGetAllExporter(t *testing.T) {
+	network := newNetwork(t)
+	newApprovedExporter(t, network, "E-1")
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateExporter", `{"id":"E-2"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(alice, issuer.CCEXPORTER, "CreateShipment",
+		`{"id":"S-1","exporterId":"E-1","destinationCountry":"JP","items":[{"packingId":"K-1","weight":10}]}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "exporters only", args: `{"limit":0}`, want: "[E-1 E-2]", wantTotal: 2},
+		{name: "skip and limit", args: `{"skip":1,"limit":1}`, want: "[E-2]", wantTotal: 2},
+		{name: "skip past the total", args: `{"skip":3,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCEXPORTER, "GetAllExporter", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllExporterReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("exporters = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
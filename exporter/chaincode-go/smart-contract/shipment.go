@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Rounding slack when comparing float32 weight totals, in kilograms.
+const WEIGHTEPSILON float32 = 0.001
+
+// CreateShipment records an export shipment for the caller's approved
+// exporter. Every packing order shipped from must be approved, fully or
+// partially, and the weight taken from it, added to what earlier shipments
+// took, may not exceed its FinalWeight, so a lot cannot be exported twice.
+func (s *SmartContract) CreateShipment(ctx contractapi.TransactionContextInterface, args string) error {
+	entityShipment := entity.Shipment{}
+	inputInterface, err := issuer.Unmarshal(args, entityShipment)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.Shipment)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+	if len(input.Items) == 0 {
+		return fmt.Errorf("shipment %s has no items", input.Id)
+	}
+
+	if _, err := core.GetShipment(ctx, input.Id); err == nil {
+		return fmt.Errorf("the shipment %s already exists", input.Id)
+	}
+
+	exporter, err := s.ReadAsset(ctx, input.ExporterID)
+	if err != nil {
+		return err
+	}
+	if status := exporter.Registration.CurrentStatus(); status != issuer.REGAPPROVED {
+		return fmt.Errorf("exporter %s is not approved (%s)", input.ExporterID, status)
+	}
+	err = issuer.AssertIdentity(ctx, exporter.Owner)
+	if err != nil {
+		return err
+	}
+
+	packingIDs, weights := core.SumItems(input.Items)
+	for _, packingID := range packingIDs {
+		packing, err := core.GetPackingOrder(ctx, packingID)
+		if err != nil {
+			return err
+		}
+		if packing.ApprovedType != entity.PACKINGAPPROVED && packing.ApprovedType != entity.PACKINGPARTIAL {
+			return fmt.Errorf("packing order %s is not approved (%s)", packingID, packing.ApprovedType)
+		}
+		if packing.FinalWeight <= 0 {
+			return fmt.Errorf("packing order %s has no final weight yet", packingID)
+		}
+
+		shipped, err := core.GetShippedWeight(ctx, packingID)
+		if err != nil {
+			return err
+		}
+		total := shipped.ShippedWeight + weights[packingID]
+		if total > packing.FinalWeight+WEIGHTEPSILON {
+			return fmt.Errorf("packing order %s: shipping %.3f more would exceed its final weight %.3f (%.3f already shipped)",
+				packingID, weights[packingID], packing.FinalWeight, shipped.ShippedWeight)
+		}
+
+		shipped.FinalWeight = packing.FinalWeight
+		shipped.ShippedWeight = total
+		shipped.ShipmentIDs = append(shipped.ShipmentIDs, input.Id)
+		err = core.PutShippedWeight(ctx, shipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	owner, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	input.DocType = entity.SHIPMENTDOCTYPE
+	input.Status = entity.SHIPMENTCREATED
+	input.Owner = owner
+	input.OrgName = orgName
+	input.UpdatedAt = now
+	input.CreatedAt = now
+	shipmentJSON, err := core.PutShipment(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.SHIPMENTDOCTYPE, issuer.EventItem{ID: input.Id, Payload: shipmentJSON})
+}
+
+// CancelShipment voids a shipment that did not leave and releases its
+// weights back to the packing orders. A shipment holding a phytosanitary
+// certificate in force cannot be canceled until the regulator revokes it.
+func (s *SmartContract) CancelShipment(ctx contractapi.TransactionContextInterface, id string) error {
+	shipment, err := core.GetShipment(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *shipment
+
+	err = issuer.AssertIdentity(ctx, shipment.Owner)
+	if err != nil {
+		return err
+	}
+	if shipment.Status == entity.SHIPMENTCANCELED {
+		return fmt.Errorf("the shipment %s is already canceled", id)
+	}
+	err = core.AssertNoPhytoCertificate(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	packingIDs, weights := core.SumItems(shipment.Items)
+	for _, packingID := range packingIDs {
+		shipped, err := core.GetShippedWeight(ctx, packingID)
+		if err != nil {
+			return err
+		}
+		shipped.ShippedWeight -= weights[packingID]
+		if shipped.ShippedWeight < 0 {
+			shipped.ShippedWeight = 0
+		}
+		shipmentIDs := []string{}
+		for _, shipmentID := range shipped.ShipmentIDs {
+			if shipmentID != id {
+				shipmentIDs = append(shipmentIDs, shipmentID)
+			}
+		}
+		shipped.ShipmentIDs = shipmentIDs
+		err = core.PutShippedWeight(ctx, shipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	shipment.Status = entity.SHIPMENTCANCELED
+	shipment.UpdatedAt = now
+	_, err = core.PutShipment(ctx, shipment)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.SHIPMENTDOCTYPE, id, before, shipment)
+}
+
+func (s *SmartContract) ReadShipment(ctx contractapi.TransactionContextInterface, id string) (*entity.Shipment, error) {
+	return core.GetShipment(ctx, id)
+}
+
+// GetShippedWeight returns how much of a packing order has been exported and
+// by which shipments.
+func (s *SmartContract) GetShippedWeight(ctx contractapi.TransactionContextInterface, packingID string) (*entity.ShippedWeight, error) {
+	return core.GetShippedWeight(ctx, packingID)
+}
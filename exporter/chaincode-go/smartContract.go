@@ -9,6 +9,7 @@ import (
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	exporter "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/exporter/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 )
 
 func main() {
@@ -17,7 +18,7 @@ func main() {
 		log.Panicf("Error creating nstda staff chaincode: %v", err)
 	}
 
-	if err := abacSmartContract.Start(); err != nil {
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
 		log.Panicf("Error starting nstda staff chaincode: %v", err)
 	}
 }
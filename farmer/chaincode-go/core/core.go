@@ -4,17 +4,25 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/entity"
 )
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll) ([]*entity.TransectionReponse, error) {
-	var filter = map[string]interface{}{}
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllFarmer) ([]*entity.TransectionFarmerReponse, error) {
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	if input.FarmerGap != "" {
-		filter["farmerGaps"] = map[string]interface{}{
-			"$elemMatch": map[string]interface{}{
-				"certId": input.FarmerGap,
-			},
+		gapID, err := getGapIDByCertID(ctx, input.FarmerGap)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = []interface{}{
+			map[string]interface{}{"gapIds": map[string]interface{}{"$elemMatch": map[string]interface{}{"$eq": gapID}}},
+			map[string]interface{}{"farmerGaps": map[string]interface{}{
+				"$elemMatch": map[string]interface{}{
+					"certId": input.FarmerGap,
+				},
+			}},
 		}
 	}
 	
@@ -38,22 +46,20 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryFarmer.Close()
 
-	var dataFarmers []*entity.TransectionReponse
+	var dataFarmers []*entity.TransectionFarmerReponse
 	for queryFarmer.HasNext() {
 		queryRes, err := queryFarmer.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataF entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataF)
+		var dataF entity.TransectionFarmerReponse
+		err = Schema.Unmarshal(ctx, queryRes.Value, &dataF)
 		if err != nil {
 			return nil, err
 		}
 		
-		 if dataF.FarmerGap == nil {
-			dataF.FarmerGap = []entity.FarmerGap{}
-	}
+		dataF.FarmerGap, dataF.UnresolvedGapIds = ResolveGaps(ctx, dataF.GapIds)
 
 		dataFarmers = append(dataFarmers, &dataF)
 	}
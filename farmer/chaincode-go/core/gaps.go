@@ -0,0 +1,90 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// GetFarmer reads a farmer as stored, without resolving its GAPs.
+func GetFarmer(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionFarmer, error) {
+	assetJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if assetJSON == nil {
+		return nil, fmt.Errorf("the asset %s does not exist", id)
+	}
+
+	var asset entity.TransectionFarmer
+	if err := Schema.Unmarshal(ctx, assetJSON, &asset); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &asset, nil
+}
+
+// GapIDs returns the IDs of the GAPs a client sent for a farmer. A client
+// still sending FarmerGaps snapshots links the GAPs they name.
+func GapIDs(gapIDs []string, snapshots []entity.FarmerGap) []string {
+	if len(gapIDs) > 0 {
+		return gapIDs
+	}
+	ids := []string{}
+	for _, snapshot := range snapshots {
+		if snapshot.Id != "" {
+			ids = append(ids, snapshot.Id)
+		}
+	}
+	return ids
+}
+
+// GetGap reads a GAP certificate from the gap chaincode.
+func GetGap(ctx contractapi.TransactionContextInterface, gapID string) (*entity.FarmerGap, error) {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCGAP, "ReadAsset", gapID)
+	if err != nil {
+		return nil, err
+	}
+	var gap entity.FarmerGap
+	if err := json.Unmarshal(payload, &gap); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &gap, nil
+}
+
+// ResolveGaps reads the current state of the given GAPs from the gap
+// chaincode, and returns the IDs of those it could not read, such as deleted
+// GAPs, separately.
+func ResolveGaps(ctx contractapi.TransactionContextInterface, gapIDs []string) ([]entity.FarmerGap, []string) {
+	gaps := []entity.FarmerGap{}
+	var unresolved []string
+	for _, gapID := range gapIDs {
+		gap, err := GetGap(ctx, gapID)
+		if err != nil {
+			unresolved = append(unresolved, gapID)
+			continue
+		}
+		gaps = append(gaps, *gap)
+	}
+	return gaps, unresolved
+}
+
+// getGapIDByCertID looks up the ID of the GAP holding certID, "" if none.
+func getGapIDByCertID(ctx contractapi.TransactionContextInterface, certID string) (string, error) {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCGAP, "GetGapByCertID", certID)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Obj *entity.FarmerGap `json:"obj"`
+	}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return "", fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	if response.Obj == nil {
+		return "", nil
+	}
+	return response.Obj.Id, nil
+}
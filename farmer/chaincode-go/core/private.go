@@ -0,0 +1,78 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+func HashPrivate(privateJSON []byte) string {
+	hash := sha256.Sum256(privateJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// GetTransientPrivate reads the private record passed in the transient map
+// under TRANSIENTPRIVATE, or nil if there is none.
+func GetTransientPrivate(ctx contractapi.TransactionContextInterface) (*entity.FarmerPrivate, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient data: %v", err)
+	}
+	privateJSON, ok := transientMap[entity.TRANSIENTPRIVATE]
+	if !ok {
+		return nil, nil
+	}
+
+	var private entity.FarmerPrivate
+	if err := json.Unmarshal(privateJSON, &private); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &private, nil
+}
+
+// GetTransientPrivates reads the private records of a batch, keyed by farmer
+// id, passed under TRANSIENTPRIVATES.
+func GetTransientPrivates(ctx contractapi.TransactionContextInterface) (map[string]*entity.FarmerPrivate, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient data: %v", err)
+	}
+	privates := map[string]*entity.FarmerPrivate{}
+	privatesJSON, ok := transientMap[entity.TRANSIENTPRIVATES]
+	if !ok {
+		return privates, nil
+	}
+	if err := json.Unmarshal(privatesJSON, &privates); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return privates, nil
+}
+
+// PutPrivate stores the private record of farmer id and returns the hash to
+// keep on the public record.
+func PutPrivate(ctx contractapi.TransactionContextInterface, id string, private *entity.FarmerPrivate) (string, error) {
+	private.Id = id
+	if err := issuer.Validate(private); err != nil {
+		return "", err
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	private.UpdatedAt = now
+
+	privateJSON, err := json.Marshal(private)
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().PutPrivateData(entity.PRIVATECOLLECTION, id, privateJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to put private data of %s: %v", id, err)
+	}
+	return HashPrivate(privateJSON), nil
+}
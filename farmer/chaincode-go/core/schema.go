@@ -0,0 +1,27 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Schema upgrades stored farmers to the current entity.TransectionFarmer.
+var Schema = issuer.NewSchema().
+	// 1: farmers link GAPs by ID in gapIds, resolved on read; older farmers
+	// held farmerGaps snapshots, whose IDs become their gapIds.
+	Register(1, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+		snapshots, _ := record["farmerGaps"].([]interface{})
+		delete(record, "farmerGaps")
+		if gapIDs, _ := record["gapIds"].([]interface{}); len(gapIDs) > 0 {
+			return nil
+		}
+		gapIDs := []interface{}{}
+		for _, snapshot := range snapshots {
+			gap, _ := snapshot.(map[string]interface{})
+			if id, _ := gap["id"].(string); id != "" {
+				gapIDs = append(gapIDs, id)
+			}
+		}
+		record["gapIds"] = gapIDs
+		return nil
+	})
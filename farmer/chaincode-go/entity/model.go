@@ -1,45 +1,61 @@
 package entity
 
 
-import "time"
+import (
+	"time"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
 
 const (
 	UNAUTHORIZE string = "client is not authorized to delete this asset"
 	TimeFormat  string = "02-01-2006T15:04:05Z"
 	SkipOver    string = "skip over total data"
+	ENTITYNAME  string = "farmer"
 )
 
 type TransectionFarmer struct {
-	Id        string    `json:"id"`
-	CertId    string    `json:"certId"`
+	Id        string    `json:"id" validate:"required,maxlen=128"`
+	CertId    string    `json:"certId" validate:"maxlen=128"`
+	PrivateHash string  `json:"privateHash"`
+	Registration issuer.Registration `json:"registration"`
 	Owner     string    `json:"owner"`
 	OrgName   string    `json:"orgName"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
-	FarmerGaps []FarmerGap `json:"farmerGaps"`
+	GapIds    []string  `json:"gapIds"`
+	// FarmerGaps is resolved from the gap chaincode on read and stored
+	// empty; clients may still send snapshots, linked by their IDs.
+	FarmerGaps []FarmerGap `json:"farmerGaps" validate:"dive"`
+	// UnresolvedGapIds are the GapIds the gap chaincode did not return on
+	// read, such as deleted GAPs. They are never stored.
+	UnresolvedGapIds []string `json:"unresolvedGapIds,omitempty" metadata:",optional"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip  int `json:"skip"`
-	Limit int `json:"limit"`
-	FarmerGap string `json:"farmerGap"`
+type FilterGetAllFarmer struct {
+	Skip      int    `json:"skip" metadata:",optional"`
+	Limit     int    `json:"limit"`
+	FarmerGap string `json:"farmerGap" metadata:",optional"`
 }
 
 type FarmerGap struct {
-	Id          string    `json:"id"`
-	CertID      string    `json:"certId"`
-	DisplayCertID      string    `json:"displayCertId"`
-	AreaCode    string    `json:"areaCode"`
-	AreaRai     float32   `json:"areaRai"`
-	AreaStatus  string    `json:"areaStatus"`
-	OldAreaCode string    `json:"oldAreaCode"`
-	IssueDate   string    `json:"issueDate"`
-	ExpireDate  string    `json:"expireDate"`
-	District    string    `json:"district"`
-	Province    string    `json:"province"`
-	UpdatedDate string    `json:"updatedDate"`
-	Source      string    `json:"source"`
-	FarmerID    string    `json:"farmerId"`
+	Id          string    `json:"id" validate:"maxlen=128"`
+	CertID      string    `json:"certId" validate:"maxlen=128"`
+	DisplayCertID      string    `json:"displayCertId" validate:"maxlen=128"`
+	AreaCode    string    `json:"areaCode" validate:"maxlen=128"`
+	AreaRai     float32   `json:"areaRai" validate:"min=0,max=100000"`
+	AreaStatus  string    `json:"areaStatus" validate:"maxlen=128"`
+	OldAreaCode string    `json:"oldAreaCode" validate:"maxlen=128"`
+	IssueDate   string    `json:"issueDate" validate:"date"`
+	ExpireDate  string    `json:"expireDate" validate:"date"`
+	District    string    `json:"district" validate:"maxlen=256"`
+	Province    string    `json:"province" validate:"maxlen=256"`
+	UpdatedDate string    `json:"updatedDate" validate:"date"`
+	Source      string    `json:"source" validate:"maxlen=256"`
+	FarmerID    string    `json:"farmerId" validate:"maxlen=128"`
 	Owner       string    `json:"owner"`
 	OrgName     string    `json:"orgName"`
 	UpdatedAt   time.Time `json:"updatedAt"`
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+const (
+	PRIVATECOLLECTION string = "farmerPrivateCollection"
+	// Transient map keys carrying the private record of CreateFarmer,
+	// UpdateAsset and SetFarmerPrivate, or a map of id to record for
+	// CreateFarmerCsv.
+	TRANSIENTPRIVATE  string = "farmerPrivate"
+	TRANSIENTPRIVATES string = "farmerPrivates"
+)
+
+// FarmerPrivate holds the personal data protected under the PDPA. It is kept
+// in PRIVATECOLLECTION under the farmer's id; the public record only carries
+// its SHA-256 hash. Salt should be a random value chosen by the client so the
+// hash cannot be matched by guessing national IDs.
+type FarmerPrivate struct {
+	Id          string    `json:"id" validate:"maxlen=128"`
+	NationalID  string    `json:"nationalId" validate:"required,maxlen=13"`
+	FirstName   string    `json:"firstName" validate:"required,maxlen=256"`
+	LastName    string    `json:"lastName" validate:"required,maxlen=256"`
+	Phone       string    `json:"phone" validate:"maxlen=32"`
+	BankName    string    `json:"bankName" validate:"maxlen=256"`
+	BankAccount string    `json:"bankAccount" validate:"maxlen=32"`
+	Salt        string    `json:"salt" validate:"required,maxlen=128"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -2,25 +2,31 @@ package entity
 
 import (
 	"time"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 )
 
-type TransectionReponse struct {
+type TransectionFarmerReponse struct {
 	Id        string    `json:"id"`
 	CertId    string    `json:"certId"`
+	PrivateHash string  `json:"privateHash"`
+	Registration issuer.Registration `json:"registration"`
+	GapIds    []string  `json:"gapIds"`
 	FarmerGap []FarmerGap `json:"farmerGaps"`
+	UnresolvedGapIds []string `json:"unresolvedGapIds,omitempty" metadata:",optional"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllFarmerReponse struct {
+	Data  string                      `json:"data"`
+	Obj   []*TransectionFarmerReponse `json:"obj"`
+	Total int                         `json:"total"`
 }
 
 type TransactionHistory struct {
-	TxId      string                `json:"tx_id"`
-	IsDelete  bool                  `json:"isDelete"`
-	Value     []*TransectionReponse `json:"value"`
-	Timestamp string                `json:"timestamp"`
+	TxId      string                      `json:"tx_id"`
+	IsDelete  bool                        `json:"isDelete"`
+	Value     []*TransectionFarmerReponse `json:"value"`
+	Timestamp string                      `json:"timestamp"`
 }
@@ -1,6 +1,7 @@
 package farmer
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -17,33 +18,65 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllFarmer",
+		"FilterFarmer",
+		"GetHistoryForKey",
+		"GetLastIdFarmer",
+		"ReadFarmerPrivate",
+		"VerifyFarmerPrivate",
+		"GetTransfer",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
+// CreateFarmer creates a farmer and returns its ID. Without an id in args the
+// ID is allocated with issuer.NextID.
 func (s *SmartContract) CreateFarmer(
 	ctx contractapi.TransactionContextInterface,
 	args string,
-) error {
+) (string, error) {
 	entityFarmer := entity.TransectionFarmer{}
 	inputInterface, err := issuer.Unmarshal(args, entityFarmer)
-
-	issuer.HandleError(err)
+	if err != nil {
+		return "", err
+	}
 	input := inputInterface.(*entity.TransectionFarmer)
 
+	if input.Id == "" {
+		input.Id, err = issuer.NextID(ctx, issuer.IDPREFIXFARMER)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return "", err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("farmer.creator", "true")
 	// if err != nil {
-	// 	return fmt.Errorf("submitting client not authorized to create asset, does not have abac.creator role")
+	// 	return "", fmt.Errorf("submitting client not authorized to create asset, does not have abac.creator role")
 	// }
 
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return fmt.Errorf("submitting client not authorized to create asset, does not have farmer.creator role")
+		return "", fmt.Errorf("submitting client not authorized to create asset, does not have farmer.creator role")
 	}
 
 	exists, err := issuer.AssetExists(ctx, input.Id)
 	issuer.HandleError(err)
 	if exists {
-		return fmt.Errorf("the asset %s already exists", input.Id)
+		return "", fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	CreatedAt := issuer.GetTimeNow()
@@ -53,73 +86,172 @@ func (s *SmartContract) CreateFarmer(
 		CertId:    input.CertId,
 		Owner:     clientID,
 		OrgName:   orgName,
+		Registration: issuer.NewRegistration(),
 		UpdatedAt: CreatedAt,
 		CreatedAt: CreatedAt,
-		FarmerGaps: input.FarmerGaps,
+		GapIds:    []string{},
+		SchemaVersion: core.Schema.Version(),
 	}
+
+	private, err := core.GetTransientPrivate(ctx)
+	if err != nil {
+		return "", err
+	}
+	if private != nil {
+		asset.PrivateHash, err = core.PutPrivate(ctx, input.Id, private)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return "", err
+	}
+
+	err = issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
+	if err != nil {
+		return "", err
+	}
+	return input.Id, nil
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	args string) error {
 	entityType := entity.TransectionFarmer{}
 	inputInterface, err := issuer.Unmarshal(args, entityType)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionFarmer)
 
-	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	asset, err := core.GetFarmer(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
 	UpdatedAt := issuer.GetTimeNow()
 
 	asset.Id = input.Id
 	asset.CertId = input.CertId
 	asset.UpdatedAt = UpdatedAt
-	asset.FarmerGaps = input.FarmerGaps
+
+	private, err := core.GetTransientPrivate(ctx)
+	if err != nil {
+		return err
+	}
+	if private != nil {
+		// Only the owner may replace the personal data, as in SetFarmerPrivate.
+		err = issuer.AssertIdentity(ctx, asset.Owner)
+		if err != nil {
+			return err
+		}
+		asset.PrivateHash, err = core.PutPrivate(ctx, input.Id, private)
+		if err != nil {
+			return err
+		}
+	}
 
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
-	asset, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientID != asset.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	if asset.PrivateHash != "" {
+		err = ctx.GetStub().DelPrivateData(entity.PRIVATECOLLECTION, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete private data of %s: %v", id, err)
+		}
+	}
+
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, asset)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
 
-	asset, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, asset.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
+
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
 
-	if clientID != asset.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	asset.Owner = newOwner
-	assetJSON, err := json.Marshal(asset)
-	issuer.HandleError(err)
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, asset.Owner)
+	if err != nil {
+		return err
+	}
 
+	asset.Owner = transfer.AcceptedBy
+	asset.UpdatedAt = transfer.UpdatedAt
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
+// ReadAsset returns a farmer with its GAPs read live from the gap chaincode.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionFarmer, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -131,28 +263,19 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionFarmer
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
-	
-	if asset.FarmerGaps == nil {
-		asset.FarmerGaps = []entity.FarmerGap{} 
-	}
+
+	asset.FarmerGaps, asset.UnresolvedGapIds = core.ResolveGaps(ctx, asset.GapIds)
 
 	return &asset, nil
 }
 
-func (s *SmartContract) GetAllFarmer(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllFarmer(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllFarmer) (*entity.GetAllFarmerReponse, error) {
 
-	var filter = map[string]interface{}{}
-
-	entityGetAll := entity.FilterGetAll{}
-	inputInterface, err := issuer.Unmarshal(args, entityGetAll)
-	if err != nil {
-		return nil, err
-	}
-	input := inputInterface.(*entity.FilterGetAll)
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	queryString, err := issuer.BuildQueryString(filter)
 	if err != nil {
@@ -168,7 +291,7 @@ func (s *SmartContract) GetAllFarmer(ctx contractapi.TransactionContextInterface
 		return nil, fmt.Errorf(issuer.SKIPOVER)
 	}
 
-	arrFarmer, err := core.FetchResultsWithPagination(ctx, input)
+	arrFarmer, err := core.FetchResultsWithPagination(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -178,14 +301,14 @@ func (s *SmartContract) GetAllFarmer(ctx contractapi.TransactionContextInterface
 	})
 
 	if len(arrFarmer) == 0 {
-		arrFarmer = []*entity.TransectionReponse{}
+		arrFarmer = []*entity.TransectionFarmerReponse{}
 	}
 	
 	for _, farmer := range arrFarmer {
 		log.Printf("farmer item %v", farmer)
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllFarmerReponse{
 		Data:  "All Farmer",
 		Obj:   arrFarmer,
 		Total: total,
@@ -206,14 +329,19 @@ func (s *SmartContract) FilterFarmer(ctx contractapi.TransactionContextInterface
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionFarmer
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -237,7 +365,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 	defer farmerResultsIterator.Close()
 
 	var farmerHistory []*entity.TransactionHistory
-	var farmerAssets []*entity.TransectionReponse
+	var farmerAssets []*entity.TransectionFarmerReponse
 	for farmerResultsIterator.HasNext() {
 		// Get the next history record
 		farmer, err := farmerResultsIterator.Next()
@@ -245,7 +373,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 			return nil, fmt.Errorf("failed to get next history farmer for key %s: %v", key, err)
 		}
 
-		var asset entity.TransectionReponse
+		var asset entity.TransectionFarmerReponse
 		if !farmer.IsDelete {
 			err = json.Unmarshal(farmer.Value, &asset)
 			if err != nil {
@@ -254,7 +382,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 			farmerAssets = append(farmerAssets, &asset)
 
 		} else {
-			farmerAssets = []*entity.TransectionReponse{}
+			farmerAssets = []*entity.TransectionFarmerReponse{}
 		}
 		// Convert the timestamp to string in the desired format
 		timestampStr := time.Unix(farmer.Timestamp.Seconds, int64(farmer.Timestamp.Nanos)).Format(issuer.TIMEFORMAT)
@@ -272,47 +400,35 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 	return farmerHistory, nil
 }
 
-func (s *SmartContract) GetLastIdFarmer(ctx contractapi.TransactionContextInterface) string {
-	// Query to get all records sorted by ID in descending order
-	query := `{
-			"selector": {},
-			"sort": [{"_id": "desc"}],
-			"limit": 1,
-			"use_index": "index-id"
-	}`
-
-	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
-	if err != nil {
-		return "error querying CouchDB"
-	}
-	defer resultsIterator.Close()
-
-	// Check if there is a result
-	if !resultsIterator.HasNext() {
-		return ""
-	}
-
-	// Get the first (and only) result
-	queryResponse, err := resultsIterator.Next()
+// GetLastIdFarmer returns the last farmer ID allocated this year, or "" if none
+// was. CreateFarmer allocates IDs itself; clients should not derive the next ID
+// from this one.
+func (s *SmartContract) GetLastIdFarmer(ctx contractapi.TransactionContextInterface) (string, error) {
+	counter, err := issuer.GetIDCounter(ctx, issuer.IDPREFIXFARMER)
 	if err != nil {
-		return "error iterating query results"
+		return "", err
 	}
+	return counter.LastID, nil
+}
 
-	var result struct {
+// SaveUserEvent publishes a client supplied record without touching state.
+// args is forwarded as the payload as-is when it is JSON, keyed by its "id".
+func (s *SmartContract) SaveUserEvent(ctx contractapi.TransactionContextInterface, args string) error {
+	var userEvent struct {
 		Id string `json:"id"`
 	}
-
-	// Unmarshal the result into the result struct
-	if err := json.Unmarshal(queryResponse.Value, &result); err != nil {
-		return "error unmarshalling document"
+	payload := json.RawMessage(args)
+	if json.Valid(payload) {
+		json.Unmarshal(payload, &userEvent)
+	} else {
+		payloadJSON, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		payload = payloadJSON
 	}
 
-	return result.Id
-}
-
-func (s *SmartContract) SaveUserEvent(ctx contractapi.TransactionContextInterface, args string) {
-	assetJSON, _ := json.Marshal(args)
-	ctx.GetStub().SetEvent("SaveUserEvent", assetJSON)
+	return issuer.EmitEvent(ctx, "userEvent.saved", entity.ENTITYNAME, issuer.EventItem{ID: userEvent.Id, Payload: payload})
 }
 
 func (s *SmartContract) CreateFarmerCsv(
@@ -320,13 +436,23 @@ func (s *SmartContract) CreateFarmerCsv(
 	args string,
 ) error {
 	var inputs []entity.TransectionFarmer
-	var eventPayloads []entity.TransectionFarmer
+	var eventItems []issuer.EventItem
 
 	errInput := json.Unmarshal([]byte(args), &inputs)
 	if errInput != nil {
 		return fmt.Errorf("failed to unmarshal JSON array: %v", errInput)
 	}
 
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
+
+	privates, err := core.GetTransientPrivates(ctx)
+	if err != nil {
+		return err
+	}
+
 	for _, input := range inputs {
 		orgName, err := ctx.GetClientIdentity().GetMSPID()
 		if err != nil {
@@ -341,7 +467,7 @@ func (s *SmartContract) CreateFarmerCsv(
 			return fmt.Errorf("the asset %s already exists", input.Id)
 		}
 
-		clientID, err := issuer.GetIdentity(ctx)
+		clientID, err := issuer.GetOwnerID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get submitting client's identity: %v", err)
 		}
@@ -349,18 +475,27 @@ func (s *SmartContract) CreateFarmerCsv(
 		asset := entity.TransectionFarmer{
 			Id:        input.Id,
 			CertId:    input.CertId,
-			FarmerGaps: input.FarmerGaps,
+			GapIds:    core.GapIDs(input.GapIds, input.FarmerGaps),
 			Owner:     clientID,
 			OrgName:   orgName,
+			Registration: issuer.NewRegistration(),
 			UpdatedAt: input.CreatedAt,
 			CreatedAt: input.UpdatedAt,
+			SchemaVersion: core.Schema.Version(),
+		}
+
+		if private, ok := privates[input.Id]; ok {
+			asset.PrivateHash, err = core.PutPrivate(ctx, input.Id, private)
+			if err != nil {
+				return err
+			}
 		}
 
 		assetJSON, err := json.Marshal(asset)
-		eventPayloads = append(eventPayloads, asset)
 		if err != nil {
 			return fmt.Errorf("failed to marshal asset JSON: %v", err)
 		}
+		eventItems = append(eventItems, issuer.EventItem{ID: input.Id, Payload: assetJSON})
 
 		err = ctx.GetStub().PutState(input.Id, assetJSON)
 		if err != nil {
@@ -371,11 +506,301 @@ func (s *SmartContract) CreateFarmerCsv(
 
 	}
 
-	eventPayloadJSON, err := json.Marshal(eventPayloads)
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ENTITYNAME, eventItems...)
+}
+
+// SetFarmerPrivate replaces the private record of a farmer with the one
+// passed in the transient map under entity.TRANSIENTPRIVATE.
+func (s *SmartContract) SetFarmerPrivate(ctx contractapi.TransactionContextInterface, id string) error {
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	private, err := core.GetTransientPrivate(ctx)
+	if err != nil {
+		return err
+	}
+	if private == nil {
+		return fmt.Errorf("transient data %s is required", entity.TRANSIENTPRIVATE)
+	}
+
+	asset.PrivateHash, err = core.PutPrivate(ctx, id, private)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = private.UpdatedAt
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, before, asset)
+}
+
+// ReadFarmerPrivate returns the private record of a farmer. Only peers of
+// the orgs in the collection hold the data, so other orgs get an error.
+func (s *SmartContract) ReadFarmerPrivate(ctx contractapi.TransactionContextInterface, id string) (*entity.FarmerPrivate, error) {
+	privateJSON, err := ctx.GetStub().GetPrivateData(entity.PRIVATECOLLECTION, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data of %s: %v", id, err)
+	}
+	if privateJSON == nil {
+		return nil, fmt.Errorf("no private data found for farmer %s", id)
+	}
+
+	var private entity.FarmerPrivate
+	err = json.Unmarshal(privateJSON, &private)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &private, nil
+}
+
+// VerifyFarmerPrivate checks the public hash of a farmer against the hash of
+// the private record the collection holds and, when a record is passed in the
+// transient map under entity.TRANSIENTPRIVATE, against that exact record.
+// Any org can call it; no private data is returned.
+func (s *SmartContract) VerifyFarmerPrivate(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if asset.PrivateHash == "" {
+		return false, nil
+	}
+
+	storedHash, err := ctx.GetStub().GetPrivateDataHash(entity.PRIVATECOLLECTION, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private data hash of %s: %v", id, err)
+	}
+	if hex.EncodeToString(storedHash) != asset.PrivateHash {
+		return false, nil
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return false, fmt.Errorf("failed to get transient data: %v", err)
+	}
+	if candidateJSON, ok := transientMap[entity.TRANSIENTPRIVATE]; ok {
+		return core.HashPrivate(candidateJSON) == asset.PrivateHash, nil
+	}
+	return true, nil
+}
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionFarmer{},
+	})
+}
+
+// SetRegistrationStatus moves a farmer registration through its lifecycle:
+// PENDING to APPROVED or REJECTED, APPROVED to SUSPENDED and back. It may
+// only be called through SetRegistrationStatus in the nstda-staff chaincode,
+// which logs the action, by an administrator.
+func (s *SmartContract) SetRegistrationStatus(ctx contractapi.TransactionContextInterface, id string, status string, reason string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.SetRegistrationStatus(ctx, &asset.Registration, status, reason)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = asset.Registration.DecidedAt
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, before, asset)
+}
+
+// AdminOverride corrects fields of a farmer record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionFarmer{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
+}
+
+// LinkGapToFarmer adds a GAP certificate to a farmer and sets the GAP's
+// farmerId in the gap chaincode in the same transaction. The farmer's owner
+// or an administrator may call it; a GAP belongs to one farmer at a time, and
+// only to an approved farmer.
+func (s *SmartContract) LinkGapToFarmer(ctx contractapi.TransactionContextInterface, farmerID string, gapID string) error {
+	asset, err := s.getOwnFarmer(ctx, farmerID)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	if status := asset.Registration.CurrentStatus(); status != issuer.REGAPPROVED {
+		return fmt.Errorf("farmer %s is not approved (%s)", farmerID, status)
+	}
+
+	gapIDs := asset.GapIds
+	for _, id := range gapIDs {
+		if id == gapID {
+			return fmt.Errorf("GAP %s is already linked to farmer %s", gapID, farmerID)
+		}
+	}
+
+	gap, err := core.GetGap(ctx, gapID)
+	if err != nil {
+		return err
+	}
+	if gap.FarmerID != "" && gap.FarmerID != farmerID {
+		return fmt.Errorf("GAP %s is linked to farmer %s", gapID, gap.FarmerID)
+	}
+	_, err = issuer.Invoke(ctx, issuer.CCGAP, "SetGapFarmer", gapID, gap.FarmerID, farmerID)
+	if err != nil {
+		return err
+	}
+
+	return s.putGapIDs(ctx, &before, asset, append(gapIDs, gapID))
+}
+
+// UnlinkGapFromFarmer removes a GAP certificate from a farmer and clears the
+// GAP's farmerId if it still names this farmer.
+func (s *SmartContract) UnlinkGapFromFarmer(ctx contractapi.TransactionContextInterface, farmerID string, gapID string) error {
+	asset, err := s.getOwnFarmer(ctx, farmerID)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	linked := false
+	gapIDs := []string{}
+	for _, id := range asset.GapIds {
+		if id == gapID {
+			linked = true
+			continue
+		}
+		gapIDs = append(gapIDs, id)
+	}
+	if !linked {
+		return fmt.Errorf("GAP %s is not linked to farmer %s", gapID, farmerID)
+	}
+
+	// A GAP deleted from the gap chaincode only needs dropping from the list.
+	if gap, err := core.GetGap(ctx, gapID); err == nil && gap.FarmerID == farmerID {
+		_, err = issuer.Invoke(ctx, issuer.CCGAP, "SetGapFarmer", gapID, farmerID, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.putGapIDs(ctx, &before, asset, gapIDs)
+}
+
+// getOwnFarmer reads a farmer the submitting client owns or administers.
+func (s *SmartContract) getOwnFarmer(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionFarmer, error) {
+	asset, err := core.GetFarmer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = issuer.AssertOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// putGapIDs stores a farmer's new GAP list.
+func (s *SmartContract) putGapIDs(ctx contractapi.TransactionContextInterface, before, asset *entity.TransectionFarmer, gapIDs []string) error {
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.GapIds = gapIDs
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(asset.Id, assetJSON)
 	if err != nil {
-		return fmt.Errorf("failed to marshal asset JSON: %v", err)
+		return err
 	}
-	ctx.GetStub().SetEvent("batchCreatedUserEvent", eventPayloadJSON)
 
-	return nil
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, asset.Id, *before, asset)
 }
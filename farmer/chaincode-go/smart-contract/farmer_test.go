@@ -0,0 +1,388 @@
+package farmer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/entity"
+	farmer "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org2MSP", "bob", nil)
+)
+
+// newNetwork deploys the farmer chaincode next to a gap chaincode holding
+// gaps, keyed by ID, whose farmerId SetGapFarmer updates, and an nstda-staff
+// chaincode relaying registration decisions.
+func newNetwork(t *testing.T, gaps map[string]*entity.FarmerGap) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&farmer.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCFARMER, chaincode)
+	network.Deploy(issuer.CCNSTDASTAFF, issuertest.NewFake().
+		Forwards("SetRegistrationStatus", issuer.CCFARMER))
+	network.Deploy(issuer.CCGAP, issuertest.NewFake().
+		On("ReadAsset", func(args []string) ([]byte, error) {
+			gap, ok := gaps[args[0]]
+			if !ok {
+				return nil, fmt.Errorf("the asset %s does not exist", args[0])
+			}
+			return json.Marshal(gap)
+		}).
+		On("GetGapByCertID", func(args []string) ([]byte, error) {
+			response := map[string]interface{}{"data": "Gap by certId", "obj": nil}
+			for _, gap := range gaps {
+				if gap.CertID == args[0] {
+					response["obj"] = gap
+				}
+			}
+			return json.Marshal(response)
+		}).
+		On("SetGapFarmer", func(args []string) ([]byte, error) {
+			gap := gaps[args[0]]
+			if gap.FarmerID != args[1] {
+				return nil, fmt.Errorf("GAP %s is linked to farmer %s", args[0], gap.FarmerID)
+			}
+			gap.FarmerID = args[2]
+			return nil, nil
+		}))
+	return network
+}
+
+// approveFarmer approves a farmer through the nstda-staff chaincode.
+func approveFarmer(t *testing.T, network *issuertest.Network, id string) {
+	t.Helper()
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "SetRegistrationStatus", id, issuer.REGAPPROVED, "checked"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFarmer(t *testing.T, network *issuertest.Network, id string) *entity.TransectionFarmer {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCFARMER, "ReadAsset", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionFarmer
+	if err := json.Unmarshal(payload, &asset); err != nil {
+		t.Fatal(err)
+	}
+	return &asset
+}
+
+func TestCreateFarmer(t *testing.T) {
+	network := newNetwork(t, nil)
+	network.Stub(issuer.CCFARMER).Seed("F-1", []byte(`{"id":"F-1"}`))
+
+	tests := []struct {
+		name    string
+		args    string
+		wantID  string
+		wantErr bool
+	}{
+		{name: "allocated id", args: `{"certId":"C-1"}`, wantID: "FRM-2024-000001"},
+		{name: "explicit id", args: `{"id":"F-2","certId":"C-2"}`, wantID: "F-2"},
+		{name: "existing id", args: `{"id":"F-1"}`, wantErr: true},
+		{name: "invalid json", args: `{"id":`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("created %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != test.wantID {
+				t.Fatalf("id = %s, want %s", payload, test.wantID)
+			}
+			asset := readFarmer(t, network, test.wantID)
+			if asset.Registration.Status != issuer.REGPENDING || asset.OrgName != alice.MSPID {
+				t.Fatalf("farmer = %+v", asset)
+			}
+			if event := network.LastEvent(); event.EventName != "farmer.created" {
+				t.Fatalf("event = %s", event.EventName)
+			}
+		})
+	}
+}
+
+func TestFarmerPrivateData(t *testing.T) {
+	network := newNetwork(t, nil)
+	private := []byte(`{"nationalId":"1234567890123","firstName":"Somchai","lastName":"Jaidee","salt":"s1"}`)
+	_, err := network.SubmitTransient(alice, issuer.CCFARMER, map[string][]byte{entity.TRANSIENTPRIVATE: private},
+		"CreateFarmer", `{"id":"F-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCFARMER, "ReadFarmerPrivate", "F-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored entity.FarmerPrivate
+	if err := json.Unmarshal(payload, &stored); err != nil || stored.NationalID != "1234567890123" {
+		t.Fatalf("private = %s, %v", payload, err)
+	}
+	if asset := readFarmer(t, network, "F-1"); asset.PrivateHash == "" {
+		t.Fatal("public record has no private hash")
+	}
+
+	payload, err = network.Evaluate(bob, issuer.CCFARMER, "VerifyFarmerPrivate", "F-1")
+	if err != nil || string(payload) != "true" {
+		t.Fatalf("VerifyFarmerPrivate = %s, %v", payload, err)
+	}
+
+	_, err = network.SubmitTransient(bob, issuer.CCFARMER, map[string][]byte{entity.TRANSIENTPRIVATE: private},
+		"SetFarmerPrivate", "F-1")
+	if err == nil {
+		t.Fatal("a client other than the owner replaced the private record")
+	}
+	_, err = network.SubmitTransient(bob, issuer.CCFARMER, map[string][]byte{entity.TRANSIENTPRIVATE: private},
+		"UpdateAsset", `{"id":"F-1"}`)
+	if err == nil {
+		t.Fatal("a client other than the owner replaced the private record through UpdateAsset")
+	}
+	_, err = network.SubmitTransient(alice, issuer.CCFARMER, map[string][]byte{entity.TRANSIENTPRIVATE: private},
+		"UpdateAsset", `{"id":"F-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFarmerRegistration(t *testing.T) {
+	network := newNetwork(t, nil)
+	if _, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", `{"id":"F-1"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		direct   bool
+		status   string
+		want     string
+		wantErr  bool
+	}{
+		{name: "admin bypassing nstda-staff", identity: admin, direct: true, status: issuer.REGAPPROVED, want: issuer.REGPENDING, wantErr: true},
+		{name: "owner cannot approve", identity: alice, status: issuer.REGAPPROVED, want: issuer.REGPENDING, wantErr: true},
+		{name: "admin approves", identity: admin, status: issuer.REGAPPROVED, want: issuer.REGAPPROVED},
+		{name: "admin suspends", identity: admin, status: issuer.REGSUSPENDED, want: issuer.REGSUSPENDED},
+		{name: "suspended cannot be rejected", identity: admin, status: issuer.REGREJECTED, want: issuer.REGSUSPENDED, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chaincode := issuer.CCNSTDASTAFF
+			if test.direct {
+				chaincode = issuer.CCFARMER
+			}
+			_, err := network.Submit(test.identity, chaincode, "SetRegistrationStatus", "F-1", test.status, "checked")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := readFarmer(t, network, "F-1").Registration.Status; got != test.want {
+				t.Fatalf("status = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLinkGapToFarmer(t *testing.T) {
+	gaps := map[string]*entity.FarmerGap{
+		"G-1": {Id: "G-1", CertID: "GAP-001"},
+		"G-2": {Id: "G-2", CertID: "GAP-002", FarmerID: "F-9"},
+	}
+	network := newNetwork(t, gaps)
+	if _, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", `{"id":"F-1"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(alice, issuer.CCFARMER, "LinkGapToFarmer", "F-1", "G-1"); err == nil {
+		t.Fatal("a pending farmer linked a GAP")
+	}
+	approveFarmer(t, network, "F-1")
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		function string
+		gapID    string
+		wantErr  bool
+		wantGaps string
+	}{
+		{name: "owner links", identity: alice, function: "LinkGapToFarmer", gapID: "G-1", wantGaps: "[G-1]"},
+		{name: "linked twice", identity: alice, function: "LinkGapToFarmer", gapID: "G-1", wantErr: true, wantGaps: "[G-1]"},
+		{name: "gap of another farmer", identity: alice, function: "LinkGapToFarmer", gapID: "G-2", wantErr: true, wantGaps: "[G-1]"},
+		{name: "not the owner", identity: bob, function: "UnlinkGapFromFarmer", gapID: "G-1", wantErr: true, wantGaps: "[G-1]"},
+		{name: "admin unlinks", identity: admin, function: "UnlinkGapFromFarmer", gapID: "G-1", wantGaps: "[]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCFARMER, test.function, "F-1", test.gapID)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			asset := readFarmer(t, network, "F-1")
+			if got := fmt.Sprint(asset.GapIds); got != test.wantGaps {
+				t.Fatalf("gapIds = %s, want %s", got, test.wantGaps)
+			}
+			if len(asset.FarmerGaps) != len(asset.GapIds) {
+				t.Fatalf("resolved %d GAPs for %d IDs", len(asset.FarmerGaps), len(asset.GapIds))
+			}
+		})
+	}
+	if gaps["G-1"].FarmerID != "" || gaps["G-2"].FarmerID != "F-9" {
+		t.Fatalf("gap farmers = %q, %q", gaps["G-1"].FarmerID, gaps["G-2"].FarmerID)
+	}
+}
+
+func TestReadFarmerWithDeletedGap(t *testing.T) {
+	gaps := map[string]*entity.FarmerGap{
+		"G-1": {Id: "G-1", CertID: "GAP-001"},
+		"G-2": {Id: "G-2", CertID: "GAP-002"},
+	}
+	network := newNetwork(t, gaps)
+	if _, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", `{"id":"F-1"}`); err != nil {
+		t.Fatal(err)
+	}
+	approveFarmer(t, network, "F-1")
+	for _, gapID := range []string{"G-1", "G-2"} {
+		if _, err := network.Submit(alice, issuer.CCFARMER, "LinkGapToFarmer", "F-1", gapID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	delete(gaps, "G-2")
+
+	asset := readFarmer(t, network, "F-1")
+	if len(asset.FarmerGaps) != 1 || fmt.Sprint(asset.UnresolvedGapIds) != "[G-2]" {
+		t.Fatalf("farmerGaps = %+v, unresolvedGapIds = %v", asset.FarmerGaps, asset.UnresolvedGapIds)
+	}
+	if state := network.Stub(issuer.CCFARMER).State("F-1"); strings.Contains(string(state), "unresolvedGapIds") {
+		t.Fatalf("unresolved GAP IDs stored: %s", state)
+	}
+}
+
+func TestMigrateFarmer(t *testing.T) {
+	gaps := map[string]*entity.FarmerGap{
+		"G-1": {Id: "G-1", CertID: "GAP-001", FarmerID: "F-1"},
+		"G-2": {Id: "G-2", CertID: "GAP-002"},
+	}
+	network := newNetwork(t, gaps)
+	// A farmer stored with a GAP snapshot, before gapIds and schema versions.
+	stub := network.Stub(issuer.CCFARMER)
+	stub.Seed("F-1", []byte(`{"id":"F-1","owner":"`+alice.ClientID()+`","farmerGaps":[{"id":"G-1","certId":"GAP-000"}]}`))
+
+	asset := readFarmer(t, network, "F-1")
+	if fmt.Sprint(asset.GapIds) != "[G-1]" || len(asset.FarmerGaps) != 1 || asset.FarmerGaps[0].CertID != "GAP-001" {
+		t.Fatalf("ReadAsset = %+v, want the legacy farmer upgraded", asset)
+	}
+	payload, err := network.Submit(admin, issuer.CCFARMER, "MigrateBatch", "", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result issuer.MigrationResult
+	if err := json.Unmarshal(payload, &result); err != nil || result.Migrated != 1 || !result.Done {
+		t.Fatalf("MigrateBatch = %s, %v", payload, err)
+	}
+	if state := string(stub.State("F-1")); strings.Contains(state, "farmerGaps") || !strings.Contains(state, `"gapIds":["G-1"]`) {
+		t.Fatalf("stored farmer = %s", state)
+	}
+
+	if _, err := network.Submit(alice, issuer.CCFARMER, "LinkGapToFarmer", "F-1", "G-2"); err != nil {
+		t.Fatal(err)
+	}
+	if asset := readFarmer(t, network, "F-1"); fmt.Sprint(asset.GapIds) != "[G-1 G-2]" {
+		t.Fatalf("gapIds = %v, want [G-1 G-2]", asset.GapIds)
+	}
+}
+
+func TestDeleteFarmer(t *testing.T) {
+	network := newNetwork(t, nil)
+	if _, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", `{"id":"F-1"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(bob, issuer.CCFARMER, "DeleteAsset", "F-1"); err == nil {
+		t.Fatal("a client other than the owner deleted the farmer")
+	}
+	if _, err := network.Submit(alice, issuer.CCFARMER, "DeleteAsset", "F-1"); err != nil {
+		t.Fatal(err)
+	}
+	if network.Stub(issuer.CCFARMER).State("F-1") != nil {
+		t.Fatal("farmer still stored")
+	}
+}
+
+func TestGetAllFarmer(t *testing.T) {
+	gaps := map[string]*entity.FarmerGap{
+		"G-1": {Id: "G-1", CertID: "GAP-001"},
+		"G-2": {Id: "G-2", CertID: "GAP-002", FarmerID: "F-2"},
+	}
+	network := newNetwork(t, gaps)
+	network.Stub(issuer.CCFARMER).Seed("F-2", []byte(`{"id":"F-2","farmerGaps":[{"id":"G-2","certId":"GAP-002"}]}`))
+	if _, err := network.Submit(alice, issuer.CCFARMER, "CreateFarmer", `{"id":"F-1"}`); err != nil {
+		t.Fatal(err)
+	}
+	approveFarmer(t, network, "F-1")
+	for _, step := range [][]string{
+		{"LinkGapToFarmer", "F-1", "G-1"},
+		{"CreateFarmer", `{"id":"F-3"}`},
+	} {
+		if _, err := network.Submit(alice, issuer.CCFARMER, step[0], step[1:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "all, oldest update first", args: `{"limit":0}`, want: "[F-2 F-1 F-3]", wantTotal: 3},
+		{name: "linked GAP", args: `{"farmerGap":"GAP-001","limit":0}`, want: "[F-1]", wantTotal: 3},
+		{name: "legacy GAP snapshot", args: `{"farmerGap":"GAP-002","limit":0}`, want: "[F-2]", wantTotal: 3},
+		{name: "unknown GAP", args: `{"farmerGap":"GAP-404","limit":0}`, want: "[]", wantTotal: 3},
+		{name: "skip and limit", args: `{"skip":1,"limit":1}`, want: "[F-2]", wantTotal: 3},
+		{name: "skip past the total", args: `{"skip":4,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCFARMER, "GetAllFarmer", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllFarmerReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, farmer := range response.Obj {
+				ids = append(ids, farmer.Id)
+			}
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("farmers = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
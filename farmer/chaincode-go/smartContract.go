@@ -9,6 +9,7 @@ import (
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	farmer "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/farmer/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 )
 
 
@@ -19,7 +20,7 @@ func main() {
 		log.Panicf("Error creating farmer chaincode: %v", err)
 	}
 
-	if err := abacSmartContract.Start(); err != nil {
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
 		log.Panicf("Error starting farmer chaincode: %v", err)
 	}
 }
@@ -4,47 +4,58 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
 )
 
-func SetFilter(input *entity.FilterGetAll) map[string]interface{} {
+func SetFilter(input *entity.FilterGetAllGAP) map[string]interface{} {
 	var filter = map[string]interface{}{}
-	if input.FarmerID != nil {
-		filter["farmerId"] = *input.FarmerID
+	if input.FarmerID != "" {
+		filter["farmerId"] = input.FarmerID
 	}
-	if input.CertID != nil {
-		filter["certId"] = *input.CertID
+	if input.CertID != "" {
+		filter["certId"] = input.CertID
 	}
-	if input.AreaCode != nil {
-		filter["areaCode"] = *input.AreaCode
+	if input.AreaCode != "" {
+		filter["areaCode"] = input.AreaCode
 	}
-	if input.Province != nil {
-		filter["province"] = *input.Province
+	if input.Province != "" {
+		filter["province"] = input.Province
 	}
-	if input.District != nil {
-		filter["district"] = *input.District
+	if input.District != "" {
+		filter["district"] = input.District
 	}
-	if input.AreaRaiFrom != nil && input.AreaRaiTo != nil {
-		filter["areaRai"] = map[string]interface{}{
-			"$gte": *input.AreaRaiFrom,
-			"$lte": *input.AreaRaiTo,
+	if input.ProvinceCode != "" {
+		filter["provinceCode"] = input.ProvinceCode
+	}
+	if input.DistrictCode != "" {
+		filter["districtCode"] = input.DistrictCode
+	}
+	if input.SubDistrictCode != "" {
+		filter["subDistrictCode"] = input.SubDistrictCode
+	}
+	if input.AreaRaiFrom != 0 || input.AreaRaiTo != 0 {
+		areaRai := map[string]interface{}{"$gte": input.AreaRaiFrom}
+		if input.AreaRaiTo != 0 {
+			areaRai["$lte"] = input.AreaRaiTo
 		}
+		filter["areaRai"] = areaRai
 	}
-	if input.IssueDate != nil {
-		filter["issueDate"] = *input.IssueDate
+	if input.IssueDate != "" {
+		filter["issueDate"] = input.IssueDate
 	}
-	if input.ExpireDate != nil {
-		filter["expireDate"] = *input.ExpireDate
+	if input.ExpireDate != "" {
+		filter["expireDate"] = input.ExpireDate
 	}
 
-	if input.AvailableGap != nil {
+	if input.AvailableGap != "" {
 		filter["farmerId"] = ""
 	}
 
-	return filter
+	return issuer.FilterAssetsOnly(filter)
 }
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll, filter map[string]interface{}) ([]*entity.TransectionReponse, error) {
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllGAP, filter map[string]interface{}) ([]*entity.TransectionGAPReponse, error) {
 
 	selector := map[string]interface{}{
 		"selector": filter,
@@ -66,15 +77,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryResults.Close()
 
-	var assets []*entity.TransectionReponse
+	var assets []*entity.TransectionGAPReponse
 	for queryResults.HasNext() {
 		queryResponse, err := queryResults.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var asset entity.TransectionReponse
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		var asset entity.TransectionGAPReponse
+		err = Schema.Unmarshal(ctx, queryResponse.Value, &asset)
 		if err != nil {
 			return nil, err
 		}
@@ -84,3 +95,44 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 
 	return assets, nil
 }
+
+// MAXPAGESIZE caps bookmark-paged queries to stay within the peer's
+// totalQueryLimit.
+const MAXPAGESIZE int = 200
+
+func FetchPage(ctx contractapi.TransactionContextInterface, queryString string, limit int, bookmark string) ([]*entity.TransectionGAPReponse, string, error) {
+	if limit <= 0 || limit > MAXPAGESIZE {
+		limit = MAXPAGESIZE
+	}
+
+	queryResults, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, int32(limit), bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer queryResults.Close()
+
+	assets := []*entity.TransectionGAPReponse{}
+	for queryResults.HasNext() {
+		queryResponse, err := queryResults.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		var asset entity.TransectionGAPReponse
+		err = Schema.Unmarshal(ctx, queryResponse.Value, &asset)
+		if err != nil {
+			return nil, "", err
+		}
+
+		assets = append(assets, &asset)
+	}
+
+	return assets, metadata.GetBookmark(), nil
+}
+
+// AggregateSpec is what AggregateGap may group by, sum and filter on.
+var AggregateSpec = issuer.AggregateSpec{
+	GroupBy:    []string{"province", "provinceCode", "district", "districtCode", "subDistrictCode", "areaStatus", "source", "orgName"},
+	Fields:     []string{"areaRai", "computedAreaRai"},
+	DateFields: []string{"issueDate", "expireDate", "createdAt", "updatedAt"},
+}
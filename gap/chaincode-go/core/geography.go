@@ -0,0 +1,279 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+var geoCodePattern = map[string]*regexp.Regexp{
+	entity.GEOPROVINCE:    regexp.MustCompile(`^[0-9]{2}$`),
+	entity.GEODISTRICT:    regexp.MustCompile(`^[0-9]{4}$`),
+	entity.GEOSUBDISTRICT: regexp.MustCompile(`^[0-9]{6}$`),
+}
+
+// Administrative prefixes and suffixes people add to area names, longest first.
+var geoNamePrefixes = []string{
+	"กิ่งอำเภอ", "จังหวัด", "อำเภอ", "ตำบล", "แขวง", "เขต", "จ.", "อ.", "ต.",
+	"changwat ", "amphoe ", "tambon ", "khwaeng ", "khet ",
+}
+
+var geoNameSuffixes = []string{
+	" sub-district", " subdistrict", " province", " district",
+}
+
+// NormalizeGeoName reduces an area name to the form used by the name index:
+// lower case, without administrative prefixes, spaces, dots or dashes.
+func NormalizeGeoName(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	for _, prefix := range geoNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimSpace(strings.TrimPrefix(name, prefix))
+			break
+		}
+	}
+	for _, suffix := range geoNameSuffixes {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(name)
+}
+
+// GeoParentCode derives the parent of an area from its code: TH for
+// provinces, the first 2 digits for districts, the first 4 for sub-districts.
+func GeoParentCode(level, code string) (string, error) {
+	pattern, ok := geoCodePattern[level]
+	if !ok {
+		return "", fmt.Errorf("unknown geography level %q", level)
+	}
+	if !pattern.MatchString(code) {
+		return "", fmt.Errorf("invalid %s code %q", level, code)
+	}
+	if level == entity.GEOPROVINCE {
+		return entity.GEOCOUNTRY, nil
+	}
+	return code[:len(code)-2], nil
+}
+
+func geoKey(ctx contractapi.TransactionContextInterface, level, parentCode, code string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(entity.GEODOCTYPE, []string{level, parentCode, code})
+}
+
+func geoNameKey(ctx contractapi.TransactionContextInterface, level, parentCode, name string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(entity.GEONAMEDOCTYPE, []string{level, parentCode, NormalizeGeoName(name)})
+}
+
+func geoNames(area *entity.GeoArea) []string {
+	names := []string{area.NameTH}
+	if area.NameEN != "" {
+		names = append(names, area.NameEN)
+	}
+	return append(names, area.Aliases...)
+}
+
+func GetGeoArea(ctx contractapi.TransactionContextInterface, level, code string) (*entity.GeoArea, error) {
+	parentCode, err := GeoParentCode(level, code)
+	if err != nil {
+		return nil, err
+	}
+	key, err := geoKey(ctx, level, parentCode, code)
+	if err != nil {
+		return nil, err
+	}
+	areaJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if areaJSON == nil {
+		return nil, nil
+	}
+
+	var area entity.GeoArea
+	if err := json.Unmarshal(areaJSON, &area); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &area, nil
+}
+
+// GetGeoAreas lists the areas of a level below parentCode, e.g. the districts
+// of province 50.
+func GetGeoAreas(ctx contractapi.TransactionContextInterface, level, parentCode string) ([]*entity.GeoArea, error) {
+	attributes := []string{level}
+	if parentCode != "" {
+		attributes = append(attributes, parentCode)
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(entity.GEODOCTYPE, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	areas := []*entity.GeoArea{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var area entity.GeoArea
+		if err := json.Unmarshal(queryResponse.Value, &area); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		areas = append(areas, &area)
+	}
+	return areas, nil
+}
+
+// PutGeoArea stores area and re-indexes its names, dropping the index
+// entries of names it no longer carries.
+func PutGeoArea(ctx contractapi.TransactionContextInterface, area *entity.GeoArea) error {
+	parentCode, err := GeoParentCode(area.Level, area.Code)
+	if err != nil {
+		return err
+	}
+	if area.ParentCode != "" && area.ParentCode != parentCode {
+		return fmt.Errorf("%s %s must belong to %s, not %s", area.Level, area.Code, parentCode, area.ParentCode)
+	}
+	area.ParentCode = parentCode
+	area.DocType = entity.GEODOCTYPE
+
+	existing, err := GetGeoArea(ctx, area.Level, area.Code)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		for _, name := range geoNames(existing) {
+			key, err := geoNameKey(ctx, area.Level, parentCode, name)
+			if err != nil {
+				return err
+			}
+			if err := ctx.GetStub().DelState(key); err != nil {
+				return fmt.Errorf("failed to delete name index of %s %s: %v", area.Level, area.Code, err)
+			}
+		}
+	}
+
+	for _, name := range geoNames(area) {
+		if NormalizeGeoName(name) == "" {
+			continue
+		}
+		key, err := geoNameKey(ctx, area.Level, parentCode, name)
+		if err != nil {
+			return err
+		}
+		owner, err := geoNameOwner(ctx, key)
+		if err != nil {
+			return err
+		}
+		if owner != "" && owner != area.Code {
+			return fmt.Errorf("name %q of %s %s is already used by %s", name, area.Level, area.Code, owner)
+		}
+		nameJSON, err := json.Marshal(entity.GeoName{DocType: entity.GEONAMEDOCTYPE, Code: area.Code})
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(key, nameJSON); err != nil {
+			return fmt.Errorf("failed to index name %q of %s %s: %v", name, area.Level, area.Code, err)
+		}
+	}
+
+	key, err := geoKey(ctx, area.Level, parentCode, area.Code)
+	if err != nil {
+		return err
+	}
+	areaJSON, err := json.Marshal(area)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, areaJSON)
+}
+
+func geoNameOwner(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	nameJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if nameJSON == nil {
+		return "", nil
+	}
+	var geoName entity.GeoName
+	if err := json.Unmarshal(nameJSON, &geoName); err != nil {
+		return "", fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return geoName.Code, nil
+}
+
+func hasGeoLevel(ctx contractapi.TransactionContextInterface, level string) (bool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(entity.GEODOCTYPE, []string{level})
+	if err != nil {
+		return false, err
+	}
+	defer resultsIterator.Close()
+	return resultsIterator.HasNext(), nil
+}
+
+// ResolveGeoArea finds an area below parentCode by code or, failing that, by
+// any of its names. It returns nil when neither is given, or when no
+// reference data has been loaded for the level yet.
+func ResolveGeoArea(ctx contractapi.TransactionContextInterface, level, parentCode, code, name string) (*entity.GeoArea, error) {
+	if code != "" {
+		area, err := GetGeoArea(ctx, level, code)
+		if err != nil {
+			return nil, err
+		}
+		if area == nil || area.ParentCode != parentCode {
+			return nil, fmt.Errorf("unknown %s code %q in %s", level, code, parentCode)
+		}
+		return area, nil
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, nil
+	}
+
+	key, err := geoNameKey(ctx, level, parentCode, name)
+	if err != nil {
+		return nil, err
+	}
+	code, err = geoNameOwner(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		loaded, err := hasGeoLevel(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+		if !loaded {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown %s %q in %s", level, name, parentCode)
+	}
+	return GetGeoArea(ctx, level, code)
+}
+
+// NormalizeLocation replaces the free-text province, district and
+// sub-district of a GAP plot with the canonical Thai names and fills in their
+// codes. Levels without reference data are left as entered.
+func NormalizeLocation(ctx contractapi.TransactionContextInterface, gap *entity.TransectionGAP) error {
+	province, err := ResolveGeoArea(ctx, entity.GEOPROVINCE, entity.GEOCOUNTRY, gap.ProvinceCode, gap.Province)
+	if err != nil || province == nil {
+		return err
+	}
+	gap.Province, gap.ProvinceCode = province.NameTH, province.Code
+
+	district, err := ResolveGeoArea(ctx, entity.GEODISTRICT, province.Code, gap.DistrictCode, gap.District)
+	if err != nil || district == nil {
+		return err
+	}
+	gap.District, gap.DistrictCode = district.NameTH, district.Code
+
+	subDistrict, err := ResolveGeoArea(ctx, entity.GEOSUBDISTRICT, district.Code, gap.SubDistrictCode, gap.SubDistrict)
+	if err != nil || subDistrict == nil {
+		return err
+	}
+	gap.SubDistrict, gap.SubDistrictCode = subDistrict.NameTH, subDistrict.Code
+	return nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
+)
+
+const (
+	EARTHRADIUS   float64 = 6378137 // WGS 84 equatorial radius in metres
+	SQMPERRAI     float64 = 1600
+	AREATOLERANCE float64 = 0.1 // allowed relative difference between AreaRai and the polygon
+)
+
+func validateRing(ring [][]float64, index int) error {
+	if len(ring) < 4 {
+		return fmt.Errorf("ring %d needs at least 4 positions", index)
+	}
+	for i, position := range ring {
+		if len(position) < 2 {
+			return fmt.Errorf("ring %d position %d must be [lon, lat]", index, i)
+		}
+		if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return fmt.Errorf("ring %d position %d is out of range", index, i)
+		}
+	}
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return fmt.Errorf("ring %d is not closed", index)
+	}
+	return nil
+}
+
+func ValidatePolygon(polygon *entity.GeoPolygon) error {
+	if polygon.Type != "Polygon" {
+		return fmt.Errorf("boundary must be a GeoJSON Polygon, got %q", polygon.Type)
+	}
+	if len(polygon.Coordinates) == 0 {
+		return fmt.Errorf("boundary has no rings")
+	}
+	for i, ring := range polygon.Coordinates {
+		if err := validateRing(ring, i); err != nil {
+			return fmt.Errorf("boundary: %v", err)
+		}
+	}
+	return nil
+}
+
+// ringArea is the area of a ring on the sphere in square metres, after
+// Chamberlain & Duquette, "Some Algorithms for Polygons on a Sphere" (2007).
+func ringArea(ring [][]float64) float64 {
+	var total float64
+	for i := 0; i < len(ring)-1; i++ {
+		lon1, lat1 := ring[i][0]*math.Pi/180, ring[i][1]*math.Pi/180
+		lon2, lat2 := ring[i+1][0]*math.Pi/180, ring[i+1][1]*math.Pi/180
+		total += (lon2 - lon1) * (2 + math.Sin(lat1) + math.Sin(lat2))
+	}
+	return math.Abs(total * EARTHRADIUS * EARTHRADIUS / 2)
+}
+
+// PolygonAreaRai is the area of the polygon less its holes, in rai.
+func PolygonAreaRai(polygon *entity.GeoPolygon) float64 {
+	area := ringArea(polygon.Coordinates[0])
+	for _, hole := range polygon.Coordinates[1:] {
+		area -= ringArea(hole)
+	}
+	return math.Max(area, 0) / SQMPERRAI
+}
+
+// PolygonCentroid is the planar centroid of the outer ring, which is accurate
+// enough for plot-sized polygons.
+func PolygonCentroid(polygon *entity.GeoPolygon) entity.GeoPoint {
+	ring := polygon.Coordinates[0]
+	var area, lon, lat float64
+	for i := 0; i < len(ring)-1; i++ {
+		cross := ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+		area += cross
+		lon += (ring[i][0] + ring[i+1][0]) * cross
+		lat += (ring[i][1] + ring[i+1][1]) * cross
+	}
+	if area == 0 {
+		for _, position := range ring[:len(ring)-1] {
+			lon += position[0]
+			lat += position[1]
+		}
+		count := float64(len(ring) - 1)
+		return entity.GeoPoint{Lat: lat / count, Lon: lon / count}
+	}
+	return entity.GeoPoint{Lat: lat / (3 * area), Lon: lon / (3 * area)}
+}
+
+// ApplyGeometry validates the plot boundary, records its computed area and
+// fills in AreaRai and Location when they were not given. A declared AreaRai
+// that differs from the boundary by more than AREATOLERANCE is rejected.
+func ApplyGeometry(gap *entity.TransectionGAP) error {
+	gap.ComputedAreaRai = 0
+	if gap.Boundary == nil {
+		return nil
+	}
+	if err := ValidatePolygon(gap.Boundary); err != nil {
+		return err
+	}
+
+	computed := PolygonAreaRai(gap.Boundary)
+	gap.ComputedAreaRai = math.Round(computed*100) / 100
+	if gap.AreaRai == 0 {
+		gap.AreaRai = float32(gap.ComputedAreaRai)
+	} else if math.Abs(float64(gap.AreaRai)-computed) > AREATOLERANCE*float64(gap.AreaRai) {
+		return fmt.Errorf("areaRai %.2f does not match the boundary area of %.2f rai", gap.AreaRai, computed)
+	}
+
+	if gap.Location == nil {
+		centroid := PolygonCentroid(gap.Boundary)
+		gap.Location = &centroid
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Schema upgrades stored GAPs to the current entity.TransectionGAP.
+var Schema = issuer.NewSchema().
+	// 1: displayCertId was added; the certificate ID is what older GAPs
+	// displayed.
+	Register(1, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+		if _, ok := record["displayCertId"]; !ok {
+			record["displayCertId"] = record["certId"]
+		}
+		return nil
+	})
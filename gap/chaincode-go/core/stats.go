@@ -0,0 +1,34 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// STATPROVINCE counts certificates and sums areaRai per province code, or
+// per province name for plots outside the geography reference.
+const STATPROVINCE string = "gapByProvince"
+
+func AddStats(stats *issuer.Stats, gap *entity.TransectionGAP, count int) {
+	province := gap.ProvinceCode
+	if province == "" {
+		province = gap.Province
+	}
+	stats.Add(STATPROVINCE, []string{province}, count, map[string]float64{
+		"areaRai": float64(gap.AreaRai) * float64(count),
+	})
+}
+
+// UpdateStats moves one record's contribution from before to after; either
+// may be nil for a create or delete.
+func UpdateStats(ctx contractapi.TransactionContextInterface, before, after *entity.TransectionGAP) error {
+	stats := issuer.NewStats()
+	if before != nil {
+		AddStats(stats, before, -1)
+	}
+	if after != nil {
+		AddStats(stats, after, 1)
+	}
+	return stats.Flush(ctx)
+}
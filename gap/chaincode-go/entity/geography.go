@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+const (
+	GEODOCTYPE     string = "geography"
+	GEONAMEDOCTYPE string = "geographyName"
+
+	GEOCOUNTRY     string = "TH"
+	GEOPROVINCE    string = "province"
+	GEODISTRICT    string = "district"
+	GEOSUBDISTRICT string = "subDistrict"
+)
+
+// GeoArea is one Thai administrative area. Codes follow the DOPA scheme:
+// 2 digits for a province, 4 for a district (amphoe/khet) and 6 for a
+// sub-district (tambon/khwaeng), each prefixed by its parent's code.
+type GeoArea struct {
+	DocType    string    `json:"docType"`
+	Level      string    `json:"level" validate:"required,enum=province|district|subDistrict"`
+	Code       string    `json:"code" validate:"required,maxlen=6"`
+	ParentCode string    `json:"parentCode"`
+	NameTH     string    `json:"nameTh" validate:"required,maxlen=128"`
+	NameEN     string    `json:"nameEn" validate:"maxlen=128"`
+	Aliases    []string  `json:"aliases"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// GeoName indexes a normalized area name to its code within the parent area.
+type GeoName struct {
+	DocType string `json:"docType"`
+	Code    string `json:"code"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+// GeoPoint is a WGS 84 coordinate in decimal degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat" validate:"min=-90,max=90"`
+	Lon float64 `json:"lon" validate:"min=-180,max=180"`
+}
+
+// GeoPolygon is a GeoJSON Polygon geometry: the first ring is the plot
+// boundary, any further rings are holes. Positions are [lon, lat].
+type GeoPolygon struct {
+	Type        string        `json:"type" validate:"required,enum=Polygon"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
@@ -2,38 +2,58 @@ package entity
 
 import "time"
 
+const ENTITYNAME string = "gap"
+
 type TransectionGAP struct {
-	Id          string    `json:"id"`
-	CertID      string    `json:"certId"`
-	DisplayCertID      string    `json:"displayCertId"`
-	AreaCode    string    `json:"areaCode"`
-	AreaRai     float32   `json:"areaRai"`
-	AreaStatus  string    `json:"areaStatus"`
-	OldAreaCode string    `json:"oldAreaCode"`
-	IssueDate   string    `json:"issueDate"`
-	ExpireDate  string    `json:"expireDate"`
-	District    string    `json:"district"`
-	Province    string    `json:"province"`
-	UpdatedDate string    `json:"updatedDate"`
-	Source      string    `json:"source"`
-	FarmerID    string    `json:"farmerId"`
+	Id          string    `json:"id" validate:"required,maxlen=128"`
+	CertID      string    `json:"certId" validate:"required,maxlen=128"`
+	DisplayCertID      string    `json:"displayCertId" validate:"maxlen=128"`
+	AreaCode    string    `json:"areaCode" validate:"maxlen=128"`
+	AreaRai     float32   `json:"areaRai" validate:"min=0,max=100000"`
+	AreaStatus  string    `json:"areaStatus" validate:"maxlen=128"`
+	OldAreaCode string    `json:"oldAreaCode" validate:"maxlen=128"`
+	IssueDate   string    `json:"issueDate" validate:"date"`
+	ExpireDate  string    `json:"expireDate" validate:"date"`
+	District    string    `json:"district" validate:"maxlen=256"`
+	Province    string    `json:"province" validate:"maxlen=256"`
+	SubDistrict string    `json:"subDistrict" validate:"maxlen=256"`
+	ProvinceCode    string `json:"provinceCode" validate:"maxlen=6"`
+	DistrictCode    string `json:"districtCode" validate:"maxlen=6"`
+	SubDistrictCode string `json:"subDistrictCode" validate:"maxlen=6"`
+	Location        *GeoPoint   `json:"location,omitempty" metadata:",optional" validate:"dive"`
+	Boundary        *GeoPolygon `json:"boundary,omitempty" metadata:",optional" validate:"dive"`
+	ComputedAreaRai float64     `json:"computedAreaRai,omitempty" metadata:",optional"`
+	UpdatedDate string    `json:"updatedDate" validate:"date"`
+	Source      string    `json:"source" validate:"maxlen=256"`
+	// FarmerID is set only by SetGapFarmer, through LinkGapToFarmer and
+	// UnlinkGapFromFarmer in the farmer chaincode; updates keep it.
+	FarmerID    string    `json:"farmerId" validate:"maxlen=128"`
 	Owner       string    `json:"owner"`
 	OrgName     string    `json:"orgName"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 	CreatedAt   time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip         int      `json:"skip"`
-	Limit        int      `json:"limit"`
-	CertID       *string  `json:"certId"`
-	FarmerID       *string  `json:"farmerId"`
-	AreaCode     *string  `json:"areaCode"`
-	District     *string  `json:"district"`
-	Province     *string  `json:"province"`
-	AreaRaiFrom  *float32 `json:"areaRaiFrom"`
-	AreaRaiTo    *float32 `json:"areaRaiTo"`
-	IssueDate    *string  `json:"issueDate"`
-	ExpireDate   *string  `json:"expireDate"`
-	AvailableGap *string  `json:"availableGap"`
+// FilterGetAllGAP is the GetAllGAP parameter. Only limit is required. Empty
+// and zero fields are not filtered on; the areaRai range applies once either
+// bound is set.
+type FilterGetAllGAP struct {
+	Skip            int     `json:"skip" metadata:",optional"`
+	Limit           int     `json:"limit"`
+	CertID          string  `json:"certId" metadata:",optional"`
+	FarmerID        string  `json:"farmerId" metadata:",optional"`
+	AreaCode        string  `json:"areaCode" metadata:",optional"`
+	District        string  `json:"district" metadata:",optional"`
+	Province        string  `json:"province" metadata:",optional"`
+	ProvinceCode    string  `json:"provinceCode" metadata:",optional"`
+	DistrictCode    string  `json:"districtCode" metadata:",optional"`
+	SubDistrictCode string  `json:"subDistrictCode" metadata:",optional"`
+	AreaRaiFrom     float32 `json:"areaRaiFrom" metadata:",optional"`
+	AreaRaiTo       float32 `json:"areaRaiTo" metadata:",optional"`
+	IssueDate       string  `json:"issueDate" metadata:",optional"`
+	ExpireDate      string  `json:"expireDate" metadata:",optional"`
+	AvailableGap    string  `json:"availableGap" metadata:",optional"`
 }
@@ -2,7 +2,7 @@ package entity
 
 import "time"
 
-type TransectionReponse struct {
+type TransectionGAPReponse struct {
 	Id          			 string    `json:"id"`
 	DisplayCertID      string    `json:"displayCertId"`
 	CertID      string    `json:"certId"`
@@ -14,6 +14,13 @@ type TransectionReponse struct {
 	ExpireDate  string    `json:"expireDate"`
 	District    string    `json:"district"`
 	Province    string    `json:"province"`
+	SubDistrict string    `json:"subDistrict"`
+	ProvinceCode    string `json:"provinceCode"`
+	DistrictCode    string `json:"districtCode"`
+	SubDistrictCode string `json:"subDistrictCode"`
+	Location        *GeoPoint   `json:"location,omitempty" metadata:",optional"`
+	Boundary        *GeoPolygon `json:"boundary,omitempty" metadata:",optional"`
+	ComputedAreaRai float64     `json:"computedAreaRai,omitempty" metadata:",optional"`
 	UpdatedDate string    `json:"updatedDate"`
 	Source      string    `json:"source"`
 	FarmerID    string    `json:"farmerId"`
@@ -21,13 +28,19 @@ type TransectionReponse struct {
 	CreatedAt   time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllGAPReponse struct {
+	Data  string                   `json:"data"`
+	Obj   []*TransectionGAPReponse `json:"obj"`
+	Total int                      `json:"total"`
 }
 
 type GetByCertIDReponse struct {
-	Data string              `json:"data"`
-	Obj  *TransectionReponse `json:"obj"`
+	Data string                 `json:"data"`
+	Obj  *TransectionGAPReponse `json:"obj"`
+}
+
+type GetPageReponse struct {
+	Data     string                   `json:"data"`
+	Obj      []*TransectionGAPReponse `json:"obj"`
+	Bookmark string                   `json:"bookmark"`
 }
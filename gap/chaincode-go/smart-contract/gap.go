@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/core"
@@ -16,15 +17,43 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllGAP",
+		"FilterGap",
+		"GetGapByCertID",
+		"GetGapByFarmerID",
+		"GetGapWithinBoundingBox",
+		"GetGeographyAreas",
+		"AggregateGap",
+		"GetStatistics",
+		"GetTransfer",
+		"GetRegulatoryStatus",
+		"GetEndorsementPolicy",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
 func (s *SmartContract) CreateGAP(
 	ctx contractapi.TransactionContextInterface,
 	args string,
 ) error {
 	entityGap := entity.TransectionGAP{}
 	inputInterface, err := issuer.Unmarshal(args, entityGap)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionGAP)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("gap.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
@@ -36,8 +65,12 @@ func (s *SmartContract) CreateGAP(
 	if existsGap {
 		return fmt.Errorf("the asset %s already exists", input.Id)
 	}
+	// SetGapFarmer alone sets farmerId, keeping the farmer's GAP list in step.
+	if input.FarmerID != "" {
+		return fmt.Errorf("GAP %s must be linked to farmer %s with LinkGapToFarmer in the farmer chaincode", input.Id, input.FarmerID)
+	}
 
-	clientIDGap, err := issuer.GetIdentity(ctx)
+	clientIDGap, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	TimeGap := issuer.GetTimeNow()
@@ -54,29 +87,76 @@ func (s *SmartContract) CreateGAP(
 		ExpireDate:  input.ExpireDate,
 		District:    input.District,
 		Province:    input.Province,
+		SubDistrict: input.SubDistrict,
+		ProvinceCode:    input.ProvinceCode,
+		DistrictCode:    input.DistrictCode,
+		SubDistrictCode: input.SubDistrictCode,
+		Location:    input.Location,
+		Boundary:    input.Boundary,
 		UpdatedDate: input.UpdatedDate,
 		Source:      input.Source,
-		FarmerID:    input.FarmerID,
 		Owner:       clientIDGap,
 		OrgName:     orgName,
 		UpdatedAt:   TimeGap,
 		CreatedAt:   TimeGap,
+		SchemaVersion: core.Schema.Version(),
 	}
+
+	err = core.NormalizeLocation(ctx, &asset)
+	if err != nil {
+		return err
+	}
+
+	err = core.ApplyGeometry(&asset)
+	if err != nil {
+		return err
+	}
+
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	err = core.UpdateStats(ctx, nil, &asset)
+	if err != nil {
+		return err
+	}
+
+	err = issuer.SetOwnerEndorsement(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, args string) error {
 
 	entityGap := entity.TransectionGAP{}
 	inputInterface, err := issuer.Unmarshal(args, entityGap)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionGAP)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.AssertNotRevoked(ctx, issuer.TARGETGAP, asset.CertID)
+	if err != nil {
+		return err
+	}
 
 	UpdatedGap := issuer.GetTimeNow()
 
@@ -91,48 +171,128 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	asset.ExpireDate = input.ExpireDate
 	asset.District = input.District
 	asset.Province = input.Province
+	asset.SubDistrict = input.SubDistrict
+	asset.ProvinceCode = input.ProvinceCode
+	asset.DistrictCode = input.DistrictCode
+	asset.SubDistrictCode = input.SubDistrictCode
+	asset.Location = input.Location
+	asset.Boundary = input.Boundary
 	asset.UpdatedDate = input.UpdatedDate
 	asset.Source = input.Source
-	asset.FarmerID = input.FarmerID
 	asset.UpdatedAt = UpdatedGap
 
+	err = core.NormalizeLocation(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	err = core.ApplyGeometry(asset)
+	if err != nil {
+		return err
+	}
+
 	assetJSON, errGap := json.Marshal(asset)
 	issuer.HandleError(errGap)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	err = core.UpdateStats(ctx, &before, asset)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetGap, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDGap, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetGap.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDGap != assetGap.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	err = core.UpdateStats(ctx, assetGap, nil)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetGap)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetGap, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertNotRevoked(ctx, issuer.TARGETGAP, assetGap.CertID)
+	if err != nil {
+		return err
+	}
 
-	if clientID != assetGap.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetGap.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
+
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetGap, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	assetGap.Owner = newOwner
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetGap.Owner)
+	if err != nil {
+		return err
+	}
+
+	assetGap.Owner = transfer.AcceptedBy
+	assetGap.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetGap)
-	issuer.HandleError(err)
-	return ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	// From now on the new owner's org endorses changes instead of the old one.
+	return issuer.SetOwnerEndorsement(ctx, id)
+}
+
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
 }
 
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionGAP, error) {
@@ -146,7 +306,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionGAP
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +319,7 @@ func (s *SmartContract) GetGapByFarmerID(ctx contractapi.TransactionContextInter
 	queryKeyFarmer := fmt.Sprintf(`{"selector":{"farmerId":"%s"}}`, farmerId)
 
 	resultsIteratorFarmer, err := ctx.GetStub().GetQueryResult(queryKeyFarmer)
-	var asset *entity.TransectionReponse
+	var asset *entity.TransectionGAPReponse
 	resData := "Get gap by farmerId"
 	if err != nil {
 		return nil, fmt.Errorf("error querying chaincode: %v", err)
@@ -180,7 +340,7 @@ func (s *SmartContract) GetGapByFarmerID(ctx contractapi.TransactionContextInter
 		return nil, fmt.Errorf("error getting next query result: %v", err)
 	}
 
-	err = json.Unmarshal(queryResponse.Value, &asset)
+	err = core.Schema.Unmarshal(ctx, queryResponse.Value, &asset)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling asset JSON: %v", err)
 	}
@@ -197,7 +357,7 @@ func (s *SmartContract) GetGapByCertID(ctx contractapi.TransactionContextInterfa
 	queryKeyGap := fmt.Sprintf(`{"selector":{"certId":"%s"}}`, certID)
 
 	resultsIteratorGap, err := ctx.GetStub().GetQueryResult(queryKeyGap)
-	var asset *entity.TransectionReponse
+	var asset *entity.TransectionGAPReponse
 	resData := "Get gap by certID"
 	if err != nil {
 		resData = fmt.Sprintf("error querying chaincode: %v", err)
@@ -221,7 +381,7 @@ func (s *SmartContract) GetGapByCertID(ctx contractapi.TransactionContextInterfa
 		return nil, fmt.Errorf("error getting next query result: %v", err)
 	}
 
-	err = json.Unmarshal(queryResponse.Value, &asset)
+	err = core.Schema.Unmarshal(ctx, queryResponse.Value, &asset)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling asset JSON: %v", err)
 	}
@@ -232,14 +392,9 @@ func (s *SmartContract) GetGapByCertID(ctx contractapi.TransactionContextInterfa
 	}, nil
 }
 
-func (s *SmartContract) GetAllGAP(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllGAP(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllGAP) (*entity.GetAllGAPReponse, error) {
 
-	entityGetAllGap := entity.FilterGetAll{}
-	interfaceGap, err := issuer.Unmarshal(args, entityGetAllGap)
-	if err != nil {
-		return nil, err
-	}
-	inputGap := interfaceGap.(*entity.FilterGetAll)
+	inputGap := &input
 	filterGap := core.SetFilter(inputGap)
 
 	queryStringGap, err := issuer.BuildQueryString(filterGap)
@@ -266,10 +421,10 @@ func (s *SmartContract) GetAllGAP(ctx contractapi.TransactionContextInterface, a
 	})
 
 	if len(assets) == 0 {
-		assets = []*entity.TransectionReponse{}
+		assets = []*entity.TransectionGAPReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllGAPReponse{
 		Data:  "All Gap",
 		Obj:   assets,
 		Total: total,
@@ -290,14 +445,19 @@ func (s *SmartContract) FilterGap(ctx contractapi.TransactionContextInterface, k
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionGAP
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -319,8 +479,18 @@ func (s *SmartContract) UpdateMultipleGap(
 ) error {
 	var inputs []entity.TransectionGAP
 
+	var eventItems []issuer.EventItem
+	stats := issuer.NewStats()
+
 	errInputGap := json.Unmarshal([]byte(args), &inputs)
-	issuer.HandleError(errInputGap)
+	if errInputGap != nil {
+		return fmt.Errorf("failed to unmarshal JSON array: %v", errInputGap)
+	}
+
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
 	
 	for _, input := range inputs {
 		assetJSON, err := ctx.GetStub().GetState(input.Id)
@@ -332,10 +502,17 @@ func (s *SmartContract) UpdateMultipleGap(
 		}
 		
 		var existingAsset entity.TransectionGAP
-		err = json.Unmarshal(assetJSON, &existingAsset)
+		err = core.Schema.Unmarshal(ctx, assetJSON, &existingAsset)
 		if err != nil {
 			return fmt.Errorf("failed to unmarshal existing asset: %v", err)
 		}
+		before := existingAsset
+
+		err = issuer.AssertNotRevoked(ctx, issuer.TARGETGAP, existingAsset.CertID)
+		if err != nil {
+			return err
+		}
+
 		UpdatedGap := issuer.GetTimeNow()
 		
 		existingAsset.Id =          				 input.Id
@@ -349,10 +526,25 @@ func (s *SmartContract) UpdateMultipleGap(
 		existingAsset.ExpireDate =  input.ExpireDate
 		existingAsset.District =    input.District
 		existingAsset.Province =    input.Province
+		existingAsset.SubDistrict = input.SubDistrict
+		existingAsset.ProvinceCode =    input.ProvinceCode
+		existingAsset.DistrictCode =    input.DistrictCode
+		existingAsset.SubDistrictCode = input.SubDistrictCode
+		existingAsset.Location =    input.Location
+		existingAsset.Boundary =    input.Boundary
 		existingAsset.UpdatedAt =		UpdatedGap
 		existingAsset.Source =      input.Source
-		existingAsset.FarmerID =    input.FarmerID
 		existingAsset.UpdatedDate = input.UpdatedDate
+
+		err = core.NormalizeLocation(ctx, &existingAsset)
+		if err != nil {
+			return fmt.Errorf("asset %s: %v", input.Id, err)
+		}
+
+		err = core.ApplyGeometry(&existingAsset)
+		if err != nil {
+			return fmt.Errorf("asset %s: %v", input.Id, err)
+		}
 		
 		updatedAssetJSON, err := json.Marshal(existingAsset)
 		if err != nil {
@@ -363,11 +555,25 @@ func (s *SmartContract) UpdateMultipleGap(
 		if err != nil {
 			return fmt.Errorf("failed to update asset in world state: %v", err)
 		}
+
+		core.AddStats(stats, &before, -1)
+		core.AddStats(stats, &existingAsset, 1)
+
+		eventItem, err := issuer.EventDiff(input.Id, before, existingAsset)
+		if err != nil {
+			return err
+		}
+		eventItems = append(eventItems, eventItem)
 		
 		fmt.Printf("Asset %s updated successfully\n", input.Id)
 	}
 	
-	return nil
+	err := stats.Flush(ctx)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTUPDATED, entity.ENTITYNAME, eventItems...)
 }
 
 func (s *SmartContract) CreateGapCsv(
@@ -376,8 +582,18 @@ func (s *SmartContract) CreateGapCsv(
 ) error {
 	var inputs []entity.TransectionGAP
 
+	var eventItems []issuer.EventItem
+	stats := issuer.NewStats()
+
 	errInputGap := json.Unmarshal([]byte(args), &inputs)
-	issuer.HandleError(errInputGap)
+	if errInputGap != nil {
+		return fmt.Errorf("failed to unmarshal JSON array: %v", errInputGap)
+	}
+
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
 
 	for _, input := range inputs {
 		// err := ctx.GetClientIdentity().AssertAttributeValue("gap.creator", "true")
@@ -394,8 +610,11 @@ func (s *SmartContract) CreateGapCsv(
 		if existGap {
 			return fmt.Errorf("the asset %s already exists", input.Id)
 		}
+		if input.FarmerID != "" {
+			return fmt.Errorf("GAP %s must be linked to farmer %s with LinkGapToFarmer in the farmer chaincode", input.Id, input.FarmerID)
+		}
 
-		clientIDGap, err := issuer.GetIdentity(ctx)
+		clientIDGap, err := issuer.GetOwnerID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get submitting client's identity: %v", err)
 		}
@@ -412,11 +631,27 @@ func (s *SmartContract) CreateGapCsv(
 			ExpireDate:  input.ExpireDate,
 			District:    input.District,
 			Province:    input.Province,
+			SubDistrict: input.SubDistrict,
+			ProvinceCode:    input.ProvinceCode,
+			DistrictCode:    input.DistrictCode,
+			SubDistrictCode: input.SubDistrictCode,
+			Location:    input.Location,
+			Boundary:    input.Boundary,
 			UpdatedDate: input.UpdatedDate,
 			Source:      input.Source,
-			FarmerID:    input.FarmerID,
 			Owner:       clientIDGap,
 			OrgName:     orgNameGap,
+			SchemaVersion: core.Schema.Version(),
+		}
+
+		err = core.NormalizeLocation(ctx, &assetGap)
+		if err != nil {
+			return fmt.Errorf("asset %s: %v", input.Id, err)
+		}
+
+		err = core.ApplyGeometry(&assetGap)
+		if err != nil {
+			return fmt.Errorf("asset %s: %v", input.Id, err)
 		}
 		assetJSON, err := json.Marshal(assetGap)
 		if err != nil {
@@ -428,8 +663,198 @@ func (s *SmartContract) CreateGapCsv(
 			return fmt.Errorf("failed to put state for asset %s: %v", input.Id, err)
 		}
 
+		err = issuer.SetOwnerEndorsement(ctx, input.Id)
+		if err != nil {
+			return err
+		}
+
+		core.AddStats(stats, &assetGap, 1)
+		eventItems = append(eventItems, issuer.EventItem{ID: input.Id, Payload: assetJSON})
+
 		fmt.Printf("Asset %s created successfully\n", input.Id)
 	}
 
-	return nil
+	err := stats.Flush(ctx)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ENTITYNAME, eventItems...)
+}
+
+// AggregateGap groups records for dashboards and totals, counts and averages
+// the requested fields, e.g. {"groupBy":["province"],"fields":["areaRai"]}.
+// It reads one bounded page per call; repeat with the returned bookmark and
+// groups until done.
+func (s *SmartContract) AggregateGap(ctx contractapi.TransactionContextInterface, args string) (*issuer.AggregateResult, error) {
+	entityQuery := issuer.AggregateQuery{}
+	inputInterface, err := issuer.Unmarshal(args, entityQuery)
+	if err != nil {
+		return nil, err
+	}
+	input := inputInterface.(*issuer.AggregateQuery)
+
+	return issuer.Aggregate(ctx, core.AggregateSpec, input)
+}
+
+// GetStatistics sums the running counters of a statistic, e.g.
+// core.STATPROVINCE ("gapByProvince"), per group.
+func (s *SmartContract) GetStatistics(ctx contractapi.TransactionContextInterface, name string) ([]*issuer.Statistic, error) {
+	return issuer.GetStatistics(ctx, name)
+}
+
+// CompactStatistics folds the delta rows of a statistic into one row per
+// group and announces the result as a stat.compacted event. Only
+// administrators may call it.
+func (s *SmartContract) CompactStatistics(ctx contractapi.TransactionContextInterface, name string) error {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	statistics, err := issuer.CompactStatistics(ctx, name)
+	if err != nil {
+		return err
+	}
+	item, err := issuer.EventPayload(name, statistics)
+	if err != nil {
+		return err
+	}
+	return issuer.EmitEvent(ctx, issuer.EVENTCOMPACTED, issuer.STATDOCTYPE, item)
+}
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetEndorsementPolicy lists the orgs that must endorse changes to an asset:
+// the owner's org and the regulator.
+func (s *SmartContract) GetEndorsementPolicy(ctx contractapi.TransactionContextInterface, id string) (*issuer.EndorsementPolicy, error) {
+	return issuer.GetAssetEndorsement(ctx, id)
+}
+
+// GetRegulatoryStatus returns the regulator's status of the certificate of
+// a GAP record.
+func (s *SmartContract) GetRegulatoryStatus(ctx contractapi.TransactionContextInterface, id string) (*issuer.RegulatoryStatus, error) {
+	assetGap, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return issuer.GetRegulatoryStatus(ctx, issuer.TARGETGAP, assetGap.CertID)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionGAP{},
+	})
+}
+
+// AdminOverride corrects fields of a GAP record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionGAP{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	err = core.NormalizeLocation(ctx, asset)
+	if err != nil {
+		return err
+	}
+	err = core.ApplyGeometry(asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	err = core.UpdateStats(ctx, before, asset)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
+}
+
+// SetGapFarmer changes the farmer a GAP certificate belongs to from
+// fromFarmerID to toFarmerID, failing if it currently belongs to someone
+// else. It is called by LinkGapToFarmer and UnlinkGapFromFarmer in the farmer
+// chaincode, which keep the farmer's list of GAP IDs in step; only
+// administrators may call it directly.
+func (s *SmartContract) SetGapFarmer(ctx contractapi.TransactionContextInterface, id string, fromFarmerID string, toFarmerID string) error {
+	err := issuer.AssertCalledVia(ctx, issuer.CCFARMER)
+	if err != nil {
+		return err
+	}
+
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+	if asset.FarmerID != fromFarmerID {
+		return fmt.Errorf("GAP %s belongs to farmer %q, not %q", id, asset.FarmerID, fromFarmerID)
+	}
+	before := *asset
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.FarmerID = toFarmerID
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, before, asset)
 }
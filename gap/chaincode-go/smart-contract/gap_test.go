@@ -0,0 +1,378 @@
+package gap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
+	gap "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org2MSP", "bob", nil)
+)
+
+const square = `{"type":"Polygon","coordinates":[[[100,13],[100.001,13],[100.001,13.001],[100,13.001],[100,13]]]}`
+
+// newNetwork deploys the gap chaincode with a regulator that revoked the
+// certificates listed in revoked.
+func newNetwork(t *testing.T, revoked ...string) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&gap.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCGAP, chaincode)
+	network.Deploy(issuer.CCREGULATOR, issuertest.NewFake().
+		On("GetRegulatoryStatus", func(args []string) ([]byte, error) {
+			status := issuer.RegulatoryStatus{TargetType: args[0], TargetID: args[1], Status: issuer.CERTACTIVE}
+			for _, certID := range revoked {
+				if certID == args[1] {
+					status.Status = issuer.CERTREVOKED
+				}
+			}
+			return json.Marshal(status)
+		}))
+	return network
+}
+
+func readGap(t *testing.T, network *issuertest.Network, id string) *entity.TransectionGAP {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCGAP, "ReadAsset", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionGAP
+	if err := json.Unmarshal(payload, &asset); err != nil {
+		t.Fatal(err)
+	}
+	return &asset
+}
+
+func TestCreateGAP(t *testing.T) {
+	network := newNetwork(t)
+	_, err := network.Submit(admin, issuer.CCGAP, "LoadGeography", `[
+		{"level":"province","code":"50","nameTh":"เชียงใหม่","nameEn":"Chiang Mai"},
+		{"level":"district","code":"5001","nameTh":"เมืองเชียงใหม่"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		args         string
+		wantErr      bool
+		wantProvince string
+	}{
+		{name: "province by name", args: `{"id":"G-1","certId":"GAP-001","province":"จังหวัดเชียงใหม่","district":"เมืองเชียงใหม่"}`, wantProvince: "50"},
+		{name: "province by English name", args: `{"id":"G-2","certId":"GAP-002","province":"Chiang Mai Province"}`, wantProvince: "50"},
+		{name: "boundary fills area", args: `{"id":"G-3","certId":"GAP-003","boundary":` + square + `}`},
+		{name: "existing id", args: `{"id":"G-1","certId":"GAP-004"}`, wantErr: true},
+		{name: "missing certId", args: `{"id":"G-5"}`, wantErr: true},
+		{name: "unknown province code", args: `{"id":"G-6","certId":"GAP-006","provinceCode":"99"}`, wantErr: true},
+		{name: "area does not match boundary", args: `{"id":"G-7","certId":"GAP-007","areaRai":1000,"boundary":` + square + `}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("created")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var input entity.TransectionGAP
+			if err := json.Unmarshal([]byte(test.args), &input); err != nil {
+				t.Fatal(err)
+			}
+			asset := readGap(t, network, input.Id)
+			if asset.ProvinceCode != test.wantProvince {
+				t.Fatalf("provinceCode = %q, want %q", asset.ProvinceCode, test.wantProvince)
+			}
+			if asset.Boundary != nil && (asset.AreaRai <= 0 || asset.Location == nil) {
+				t.Fatalf("areaRai = %v, location = %v", asset.AreaRai, asset.Location)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCGAP, "GetEndorsementPolicy", "G-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var policy issuer.EndorsementPolicy
+	if err := json.Unmarshal(payload, &policy); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(policy.Orgs) != "[Org1MSP RegulatorMSP]" {
+		t.Fatalf("endorsing orgs = %v", policy.Orgs)
+	}
+
+	payload, err = network.Evaluate(alice, issuer.CCGAP, "GetStatistics", core.STATPROVINCE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats []issuer.Statistic
+	if err := json.Unmarshal(payload, &stats); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, stat := range stats {
+		counts[fmt.Sprint(stat.Group)] = stat.Count
+	}
+	if counts["[50]"] != 2 || counts["[]"] != 1 {
+		t.Fatalf("statistics = %s", payload)
+	}
+
+	if _, err := network.Submit(alice, issuer.CCGAP, "CompactStatistics", core.STATPROVINCE); err == nil {
+		t.Fatal("a member compacted statistics")
+	}
+	if _, err := network.Submit(admin, issuer.CCGAP, "CompactStatistics", core.STATPROVINCE); err != nil {
+		t.Fatal(err)
+	}
+	if event := network.LastEvent(); event == nil || event.EventName != issuer.STATDOCTYPE+"."+issuer.EVENTCOMPACTED {
+		t.Fatalf("event = %v, want stat.compacted", event)
+	}
+	compacted, err := network.Evaluate(alice, issuer.CCGAP, "GetStatistics", core.STATPROVINCE)
+	if err != nil || string(compacted) != string(payload) {
+		t.Fatalf("statistics after compaction = %s, %v, want %s", compacted, err, payload)
+	}
+}
+
+func TestUpdateGAP(t *testing.T) {
+	network := newNetwork(t, "GAP-REVOKED")
+	for _, args := range []string{`{"id":"G-1","certId":"GAP-001"}`, `{"id":"G-2","certId":"GAP-REVOKED"}`} {
+		if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{name: "active certificate", args: `{"id":"G-1","certId":"GAP-001","areaRai":5}`},
+		{name: "revoked certificate", args: `{"id":"G-2","certId":"GAP-REVOKED","areaRai":5}`, wantErr: true},
+		{name: "unknown gap", args: `{"id":"G-9","certId":"GAP-009"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCGAP, "UpdateAsset", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+	if asset := readGap(t, network, "G-1"); asset.AreaRai != 5 {
+		t.Fatalf("areaRai = %v, want 5", asset.AreaRai)
+	}
+}
+
+func TestSetGapFarmer(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", `{"id":"G-1","certId":"GAP-001"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		identity   *issuertest.Identity
+		from, to   string
+		wantErr    bool
+		wantFarmer string
+	}{
+		{name: "member directly", identity: bob, from: "", to: "F-1", wantErr: true},
+		{name: "admin links", identity: admin, from: "", to: "F-1", wantFarmer: "F-1"},
+		{name: "stale farmer", identity: admin, from: "F-2", to: "F-3", wantErr: true, wantFarmer: "F-1"},
+		{name: "admin unlinks", identity: admin, from: "F-1", to: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCGAP, "SetGapFarmer", "G-1", test.from, test.to)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := readGap(t, network, "G-1").FarmerID; got != test.wantFarmer {
+				t.Fatalf("farmerId = %q, want %q", got, test.wantFarmer)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCGAP, "GetGapByCertID", "GAP-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found entity.GetByCertIDReponse
+	if err := json.Unmarshal(payload, &found); err != nil || found.Obj == nil || found.Obj.Id != "G-1" {
+		t.Fatalf("GetGapByCertID = %s, %v", payload, err)
+	}
+}
+
+func TestGapFarmerOnlySetByLink(t *testing.T) {
+	network := newNetwork(t)
+	for _, function := range []string{"CreateGAP", "CreateGapCsv"} {
+		args := `{"id":"G-1","certId":"GAP-001","farmerId":"F-1"}`
+		if function == "CreateGapCsv" {
+			args = "[" + args + "]"
+		}
+		if _, err := network.Submit(alice, issuer.CCGAP, function, args); err == nil {
+			t.Fatalf("%s set the farmer", function)
+		} else if !strings.Contains(err.Error(), "LinkGapToFarmer") {
+			t.Fatalf("%s: %v", function, err)
+		}
+	}
+	if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", `{"id":"G-1","certId":"GAP-001"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(admin, issuer.CCGAP, "SetGapFarmer", "G-1", "", "F-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, update := range []struct{ function, args string }{
+		{"UpdateAsset", `{"id":"G-1","certId":"GAP-001","farmerId":"F-2"}`},
+		{"UpdateAsset", `{"id":"G-1","certId":"GAP-001"}`},
+		{"UpdateMultipleGap", `[{"id":"G-1","certId":"GAP-001","farmerId":"F-2"}]`},
+	} {
+		if _, err := network.Submit(alice, issuer.CCGAP, update.function, update.args); err != nil {
+			t.Fatal(err)
+		}
+		if got := readGap(t, network, "G-1").FarmerID; got != "F-1" {
+			t.Fatalf("%s %s: farmerId = %q, want F-1", update.function, update.args, got)
+		}
+	}
+}
+
+func TestDeleteGAP(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", `{"id":"G-1","certId":"GAP-001"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(bob, issuer.CCGAP, "DeleteAsset", "G-1"); err == nil {
+		t.Fatal("a client other than the owner deleted the GAP")
+	}
+	if _, err := network.Submit(alice, issuer.CCGAP, "DeleteAsset", "G-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Evaluate(alice, issuer.CCGAP, "ReadAsset", "G-1"); err == nil {
+		t.Fatal("GAP still readable")
+	}
+}
+
+func TestMigrateGAP(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", `{"id":"G-1","certId":"GAP-001"}`); err != nil {
+		t.Fatal(err)
+	}
+	if asset := readGap(t, network, "G-1"); asset.SchemaVersion != core.Schema.Version() {
+		t.Fatalf("created at schema version %d, want %d", asset.SchemaVersion, core.Schema.Version())
+	}
+
+	// Store the GAP as it was before displayCertId and schema versions.
+	stub := network.Stub(issuer.CCGAP)
+	var legacy map[string]interface{}
+	if err := json.Unmarshal(stub.State("G-1"), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	delete(legacy, "displayCertId")
+	delete(legacy, "schemaVersion")
+	value, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.Seed("G-1", value)
+
+	if asset := readGap(t, network, "G-1"); asset.DisplayCertID != "GAP-001" || asset.SchemaVersion != 1 {
+		t.Errorf("ReadAsset = %+v, want the legacy GAP upgraded", asset)
+	}
+	if _, err := network.Submit(alice, issuer.CCGAP, "MigrateBatch", "", "10"); err == nil {
+		t.Fatal("a member migrated GAPs")
+	}
+	payload, err := network.Submit(admin, issuer.CCGAP, "MigrateBatch", "", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result issuer.MigrationResult
+	if err := json.Unmarshal(payload, &result); err != nil || result.Migrated != 1 || !result.Done {
+		t.Fatalf("MigrateBatch = %s, %v", payload, err)
+	}
+	var stored entity.TransectionGAP
+	if err := json.Unmarshal(stub.State("G-1"), &stored); err != nil || stored.DisplayCertID != "GAP-001" || stored.SchemaVersion != 1 {
+		t.Errorf("stored GAP = %s, %v", stub.State("G-1"), err)
+	}
+}
+
+func TestGetAllGAP(t *testing.T) {
+	network := newNetwork(t)
+	for _, args := range []string{
+		`{"id":"G-1","certId":"GAP-001","areaRai":5}`,
+		`{"id":"G-2","certId":"GAP-002","areaRai":12}`,
+		`{"id":"G-3","certId":"GAP-003","areaRai":20}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCGAP, "CreateGAP", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := network.Submit(admin, issuer.CCGAP, "SetGapFarmer", "G-3", "", "F-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "all", args: `{"limit":0}`, want: "[G-1 G-2 G-3]", wantTotal: 3},
+		{name: "area range", args: `{"areaRaiFrom":10,"areaRaiTo":20,"limit":0}`, want: "[G-2 G-3]", wantTotal: 2},
+		{name: "area lower bound", args: `{"areaRaiFrom":10,"limit":0}`, want: "[G-2 G-3]", wantTotal: 2},
+		{name: "area upper bound", args: `{"areaRaiTo":12,"limit":0}`, want: "[G-1 G-2]", wantTotal: 2},
+		{name: "available", args: `{"availableGap":"true","limit":0}`, want: "[G-1 G-2]", wantTotal: 2},
+		{name: "farmer", args: `{"farmerId":"F-1","limit":0}`, want: "[G-3]", wantTotal: 1},
+		{name: "certificate", args: `{"certId":"GAP-002","limit":0}`, want: "[G-2]", wantTotal: 1},
+		{name: "skip and limit", args: `{"skip":1,"limit":1}`, want: "[G-2]", wantTotal: 3},
+		{name: "skip past the total", args: `{"skip":4,"limit":0}`, wantErr: true},
+		{name: "without a limit", args: `{}`, wantErr: true},
+		{name: "unknown filter", args: `{"limit":0,"owner":"alice"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCGAP, "GetAllGAP", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllGAPReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("gaps = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package gap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/entity"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// LoadGeography creates or updates provinces, districts and sub-districts
+// from a JSON array of entity.GeoArea. Only administrators may call it.
+func (s *SmartContract) LoadGeography(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	var inputs []entity.GeoArea
+	err = json.Unmarshal([]byte(args), &inputs)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON array: %v", err)
+	}
+
+	err = issuer.ValidateEach(inputs)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Writes of this transaction are not visible to its own reads, so
+	// duplicates inside the batch have to be caught here.
+	codes := map[string]bool{}
+	names := map[string]string{}
+	var eventItems []issuer.EventItem
+	for i := range inputs {
+		area := &inputs[i]
+		parentCode, err := core.GeoParentCode(area.Level, area.Code)
+		if err != nil {
+			return fmt.Errorf("[%d]: %v", i, err)
+		}
+		codeKey := area.Level + "/" + area.Code
+		if codes[codeKey] {
+			return fmt.Errorf("%s %s is listed more than once", area.Level, area.Code)
+		}
+		codes[codeKey] = true
+
+		for _, name := range append([]string{area.NameTH, area.NameEN}, area.Aliases...) {
+			if name == "" {
+				continue
+			}
+			nameKey := area.Level + "/" + parentCode + "/" + core.NormalizeGeoName(name)
+			if code, ok := names[nameKey]; ok && code != area.Code {
+				return fmt.Errorf("name %q is used by both %s and %s", name, code, area.Code)
+			}
+			names[nameKey] = area.Code
+		}
+
+		area.UpdatedAt = now
+		err = core.PutGeoArea(ctx, area)
+		if err != nil {
+			return err
+		}
+		eventItems = append(eventItems, issuer.EventItem{ID: codeKey})
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTUPDATED, entity.GEODOCTYPE, eventItems...)
+}
+
+// GetGeographyAreas lists the areas of a level (province, district or
+// subDistrict), optionally only those below parentCode.
+func (s *SmartContract) GetGeographyAreas(ctx contractapi.TransactionContextInterface, level string, parentCode string) ([]*entity.GeoArea, error) {
+	return core.GetGeoAreas(ctx, level, parentCode)
+}
+
+// GetGapWithinBoundingBox finds plots whose location lies inside the box, a
+// page of at most limit records at a time. Pass the returned bookmark to
+// fetch the next page.
+func (s *SmartContract) GetGapWithinBoundingBox(
+	ctx contractapi.TransactionContextInterface,
+	minLat float64,
+	minLon float64,
+	maxLat float64,
+	maxLon float64,
+	limit int,
+	bookmark string,
+) (*entity.GetPageReponse, error) {
+	if minLat > maxLat || minLon > maxLon {
+		return nil, fmt.Errorf("bounding box min corner must be below and left of the max corner")
+	}
+
+	filter := issuer.FilterAssetsOnly(map[string]interface{}{
+		"location.lat": map[string]interface{}{"$gte": minLat, "$lte": maxLat},
+		"location.lon": map[string]interface{}{"$gte": minLon, "$lte": maxLon},
+	})
+	queryString, err := issuer.BuildQueryString(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, nextBookmark, err := core.FetchPage(ctx, queryString, limit, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.GetPageReponse{
+		Data:     "Gap within bounding box",
+		Obj:      assets,
+		Bookmark: nextBookmark,
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	gap "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gap/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 )
 
 func main() {
@@ -17,7 +18,7 @@ func main() {
 		log.Panicf("Error creating gap chaincode: %v", err)
 	}
 
-	if err := abacSmartContract.Start(); err != nil {
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
 		log.Panicf("Error starting gap chaincode: %v", err)
 	}
 }
@@ -4,24 +4,25 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go/entity"
 )
 
-func SetFilter(input *entity.FilterGetAll) map[string]interface{} {
+func SetFilter(input *entity.FilterGetAllGMP) map[string]interface{} {
 	var filter = map[string]interface{}{}
 
-	if input.PackingHouseRegisterNumber != nil {
+	if input.PackingHouseRegisterNumber != "" {
 		filter["packingHouseRegisterNumber"] = input.PackingHouseRegisterNumber
 	}
 
-	if input.Address != nil {
+	if input.Address != "" {
 		filter["address"] = input.Address
 	}
 
-	return filter
+	return issuer.FilterAssetsOnly(filter)
 }
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll, filter map[string]interface{}) ([]*entity.TransectionReponse, error) {
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllGMP, filter map[string]interface{}) ([]*entity.TransectionGMPReponse, error) {
 	selector := map[string]interface{}{
 		"selector": filter,
 	}
@@ -42,15 +43,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryGmp.Close()
 
-	var dataGmp []*entity.TransectionReponse
+	var dataGmp []*entity.TransectionGMPReponse
 	for queryGmp.HasNext() {
 		queryRes, err := queryGmp.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataG entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataG)
+		var dataG entity.TransectionGMPReponse
+		err = Schema.Unmarshal(ctx, queryRes.Value, &dataG)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,6 @@
+package core
+
+import "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+
+// Schema upgrades stored GMPs to the current entity.TransectionGMP.
+var Schema = issuer.NewSchema()
@@ -2,18 +2,23 @@ package entity
 
 import "time"
 
+const ENTITYNAME string = "gmp"
+
 type TransectionGMP struct {
-	Id                         string    `json:"id"`
-	PackerId 									 string    `json:"packerId"`
-	PackingHouseRegisterNumber string    `json:"packingHouseRegisterNumber"`
-	Address                    string    `json:"address"`
-	PackingHouseName           string    `json:"packingHouseName"`
-	UpdatedDate                string    `json:"updatedDate"`
-	Source                     string    `json:"source"`
+	Id                         string    `json:"id" validate:"required,maxlen=128"`
+	PackerId 									 string    `json:"packerId" validate:"maxlen=128"`
+	PackingHouseRegisterNumber string    `json:"packingHouseRegisterNumber" validate:"required,maxlen=128"`
+	Address                    string    `json:"address" validate:"maxlen=1024"`
+	PackingHouseName           string    `json:"packingHouseName" validate:"maxlen=256"`
+	UpdatedDate                string    `json:"updatedDate" validate:"date"`
+	Source                     string    `json:"source" validate:"maxlen=256"`
 	Owner                      string    `json:"owner"`
 	OrgName                    string    `json:"orgName"`
 	UpdatedAt                  time.Time `json:"updatedAt"`
 	CreatedAt                  time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
 type Pagination struct {
@@ -21,10 +26,10 @@ type Pagination struct {
 	Limit int `json:"limit"`
 }
 
-type FilterGetAll struct {
-	Skip                       int     `json:"skip"`
-	PackerId 									 string  `json:"packerId"`
-	Limit                      int     `json:"limit"`
-	PackingHouseRegisterNumber *string `json:"packingHouseRegisterNumber"`
-	Address                    *string `json:"address"`
+type FilterGetAllGMP struct {
+	Skip                       int    `json:"skip" metadata:",optional"`
+	PackerId                   string `json:"packerId" metadata:",optional"`
+	Limit                      int    `json:"limit"`
+	PackingHouseRegisterNumber string `json:"packingHouseRegisterNumber" metadata:",optional"`
+	Address                    string `json:"address" metadata:",optional"`
 }
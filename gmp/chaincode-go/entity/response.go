@@ -2,7 +2,7 @@ package entity
 
 import "time"
 
-type TransectionReponse struct {
+type TransectionGMPReponse struct {
 	Id                         string    `json:"id"`
 	PackerId 									 string    `json:"packerId"`
 	PackingHouseRegisterNumber string    `json:"packingHouseRegisterNumber"`
@@ -14,13 +14,13 @@ type TransectionReponse struct {
 	CreatedAt                  time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllGMPReponse struct {
+	Data  string                   `json:"data"`
+	Obj   []*TransectionGMPReponse `json:"obj"`
+	Total int                      `json:"total"`
 }
 
 type GetByRegisterNumberResponse struct {
 	Data string              `json:"data"`
-	Obj  *TransectionReponse `json:"obj"`
+	Obj  *TransectionGMPReponse `json:"obj"`
 }
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go/core"
@@ -15,15 +16,37 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllGMP",
+		"FilterGmp",
+		"GetGmpByPackingHouseNumber",
+		"GetTransfer",
+		"GetRegulatoryStatus",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
 func (s *SmartContract) CreateGMP(
 	ctx contractapi.TransactionContextInterface,
 	args string,
 ) error {
 	entityGmp := entity.TransectionGMP{}
 	inputInterface, err := issuer.Unmarshal(args, entityGmp)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionGMP)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("gmp.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
@@ -36,7 +59,7 @@ func (s *SmartContract) CreateGMP(
 		return fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	TimeGmp := issuer.GetTimeNow()
@@ -53,27 +76,47 @@ func (s *SmartContract) CreateGMP(
 		OrgName:                    orgName,
 		UpdatedAt:                  TimeGmp,
 		CreatedAt:                  TimeGmp,
+		SchemaVersion:              core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, args string) error {
 
 	entityGmp := entity.TransectionGMP{}
 	inputInterface, err := issuer.Unmarshal(args, entityGmp)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionGMP)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
-	if clientID != asset.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	err = issuer.AssertNotRevoked(ctx, issuer.TARGETGMP, asset.PackingHouseRegisterNumber)
+	if err != nil {
+		return err
+	}
+
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
 	}
 
 	UpdatedGmp := issuer.GetTimeNow()
@@ -90,42 +133,91 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	assetJSON, errG := json.Marshal(asset)
 	issuer.HandleError(errG)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetGmp, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDGmp, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetGmp.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDGmp != assetGmp.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetGmp)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetG, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertNotRevoked(ctx, issuer.TARGETGMP, assetG.PackingHouseRegisterNumber)
+	if err != nil {
+		return err
+	}
+
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetG.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
 
-	if clientID != assetG.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetG, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetG.Owner)
+	if err != nil {
+		return err
 	}
 
-	assetG.Owner = newOwner
+	assetG.Owner = transfer.AcceptedBy
+	assetG.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetG)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionGMP, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -137,7 +229,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionGMP
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -145,14 +237,9 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &asset, nil
 }
 
-func (s *SmartContract) GetAllGMP(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllGMP(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllGMP) (*entity.GetAllGMPReponse, error) {
 
-	entityGetAllGmp := entity.FilterGetAll{}
-	interfaceGmp, err := issuer.Unmarshal(args, entityGetAllGmp)
-	if err != nil {
-		return nil, err
-	}
-	inputGmp := interfaceGmp.(*entity.FilterGetAll)
+	inputGmp := &input
 	filterGmp := core.SetFilter(inputGmp)
 
 	queryStringGmp, err := issuer.BuildQueryString(filterGmp)
@@ -179,10 +266,10 @@ func (s *SmartContract) GetAllGMP(ctx contractapi.TransactionContextInterface, a
 	})
 
 	if len(assets) == 0 {
-		assets = []*entity.TransectionReponse{}
+		assets = []*entity.TransectionGMPReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllGMPReponse{
 		Data:  "All Gmp",
 		Obj:   assets,
 		Total: total,
@@ -194,7 +281,7 @@ func (s *SmartContract) GetGmpByPackingHouseNumber(ctx contractapi.TransactionCo
 	queryKeyPackingHouse := fmt.Sprintf(`{"selector":{"packingHouseRegisterNumber":"%s"}}`, packingHouseRegisterNumber)
 
 	resultsIteratorPackingHouse, err := ctx.GetStub().GetQueryResult(queryKeyPackingHouse)
-	var asset *entity.TransectionReponse
+	var asset *entity.TransectionGMPReponse
 	resData := "Get gmp by packingHouseRegisterNumber"
 	if err != nil {
 		return nil, fmt.Errorf("error querying chaincode: %v", err)
@@ -215,7 +302,7 @@ func (s *SmartContract) GetGmpByPackingHouseNumber(ctx contractapi.TransactionCo
 		return nil, fmt.Errorf("error getting next query result: %v", err)
 	}
 
-	err = json.Unmarshal(queryResponse.Value, &asset)
+	err = core.Schema.Unmarshal(ctx, queryResponse.Value, &asset)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling asset JSON: %v", err)
 	}
@@ -241,14 +328,19 @@ func (s *SmartContract) FilterGmp(ctx contractapi.TransactionContextInterface, k
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionGMP
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -270,8 +362,17 @@ func (s *SmartContract) CreateGmpCsv(
 ) error {
 	var inputs []entity.TransectionGMP
 
+	var eventItems []issuer.EventItem
+
 	errInputGmp := json.Unmarshal([]byte(args), &inputs)
-	issuer.HandleError(errInputGmp)
+	if errInputGmp != nil {
+		return fmt.Errorf("failed to unmarshal JSON array: %v", errInputGmp)
+	}
+
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
 
 	for _, input := range inputs {
 		// err := ctx.GetClientIdentity().AssertAttributeValue("gmp.creator", "true")
@@ -289,7 +390,7 @@ func (s *SmartContract) CreateGmpCsv(
 			return fmt.Errorf("the asset %s already exists", input.Id)
 		}
 
-		clientIDG, err := issuer.GetIdentity(ctx)
+		clientIDG, err := issuer.GetOwnerID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get submitting client's identity: %v", err)
 		}
@@ -304,6 +405,7 @@ func (s *SmartContract) CreateGmpCsv(
 			Source:                     input.Source,
 			Owner:                      clientIDG,
 			OrgName:                    orgNameG,
+			SchemaVersion:              core.Schema.Version(),
 		}
 		assetJSON, err := json.Marshal(assetG)
 		if err != nil {
@@ -315,10 +417,12 @@ func (s *SmartContract) CreateGmpCsv(
 			return fmt.Errorf("failed to put state for asset %s: %v", input.Id, err)
 		}
 
+		eventItems = append(eventItems, issuer.EventItem{ID: input.Id, Payload: assetJSON})
+
 		fmt.Printf("Asset %s created successfully\n", input.Id)
 	}
 
-	return nil
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ENTITYNAME, eventItems...)
 }
 
 
@@ -328,8 +432,17 @@ func (s *SmartContract) UpdateMultipleGmp(
 ) error {
 	var inputs []entity.TransectionGMP
 
+	var eventItems []issuer.EventItem
+
 	errInputGap := json.Unmarshal([]byte(args), &inputs)
-	issuer.HandleError(errInputGap)
+	if errInputGap != nil {
+		return fmt.Errorf("failed to unmarshal JSON array: %v", errInputGap)
+	}
+
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
 	
 	for _, input := range inputs {
 		assetJSON, err := ctx.GetStub().GetState(input.Id)
@@ -341,10 +454,17 @@ func (s *SmartContract) UpdateMultipleGmp(
 		}
 		
 		var existingAsset entity.TransectionGMP
-		err = json.Unmarshal(assetJSON, &existingAsset)
+		err = core.Schema.Unmarshal(ctx, assetJSON, &existingAsset)
 		if err != nil {
 			return fmt.Errorf("failed to unmarshal existing asset: %v", err)
 		}
+		before := existingAsset
+
+		err = issuer.AssertNotRevoked(ctx, issuer.TARGETGMP, existingAsset.PackingHouseRegisterNumber)
+		if err != nil {
+			return err
+		}
+
 		UpdatedGmp := issuer.GetTimeNow()
 		
 		existingAsset.Id = input.Id
@@ -365,9 +485,93 @@ func (s *SmartContract) UpdateMultipleGmp(
 		if err != nil {
 			return fmt.Errorf("failed to update asset in world state: %v", err)
 		}
+
+		eventItem, err := issuer.EventDiff(input.Id, before, existingAsset)
+		if err != nil {
+			return err
+		}
+		eventItems = append(eventItems, eventItem)
 		
 		fmt.Printf("Asset %s updated successfully\n", input.Id)
 	}
 	
-	return nil
+	return issuer.EmitEvent(ctx, issuer.EVENTUPDATED, entity.ENTITYNAME, eventItems...)
+}
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetRegulatoryStatus returns the regulator's status of the certificate of
+// a GMP record.
+func (s *SmartContract) GetRegulatoryStatus(ctx contractapi.TransactionContextInterface, id string) (*issuer.RegulatoryStatus, error) {
+	assetG, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return issuer.GetRegulatoryStatus(ctx, issuer.TARGETGMP, assetG.PackingHouseRegisterNumber)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionGMP{},
+	})
+}
+
+// AdminOverride corrects fields of a GMP record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionGMP{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
 }
@@ -0,0 +1,202 @@
+package gmp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go/entity"
+	gmp "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/gmp/chaincode-go/smart-contract"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org2MSP", "bob", nil)
+)
+
+// newNetwork deploys the gmp chaincode with a regulator that revoked the
+// packing houses listed in revoked.
+func newNetwork(t *testing.T, revoked ...string) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&gmp.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCGMP, chaincode)
+	network.Deploy(issuer.CCREGULATOR, issuertest.NewFake().
+		On("GetRegulatoryStatus", func(args []string) ([]byte, error) {
+			status := issuer.RegulatoryStatus{TargetType: args[0], TargetID: args[1], Status: issuer.CERTACTIVE}
+			for _, registerNumber := range revoked {
+				if registerNumber == args[1] {
+					status.Status = issuer.CERTREVOKED
+				}
+			}
+			return json.Marshal(status)
+		}))
+	return network
+}
+
+func TestCreateGMP(t *testing.T) {
+	network := newNetwork(t)
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{name: "valid", args: `{"id":"M-1","packingHouseRegisterNumber":"GMP-001","packingHouseName":"Suan Mamuang"}`},
+		{name: "existing id", args: `{"id":"M-1","packingHouseRegisterNumber":"GMP-002"}`, wantErr: true},
+		{name: "missing register number", args: `{"id":"M-3"}`, wantErr: true},
+		{name: "invalid updatedDate", args: `{"id":"M-4","packingHouseRegisterNumber":"GMP-004","updatedDate":"yesterday"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCGMP, "CreateGMP", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(bob, issuer.CCGMP, "GetGmpByPackingHouseNumber", "GMP-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found entity.GetByRegisterNumberResponse
+	if err := json.Unmarshal(payload, &found); err != nil || found.Obj == nil || found.Obj.Id != "M-1" {
+		t.Fatalf("GetGmpByPackingHouseNumber = %s, %v", payload, err)
+	}
+}
+
+func TestUpdateGMP(t *testing.T) {
+	network := newNetwork(t, "GMP-REVOKED")
+	for _, args := range []string{
+		`{"id":"M-1","packingHouseRegisterNumber":"GMP-001"}`,
+		`{"id":"M-2","packingHouseRegisterNumber":"GMP-REVOKED"}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCGMP, "CreateGMP", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		args     string
+		wantErr  bool
+	}{
+		{name: "not the owner", identity: bob, args: `{"id":"M-1","packingHouseRegisterNumber":"GMP-001","address":"Bangkok"}`, wantErr: true},
+		{name: "owner", identity: alice, args: `{"id":"M-1","packingHouseRegisterNumber":"GMP-001","address":"Chiang Mai"}`},
+		{name: "revoked", identity: alice, args: `{"id":"M-2","packingHouseRegisterNumber":"GMP-REVOKED","address":"Chiang Mai"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCGMP, "UpdateAsset", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCGMP, "ReadAsset", "M-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionGMP
+	if err := json.Unmarshal(payload, &asset); err != nil || asset.Address != "Chiang Mai" {
+		t.Fatalf("asset = %s, %v", payload, err)
+	}
+}
+
+func TestTransferGMP(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCGMP, "CreateGMP", `{"id":"M-1","packingHouseRegisterNumber":"GMP-001"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		identity *issuertest.Identity
+		function string
+		args     []string
+		wantErr  bool
+	}{
+		{name: "receiver cannot propose", identity: bob, function: "ProposeTransfer", args: []string{"M-1", bob.MSPID, "24"}, wantErr: true},
+		{name: "owner proposes", identity: alice, function: "ProposeTransfer", args: []string{"M-1", bob.MSPID, "24"}},
+		{name: "owner cannot accept", identity: alice, function: "AcceptTransfer", args: []string{"M-1"}, wantErr: true},
+		{name: "receiver accepts", identity: bob, function: "AcceptTransfer", args: []string{"M-1"}},
+	}
+	for _, step := range steps {
+		if _, err := network.Submit(step.identity, issuer.CCGMP, step.function, step.args...); (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", step.name, err, step.wantErr)
+		}
+	}
+
+	for identity, want := range map[*issuertest.Identity]string{alice: "false", bob: "true"} {
+		payload, err := network.Evaluate(identity, issuer.CCGMP, "VerifyOwnership", "M-1")
+		if err != nil || string(payload) != want {
+			t.Fatalf("VerifyOwnership as %s = %s, %v; want %s", identity.Name, payload, err, want)
+		}
+	}
+}
+
+func TestGetAllGMP(t *testing.T) {
+	network := newNetwork(t)
+	for _, args := range []string{
+		`{"id":"M-1","packingHouseRegisterNumber":"GMP-001","address":"Chiang Mai"}`,
+		`{"id":"M-2","packingHouseRegisterNumber":"GMP-002","address":"Rayong"}`,
+		`{"id":"M-3","packingHouseRegisterNumber":"GMP-003","address":"Chiang Mai"}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCGMP, "CreateGMP", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := network.Submit(alice, issuer.CCGMP, "ProposeTransfer", "M-1", bob.MSPID, "24"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "all", args: `{"limit":0}`, want: "[M-1 M-2 M-3]", wantTotal: 3},
+		{name: "register number", args: `{"packingHouseRegisterNumber":"GMP-002","limit":0}`, want: "[M-2]", wantTotal: 1},
+		{name: "address", args: `{"address":"Chiang Mai","limit":0}`, want: "[M-1 M-3]", wantTotal: 2},
+		{name: "skip and limit", args: `{"skip":1,"limit":1}`, want: "[M-2]", wantTotal: 3},
+		{name: "skip past the total", args: `{"skip":4,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCGMP, "GetAllGMP", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllGMPReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("gmps = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Fields an administrative override may not change. Registration has its own
+// lifecycle transaction, and migrations own the schema version.
+var overrideProtected = []string{"id", DOCTYPE, "owner", "orgName", "createdAt", "updatedAt", "registration", SCHEMAVERSION}
+
+// ApplyOverride loads the record stored under id into record, a pointer to the
+// chaincode's model struct, upgraded to schema and with the fields of patch (a
+// JSON object) replaced.
+// Only administrators may call it, patch may only name fields of the model
+// other than overrideProtected, and the result must pass Validate. The caller
+// stamps UpdatedAt and writes the record.
+func ApplyOverride(ctx contractapi.TransactionContextInterface, schema *Schema, id, patch string, record interface{}) error {
+	if err := AssertAdmin(ctx); err != nil {
+		return err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(patch), &fields); err != nil {
+		return fmt.Errorf("patch must be a JSON object: %v", err)
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("patch is empty")
+	}
+	known := map[string]bool{}
+	recordType := reflect.Indirect(reflect.ValueOf(record)).Type()
+	for i := 0; i < recordType.NumField(); i++ {
+		known[jsonName(recordType.Field(i))] = true
+	}
+	for name := range fields {
+		if !known[name] || containsString(overrideProtected, name) {
+			return fmt.Errorf("field %q cannot be overridden", name)
+		}
+	}
+
+	recordJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if recordJSON == nil {
+		return fmt.Errorf("the asset %s does not exist", id)
+	}
+	recordJSON, _, err = schema.Upgrade(ctx, recordJSON)
+	if err != nil {
+		return err
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(recordJSON, &stored); err != nil {
+		return fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	for name, value := range fields {
+		stored[name] = value
+	}
+
+	merged, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(merged, record); err != nil {
+		return fmt.Errorf("patch does not match the record: %v", err)
+	}
+	return Validate(record)
+}
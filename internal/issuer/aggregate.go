@@ -0,0 +1,279 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	AGGREGATEPAGESIZE    int = 500
+	AGGREGATEMAXPAGESIZE int = 1000
+)
+
+// Date fields can be grouped into buckets with a suffix, e.g. createdAt:month.
+var dateBuckets = map[string]string{
+	"day":   "2006-01-02",
+	"month": "2006-01",
+	"year":  "2006",
+}
+
+// AggregateSpec lists the fields a chaincode allows to be grouped, summed and
+// range-filtered by date.
+type AggregateSpec struct {
+	GroupBy    []string
+	Fields     []string
+	DateFields []string
+}
+
+// AggregateQuery runs over one page of at most PageSize records. To cover the
+// whole range, call again with the returned Bookmark and Groups until Done.
+type AggregateQuery struct {
+	GroupBy   []string               `json:"groupBy"`
+	Fields    []string               `json:"fields"`
+	DateField string                 `json:"dateField"`
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	Filter    map[string]interface{} `json:"filter"`
+	PageSize  int                    `json:"pageSize"`
+	Bookmark  string                 `json:"bookmark"`
+	Groups    []AggregateGroup       `json:"groups"`
+}
+
+type AggregateGroup struct {
+	Key   map[string]string  `json:"key"`
+	Count int                `json:"count"`
+	Ratio float64            `json:"ratio"`
+	Sum   map[string]float64 `json:"sum"`
+	Avg   map[string]float64 `json:"avg"`
+}
+
+type AggregateResult struct {
+	Groups   []AggregateGroup `json:"groups"`
+	Count    int              `json:"count"`
+	Scanned  int              `json:"scanned"`
+	Bookmark string           `json:"bookmark"`
+	Done     bool             `json:"done"`
+}
+
+// ParseDate reads the date formats found in stored records: RFC 3339 times
+// written by the chaincode and the DATEFORMATS accepted on input.
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range append([]string{time.RFC3339Nano}, DATEFORMATS...) {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func (spec AggregateSpec) checkQuery(query *AggregateQuery) error {
+	for _, field := range query.GroupBy {
+		name, bucket := field, ""
+		if i := strings.Index(field, ":"); i >= 0 {
+			name, bucket = field[:i], field[i+1:]
+		}
+		if bucket != "" {
+			if _, ok := dateBuckets[bucket]; !ok || !containsString(spec.DateFields, name) {
+				return fmt.Errorf("cannot group by %q", field)
+			}
+		} else if !containsString(spec.GroupBy, name) {
+			return fmt.Errorf("cannot group by %q", field)
+		}
+	}
+	for _, field := range query.Fields {
+		if !containsString(spec.Fields, field) {
+			return fmt.Errorf("cannot aggregate %q", field)
+		}
+	}
+	for field := range query.Filter {
+		if !containsString(spec.GroupBy, field) {
+			return fmt.Errorf("cannot filter by %q", field)
+		}
+	}
+	if query.From != "" || query.To != "" {
+		if !containsString(spec.DateFields, query.DateField) {
+			return fmt.Errorf("dateField must be one of %s", strings.Join(spec.DateFields, ", "))
+		}
+	}
+	return nil
+}
+
+func inDateRange(value interface{}, from, to *time.Time) bool {
+	text, ok := value.(string)
+	if !ok {
+		return false
+	}
+	date, err := ParseDate(text)
+	if err != nil {
+		return false
+	}
+	return (from == nil || !date.Before(*from)) && (to == nil || !date.After(*to))
+}
+
+func groupValue(record map[string]interface{}, field string) string {
+	name, bucket := field, ""
+	if i := strings.Index(field, ":"); i >= 0 {
+		name, bucket = field[:i], field[i+1:]
+	}
+	value, ok := record[name]
+	if !ok || value == nil {
+		return ""
+	}
+	if bucket != "" {
+		date, err := ParseDate(fmt.Sprint(value))
+		if err != nil {
+			return ""
+		}
+		return date.Format(dateBuckets[bucket])
+	}
+	if number, ok := value.(float64); ok {
+		return fmt.Sprint(number)
+	}
+	return fmt.Sprint(value)
+}
+
+func parseRange(query *AggregateQuery) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if query.From != "" {
+		parsed, err := ParseDate(query.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		from = &parsed
+	}
+	if query.To != "" {
+		parsed, err := ParseDate(query.To)
+		if err != nil {
+			return nil, nil, err
+		}
+		// A bare date includes the whole day.
+		if len(query.To) == len("2006-01-02") {
+			parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+		}
+		to = &parsed
+	}
+	return from, to, nil
+}
+
+// Aggregate groups one page of the chaincode's asset records and merges it
+// into the groups carried over from the previous pages.
+func Aggregate(ctx contractapi.TransactionContextInterface, spec AggregateSpec, query *AggregateQuery) (*AggregateResult, error) {
+	if err := spec.checkQuery(query); err != nil {
+		return nil, err
+	}
+	from, to, err := parseRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = AGGREGATEPAGESIZE
+	}
+	if pageSize > AGGREGATEMAXPAGESIZE {
+		pageSize = AGGREGATEMAXPAGESIZE
+	}
+
+	filter := map[string]interface{}{}
+	for field, value := range query.Filter {
+		filter[field] = value
+	}
+	queryString, err := BuildQueryString(FilterAssetsOnly(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, int32(pageSize), query.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	groups := map[string]*AggregateGroup{}
+	result := &AggregateResult{}
+	for _, group := range query.Groups {
+		group := group
+		keyParts := []string{}
+		for _, field := range query.GroupBy {
+			keyParts = append(keyParts, group.Key[field])
+		}
+		groups[strings.Join(keyParts, "\x00")] = &group
+		result.Count += group.Count
+	}
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		result.Scanned++
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(queryResponse.Value, &record); err != nil {
+			return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+		}
+		if (from != nil || to != nil) && !inDateRange(record[query.DateField], from, to) {
+			continue
+		}
+
+		key := map[string]string{}
+		keyParts := []string{}
+		for _, field := range query.GroupBy {
+			value := groupValue(record, field)
+			key[field] = value
+			keyParts = append(keyParts, value)
+		}
+		groupKey := strings.Join(keyParts, "\x00")
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &AggregateGroup{Key: key}
+			groups[groupKey] = group
+		}
+		if group.Sum == nil {
+			group.Sum = map[string]float64{}
+		}
+		group.Count++
+		result.Count++
+		for _, field := range query.Fields {
+			if number, ok := record[field].(float64); ok {
+				group.Sum[field] += number
+			}
+		}
+	}
+
+	result.Groups = []AggregateGroup{}
+	for _, group := range groups {
+		group.Avg = map[string]float64{}
+		if group.Sum == nil {
+			group.Sum = map[string]float64{}
+		}
+		for field, sum := range group.Sum {
+			if group.Count > 0 {
+				group.Avg[field] = sum / float64(group.Count)
+			}
+		}
+		if result.Count > 0 {
+			group.Ratio = float64(group.Count) / float64(result.Count)
+		}
+		result.Groups = append(result.Groups, *group)
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		for _, field := range query.GroupBy {
+			if result.Groups[i].Key[field] != result.Groups[j].Key[field] {
+				return result.Groups[i].Key[field] < result.Groups[j].Key[field]
+			}
+		}
+		return false
+	})
+
+	result.Done = int(metadata.GetFetchedRecordsCount()) < pageSize
+	if !result.Done {
+		result.Bookmark = metadata.GetBookmark()
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package issuer
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// GetInvokedChaincode returns the name of the chaincode the client's proposal
+// invoked. It differs from the running chaincode when this one was reached
+// through Invoke from another chaincode. Within a NamespacedChaincode it is
+// the contract the client called.
+func GetInvokedChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+	if stub, ok := ctx.GetStub().(*NamespacedStub); ok {
+		return stub.InvokedContract(), nil
+	}
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	var proposal peer.Proposal
+	if err := proto.Unmarshal(signedProposal.ProposalBytes, &proposal); err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal: %v", err)
+	}
+	var payload peer.ChaincodeProposalPayload
+	if err := proto.Unmarshal(proposal.Payload, &payload); err != nil {
+		return "", fmt.Errorf("failed to unmarshal proposal payload: %v", err)
+	}
+	var spec peer.ChaincodeInvocationSpec
+	if err := proto.Unmarshal(payload.Input, &spec); err != nil {
+		return "", fmt.Errorf("failed to unmarshal invocation spec: %v", err)
+	}
+	return spec.GetChaincodeSpec().GetChaincodeId().GetName(), nil
+}
+
+// AssertCalledVia accepts calls made through chaincodeName, which is then
+// responsible for authorizing the client, and calls from administrators.
+func AssertCalledVia(ctx contractapi.TransactionContextInterface, chaincodeName string) error {
+	invoked, err := GetInvokedChaincode(ctx)
+	if err != nil {
+		return err
+	}
+	if invoked == chaincodeName {
+		return nil
+	}
+	if err := AssertAdmin(ctx); err != nil {
+		return fmt.Errorf("must be called through the %s chaincode: %v", chaincodeName, err)
+	}
+	return nil
+}
+
+// AssertCalledThrough accepts only calls made through chaincodeName. Unlike
+// AssertCalledVia it does not let administrators call directly, for
+// transactions whose caller keeps the audit record.
+func AssertCalledThrough(ctx contractapi.TransactionContextInterface, chaincodeName string) error {
+	invoked, err := GetInvokedChaincode(ctx)
+	if err != nil {
+		return err
+	}
+	if invoked != chaincodeName {
+		return fmt.Errorf("must be called through the %s chaincode", chaincodeName)
+	}
+	return nil
+}
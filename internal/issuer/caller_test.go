@@ -0,0 +1,78 @@
+package issuer_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+// calledVia is a chaincode answering whether assert, AssertCalledVia or
+// AssertCalledThrough, accepts a call through farmer.
+type calledVia struct {
+	assert func(ctx contractapi.TransactionContextInterface, chaincodeName string) error
+}
+
+func (chaincode calledVia) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (chaincode calledVia) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	clientIdentity, err := cid.New(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	ctx.SetClientIdentity(clientIdentity)
+	if err := chaincode.assert(ctx, issuer.CCFARMER); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func TestAssertCalledVia(t *testing.T) {
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCGAP, calledVia{assert: issuer.AssertCalledVia})
+	network.Deploy(issuer.CCGMP, calledVia{assert: issuer.AssertCalledThrough})
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		strict   bool
+		via      string
+		wantErr  bool
+	}{
+		{name: "through farmer", identity: member, via: issuer.CCFARMER},
+		{name: "directly", identity: member, via: "", wantErr: true},
+		{name: "through another chaincode", identity: member, via: issuer.CCPACKER, wantErr: true},
+		{name: "administrator directly", identity: admin, via: ""},
+		{name: "strict through farmer", identity: member, strict: true, via: issuer.CCFARMER},
+		{name: "strict administrator directly", identity: admin, strict: true, via: "", wantErr: true},
+		{name: "strict administrator through another chaincode", identity: admin, strict: true, via: issuer.CCPACKER, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chaincode := issuer.CCGAP
+			if test.strict {
+				chaincode = issuer.CCGMP
+			}
+			var err error
+			if test.via == "" {
+				_, err = network.Submit(test.identity, chaincode, "SetGapFarmer")
+			} else {
+				err = network.Run(test.identity, test.via, true, func(ctx contractapi.TransactionContextInterface) error {
+					_, err := issuer.Invoke(ctx, chaincode, "SetGapFarmer")
+					return err
+				})
+			}
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package issuer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// REGULATORMSP must endorse every change to an asset protected by a
+// key-level endorsement policy, together with the owning org.
+const REGULATORMSP string = "RegulatorMSP"
+
+type EndorsementPolicy struct {
+	AssetID string   `json:"assetId"`
+	Orgs    []string `json:"orgs"`
+}
+
+// SetAssetEndorsement requires a peer of every given org to endorse future
+// writes to key, overriding the chaincode endorsement policy for it.
+func SetAssetEndorsement(ctx contractapi.TransactionContextInterface, key string, orgs ...string) error {
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return fmt.Errorf("failed to add orgs to endorsement policy of %s: %v", key, err)
+	}
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy of %s: %v", key, err)
+	}
+	err = ctx.GetStub().SetStateValidationParameter(key, policy)
+	if err != nil {
+		return fmt.Errorf("failed to set endorsement policy of %s: %v", key, err)
+	}
+	return nil
+}
+
+// SetOwnerEndorsement protects key with the submitting client's org and the
+// regulator.
+func SetOwnerEndorsement(ctx contractapi.TransactionContextInterface, key string) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	return SetAssetEndorsement(ctx, key, mspID, REGULATORMSP)
+}
+
+// GetAssetEndorsement lists the orgs whose peers must endorse writes to key.
+// An empty list means the chaincode endorsement policy applies.
+func GetAssetEndorsement(ctx contractapi.TransactionContextInterface, key string) (*EndorsementPolicy, error) {
+	policy, err := ctx.GetStub().GetStateValidationParameter(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endorsement policy of %s: %v", key, err)
+	}
+	result := &EndorsementPolicy{AssetID: key, Orgs: []string{}}
+	if len(policy) == 0 {
+		return result, nil
+	}
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endorsement policy of %s: %v", key, err)
+	}
+	result.Orgs = endorsementPolicy.ListOrgs()
+	sort.Strings(result.Orgs)
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	EVENTVERSION int = 1
+
+	EVENTCREATED          string = "created"
+	EVENTUPDATED          string = "updated"
+	EVENTDELETED          string = "deleted"
+	EVENTTRANSFERPROPOSED string = "transfer.proposed"
+	EVENTTRANSFERACCEPTED string = "transfer.accepted"
+	EVENTTRANSFERREJECTED string = "transfer.rejected"
+	EVENTTRANSFERCANCELED string = "transfer.canceled"
+	EVENTCOMPACTED        string = "compacted"
+)
+
+// ChangeEvent is the envelope of every chaincode event. It is emitted under
+// the name <entity>.<type>. Fabric keeps only one event per transaction, so a
+// write touching several records sends one event listing them in Items.
+type ChangeEvent struct {
+	Version   int                    `json:"version"`
+	Type      string                 `json:"type"`
+	Entity    string                 `json:"entity"`
+	IDs       []string               `json:"ids"`
+	Actor     string                 `json:"actor"`
+	MSPID     string                 `json:"mspId"`
+	TxID      string                 `json:"txId"`
+	Timestamp time.Time              `json:"timestamp"`
+	Payload   json.RawMessage        `json:"payload,omitempty"`
+	Diff      map[string]FieldChange `json:"diff,omitempty"`
+	Items     []EventItem            `json:"items,omitempty"`
+}
+
+// EventItem is one record changed by the transaction. Created records carry
+// their payload, updated records the diff, deleted records their last value.
+type EventItem struct {
+	ID      string                 `json:"id"`
+	Payload json.RawMessage        `json:"payload,omitempty"`
+	Diff    map[string]FieldChange `json:"diff,omitempty"`
+}
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+func EventPayload(id string, value interface{}) (EventItem, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return EventItem{}, fmt.Errorf("failed to marshal event payload for %s: %v", id, err)
+	}
+	return EventItem{ID: id, Payload: payload}, nil
+}
+
+// EventDiff lists the top-level JSON fields that differ between before and
+// after.
+func EventDiff(id string, before, after interface{}) (EventItem, error) {
+	oldFields, err := toFields(before)
+	if err != nil {
+		return EventItem{}, err
+	}
+	newFields, err := toFields(after)
+	if err != nil {
+		return EventItem{}, err
+	}
+
+	diff := map[string]FieldChange{}
+	for name, newValue := range newFields {
+		if oldValue, ok := oldFields[name]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			diff[name] = FieldChange{Old: oldFields[name], New: newValue}
+		}
+	}
+	for name, oldValue := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			diff[name] = FieldChange{Old: oldValue}
+		}
+	}
+	return EventItem{ID: id, Diff: diff}, nil
+}
+
+func toFields(value interface{}) (map[string]interface{}, error) {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(valueJSON, &fields); err != nil {
+		return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	return fields, nil
+}
+
+// EmitEvent sets the transaction's event. It must be called once, after all
+// writes of the transaction succeeded.
+func EmitEvent(ctx contractapi.TransactionContextInterface, eventType, entityName string, items ...EventItem) error {
+	actor, err := GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	timestamp, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	event := ChangeEvent{
+		Version:   EVENTVERSION,
+		Type:      eventType,
+		Entity:    entityName,
+		IDs:       []string{},
+		Actor:     actor,
+		MSPID:     mspID,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: timestamp,
+	}
+	for _, item := range items {
+		event.IDs = append(event.IDs, item.ID)
+	}
+
+	if len(items) == 1 {
+		event.Payload = items[0].Payload
+		event.Diff = items[0].Diff
+	} else {
+		event.Items = items
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+	return ctx.GetStub().SetEvent(entityName+"."+eventType, eventJSON)
+}
+
+func EmitCreated(ctx contractapi.TransactionContextInterface, entityName, id string, value interface{}) error {
+	item, err := EventPayload(id, value)
+	if err != nil {
+		return err
+	}
+	return EmitEvent(ctx, EVENTCREATED, entityName, item)
+}
+
+func EmitUpdated(ctx contractapi.TransactionContextInterface, entityName, id string, before, after interface{}) error {
+	item, err := EventDiff(id, before, after)
+	if err != nil {
+		return err
+	}
+	return EmitEvent(ctx, EVENTUPDATED, entityName, item)
+}
+
+func EmitDeleted(ctx contractapi.TransactionContextInterface, entityName, id string, before interface{}) error {
+	item, err := EventPayload(id, before)
+	if err != nil {
+		return err
+	}
+	return EmitEvent(ctx, EVENTDELETED, entityName, item)
+}
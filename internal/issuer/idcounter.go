@@ -0,0 +1,104 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// IDs allocated on the ledger look like FRM-2026-000123: a prefix per record
+// type, the year of the transaction and a counter that restarts every year.
+const (
+	IDCOUNTERDOCTYPE string = "idCounter"
+
+	IDPREFIXFARMER  string = "FRM"
+	IDPREFIXPACKER  string = "PKR"
+	IDPREFIXPACKING string = "PKG"
+
+	// IDMAXSKIP bounds how many IDs already taken by records created with an
+	// explicit ID NextID steps over.
+	IDMAXSKIP int = 1000
+)
+
+type IDCounter struct {
+	DocType string `json:"docType"`
+	Prefix  string `json:"prefix"`
+	Year    int    `json:"year"`
+	Last    int    `json:"last"`
+	LastID  string `json:"lastId"`
+}
+
+func FormatID(prefix string, year, number int) string {
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, number)
+}
+
+func idCounterKey(ctx contractapi.TransactionContextInterface, prefix string, year int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(IDCOUNTERDOCTYPE, []string{prefix, strconv.Itoa(year)})
+}
+
+// GetIDCounter returns the counter of prefix for the year of the transaction.
+func GetIDCounter(ctx contractapi.TransactionContextInterface, prefix string) (*IDCounter, error) {
+	now, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, err := idCounterKey(ctx, prefix, now.Year())
+	if err != nil {
+		return nil, err
+	}
+	counterJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if counterJSON == nil {
+		return &IDCounter{DocType: IDCOUNTERDOCTYPE, Prefix: prefix, Year: now.Year()}, nil
+	}
+
+	var counter IDCounter
+	if err := json.Unmarshal(counterJSON, &counter); err != nil {
+		return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	return &counter, nil
+}
+
+// NextID allocates the next free ID for prefix. Every allocation reads and
+// writes the same counter key, so when two transactions allocate concurrently
+// Fabric invalidates the later one with an MVCC read conflict instead of
+// handing out the same ID twice; the client resubmits it. Call it at most
+// once per transaction, since a transaction does not read its own writes.
+func NextID(ctx contractapi.TransactionContextInterface, prefix string) (string, error) {
+	counter, err := GetIDCounter(ctx, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	for skipped := 0; ; skipped++ {
+		if skipped == IDMAXSKIP {
+			return "", fmt.Errorf("no free %s ID after %s", prefix, counter.LastID)
+		}
+		counter.Last++
+		counter.LastID = FormatID(prefix, counter.Year, counter.Last)
+		exists, err := AssetExists(ctx, counter.LastID)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			break
+		}
+	}
+
+	key, err := idCounterKey(ctx, prefix, counter.Year)
+	if err != nil {
+		return "", err
+	}
+	counterJSON, err := json.Marshal(counter)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(key, counterJSON); err != nil {
+		return "", fmt.Errorf("failed to put %s counter: %v", prefix, err)
+	}
+	return counter.LastID, nil
+}
@@ -0,0 +1,41 @@
+package issuer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+func TestNextID(t *testing.T) {
+	network := issuertest.NewNetwork()
+	network.Clock = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	// A record created with an explicit ID takes the second number.
+	network.Stub(issuer.CCFARMER).Seed("FRM-2026-000002", []byte(`{"id":"FRM-2026-000002"}`))
+
+	next := func() string {
+		var id string
+		err := network.Run(member, issuer.CCFARMER, true, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			id, err = issuer.NextID(ctx, issuer.IDPREFIXFARMER)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	for _, want := range []string{"FRM-2026-000001", "FRM-2026-000003", "FRM-2026-000004"} {
+		if got := next(); got != want {
+			t.Fatalf("NextID = %s, want %s", got, want)
+		}
+	}
+
+	network.Clock = time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if got := next(); got != "FRM-2027-000001" {
+		t.Fatalf("NextID in a new year = %s, want FRM-2027-000001", got)
+	}
+}
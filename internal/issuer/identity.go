@@ -0,0 +1,159 @@
+package issuer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Owners are stored as sha256:<salt>:<hash of salt and decoded client ID> so
+// the X.509 subject of a member never lands on the shared ledger. The salt is
+// derived from the writing transaction's ID, which makes the same member's
+// records unlinkable to each other. Records written before hashing still hold
+// the raw x509::<subject>::<issuer> ID; IsIdentity accepts both forms and
+// MigrateOwners converts the old ones.
+const (
+	OWNERHASHPREFIX string = "sha256:"
+	LEGACYIDPREFIX  string = "x509::"
+)
+
+// MigrationResult reports a migration batch. Bookmark is the key of the last
+// record it processed, for the next batch to continue after.
+type MigrationResult struct {
+	Migrated int    `json:"migrated"`
+	Bookmark string `json:"bookmark"`
+	Done     bool   `json:"done"`
+}
+
+func HashIdentity(salt, clientID string) string {
+	hash := sha256.Sum256([]byte(salt + "|" + clientID))
+	return OWNERHASHPREFIX + salt + ":" + hex.EncodeToString(hash[:])
+}
+
+func txSalt(ctx contractapi.TransactionContextInterface) string {
+	hash := sha256.Sum256([]byte(ctx.GetStub().GetTxID()))
+	return hex.EncodeToString(hash[:8])
+}
+
+// GetOwnerID is the pseudonymous ID of the submitting client to store as the
+// owner of records written by this transaction.
+func GetOwnerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := GetIdentity(ctx)
+	if err != nil {
+		return "", err
+	}
+	return HashIdentity(txSalt(ctx), clientID), nil
+}
+
+func IsLegacyOwner(owner string) bool {
+	return strings.HasPrefix(owner, LEGACYIDPREFIX)
+}
+
+func matchIdentity(clientID, owner string) bool {
+	if strings.HasPrefix(owner, OWNERHASHPREFIX) {
+		parts := strings.SplitN(strings.TrimPrefix(owner, OWNERHASHPREFIX), ":", 2)
+		return len(parts) == 2 && HashIdentity(parts[0], clientID) == owner
+	}
+	return clientID == owner
+}
+
+// IsIdentity reports whether owner, hashed or raw, is the submitting client.
+func IsIdentity(ctx contractapi.TransactionContextInterface, owner string) (bool, error) {
+	clientID, err := GetIdentity(ctx)
+	if err != nil {
+		return false, err
+	}
+	return matchIdentity(clientID, owner), nil
+}
+
+func AssertIdentity(ctx contractapi.TransactionContextInterface, owner string) error {
+	isOwner, err := IsIdentity(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return ReturnError(UNAUTHORIZE)
+	}
+	return nil
+}
+
+// AssertOwnerOrAdmin accepts the owner of a record and administrators.
+func AssertOwnerOrAdmin(ctx contractapi.TransactionContextInterface, owner string) error {
+	if HasRole(ctx, ADMINROLE) {
+		return nil
+	}
+	return AssertIdentity(ctx, owner)
+}
+
+// MigrateOwners hashes the raw owner of up to pageSize asset records and of
+// their pending transfers. Call again with the returned bookmark until done.
+func MigrateOwners(ctx contractapi.TransactionContextInterface, entityName string, pageSize int, bookmark string) (*MigrationResult, error) {
+	if err := AssertAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 || pageSize > AGGREGATEMAXPAGESIZE {
+		pageSize = AGGREGATEPAGESIZE
+	}
+
+	records, done, err := queryAssetsAfter(ctx, map[string]interface{}{
+		"owner": map[string]interface{}{"$regex": "^" + LEGACYIDPREFIX},
+	}, bookmark, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := txSalt(ctx)
+	result := &MigrationResult{Done: done}
+	var eventItems []EventItem
+	for _, queryResponse := range records {
+		var record map[string]interface{}
+		if err := json.Unmarshal(queryResponse.Value, &record); err != nil {
+			return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+		}
+		rawOwner, _ := record["owner"].(string)
+		owner := HashIdentity(salt, rawOwner)
+		record["owner"] = owner
+
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.GetStub().PutState(queryResponse.Key, recordJSON); err != nil {
+			return nil, fmt.Errorf("failed to put asset %s: %v", queryResponse.Key, err)
+		}
+
+		transfer, err := GetTransfer(ctx, queryResponse.Key)
+		if err == nil && transfer.Status == TRANSFERPENDING && transfer.From == rawOwner {
+			transfer.From = owner
+			if IsLegacyOwner(transfer.To) {
+				transfer.To = HashIdentity(salt, transfer.To)
+			}
+			key, err := transferKey(ctx, transfer.AssetID)
+			if err != nil {
+				return nil, err
+			}
+			transferJSON, err := json.Marshal(transfer)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.GetStub().PutState(key, transferJSON); err != nil {
+				return nil, fmt.Errorf("failed to put transfer for asset %s: %v", transfer.AssetID, err)
+			}
+		}
+
+		eventItems = append(eventItems, EventItem{ID: queryResponse.Key})
+		result.Migrated++
+	}
+
+	if !result.Done {
+		result.Bookmark = records[len(records)-1].Key
+	}
+	if len(eventItems) == 0 {
+		return result, nil
+	}
+	return result, EmitEvent(ctx, "owner.migrated", entityName, eventItems...)
+}
@@ -0,0 +1,69 @@
+package issuer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+func TestMigrateOwners(t *testing.T) {
+	network := issuertest.NewNetwork()
+	stub := network.Stub("records")
+	for _, key := range []string{"1", "2", "3", "4", "5"} {
+		stub.Seed(key, []byte(fmt.Sprintf(`{"id":%q,"owner":%q}`, key, member.ClientID())))
+	}
+	stub.Seed("6", []byte(`{"id":"6","owner":"sha256:00:11"}`))
+
+	migrate := func(identity *issuertest.Identity, bookmark string) (*issuer.MigrationResult, error) {
+		var result *issuer.MigrationResult
+		err := network.Run(identity, "records", true, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = issuer.MigrateOwners(ctx, "record", 2, bookmark)
+			return err
+		})
+		return result, err
+	}
+
+	if _, err := migrate(member, ""); err == nil {
+		t.Fatal("a member migrated owners")
+	}
+
+	bookmark := ""
+	migrated := 0
+	for batches := 1; ; batches++ {
+		result, err := migrate(admin, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrated += result.Migrated
+		if result.Done {
+			break
+		}
+		if batches == 3 {
+			t.Fatalf("not done after %d batches", batches)
+		}
+		bookmark = result.Bookmark
+	}
+	if migrated != 5 {
+		t.Errorf("migrated %d owners, want 5", migrated)
+	}
+
+	for _, key := range []string{"1", "2", "3", "4", "5"} {
+		var record struct {
+			Owner string `json:"owner"`
+		}
+		if err := json.Unmarshal(stub.State(key), &record); err != nil {
+			t.Fatal(err)
+		}
+		err := network.Run(member, "records", false, func(ctx contractapi.TransactionContextInterface) error {
+			return issuer.AssertIdentity(ctx, record.Owner)
+		})
+		if issuer.IsLegacyOwner(record.Owner) || err != nil {
+			t.Errorf("record %s owner = %s, %v", key, record.Owner, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 )
 
 const (
@@ -15,6 +16,7 @@ const (
 	TIMEFORMAT    string = "2006-01-02T15:04:05Z"
 	SKIPOVER      string = "skip over total data"
 	DATAUNMARSHAL string = "unmarshal json string"
+	DOCTYPE       string = "docType"
 )
 
 type SmartContract struct {
@@ -47,6 +49,15 @@ func BuildQueryString(filter map[string]interface{}) (string, error) {
 	return string(queryString), nil
 }
 
+// FilterAssetsOnly narrows a selector to the chaincode's own asset documents.
+// Supporting records (transfers, ...) are written with a docType and skipped.
+func FilterAssetsOnly(filter map[string]interface{}) map[string]interface{} {
+	filter[DOCTYPE] = map[string]interface{}{
+		"$exists": false,
+	}
+	return filter
+}
+
 func CountTotalResults(ctx contractapi.TransactionContextInterface, queryString string) (int, error) {
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -65,12 +76,54 @@ func CountTotalResults(ctx contractapi.TransactionContextInterface, queryString
 	return total, nil
 }
 
+// queryAssetsAfter returns up to size asset records matching filter whose
+// keys follow after, in key order, and whether no records are left. Peers only
+// run paginated queries in read-only transactions, so transactions that
+// rewrite what they query continue from the last key they processed instead.
+func queryAssetsAfter(ctx contractapi.TransactionContextInterface, filter map[string]interface{}, after string, size int) ([]*queryresult.KV, bool, error) {
+	if after != "" {
+		filter["_id"] = map[string]interface{}{"$gt": after}
+	}
+	query, err := json.Marshal(map[string]interface{}{
+		"selector": FilterAssetsOnly(filter),
+		"sort":     []interface{}{map[string]interface{}{"_id": "asc"}},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(query))
+	if err != nil {
+		return nil, false, err
+	}
+	defer resultsIterator.Close()
+
+	var kvs []*queryresult.KV
+	for len(kvs) < size && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, false, err
+		}
+		kvs = append(kvs, queryResponse)
+	}
+	return kvs, !resultsIterator.HasNext(), nil
+}
+
 func GetTimeNow() time.Time {
 	formattedTime := time.Now().Format(TIMEFORMAT)
 	CreatedAt, _ := time.Parse(TIMEFORMAT, formattedTime)
 	return CreatedAt
 }
 
+// GetTxTimestamp returns the proposal timestamp, which is the same on every
+// endorsing peer unlike the local clock.
+func GetTxTimestamp(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTime, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	return txTime.AsTime().UTC(), nil
+}
+
 func ReturnError(data string) error {
 	return fmt.Errorf(data)
 }
@@ -0,0 +1,96 @@
+package issuertest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Call is an invocation a Fake received.
+type Call struct {
+	Function string
+	Args     []string
+}
+
+// Fake stands in for a chaincode another one calls, so a chaincode can be
+// tested without building the modules it depends on. Functions without a
+// handler fail.
+type Fake struct {
+	Calls    []Call
+	handlers map[string]func(args []string) ([]byte, error)
+	forwards map[string]string
+}
+
+var _ shim.Chaincode = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{handlers: map[string]func(args []string) ([]byte, error){}, forwards: map[string]string{}}
+}
+
+// On handles function with handler.
+func (fake *Fake) On(function string, handler func(args []string) ([]byte, error)) *Fake {
+	fake.handlers[function] = handler
+	return fake
+}
+
+// Returns answers function with value: strings and byte slices as they are,
+// anything else as JSON.
+func (fake *Fake) Returns(function string, value interface{}) *Fake {
+	return fake.On(function, func(args []string) ([]byte, error) {
+		switch value := value.(type) {
+		case string:
+			return []byte(value), nil
+		case []byte:
+			return value, nil
+		}
+		return json.Marshal(value)
+	})
+}
+
+// Fails answers function with an error.
+func (fake *Fake) Fails(function, message string) *Fake {
+	return fake.On(function, func(args []string) ([]byte, error) {
+		return nil, fmt.Errorf("%s", message)
+	})
+}
+
+// Forwards answers function by invoking it on chaincode with the same
+// arguments, as a chaincode that checks and relays calls does.
+func (fake *Fake) Forwards(function, chaincode string) *Fake {
+	fake.forwards[function] = chaincode
+	return fake
+}
+
+// CallsTo returns the arguments of every call to function.
+func (fake *Fake) CallsTo(function string) [][]string {
+	var calls [][]string
+	for _, call := range fake.Calls {
+		if call.Function == function {
+			calls = append(calls, call.Args)
+		}
+	}
+	return calls
+}
+
+func (fake *Fake) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (fake *Fake) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+	fake.Calls = append(fake.Calls, Call{Function: function, Args: args})
+	if chaincode, ok := fake.forwards[function]; ok {
+		return stub.InvokeChaincode(chaincode, stub.GetArgs(), "")
+	}
+	handler, ok := fake.handlers[function]
+	if !ok {
+		return shim.Error(fmt.Sprintf("function %s is not faked", function))
+	}
+	payload, err := handler(args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(payload)
+}
@@ -0,0 +1,97 @@
+// Package issuertest runs chaincodes against an in-memory ledger so their
+// transactions can be tested with plain go test, without peers or Docker.
+package issuertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// attrOID is the certificate extension Fabric CA stores attributes in.
+var attrOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// Identity is a client enrolled with a throwaway X.509 certificate. Its
+// attributes are read by cid the same way as those issued by Fabric CA.
+type Identity struct {
+	MSPID   string
+	Name    string
+	Attrs   map[string]string
+	creator []byte
+}
+
+// NewIdentity enrolls name as a member of mspID holding attrs, e.g.
+// {"nstda.admin": "true"}.
+func NewIdentity(mspID, name string, attrs map[string]string) *Identity {
+	if attrs == nil {
+		attrs = map[string]string{}
+	}
+	certPEM, err := newCert(mspID, name, attrs)
+	if err != nil {
+		panic(fmt.Sprintf("issuertest: failed to create certificate for %s: %v", name, err))
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	if err != nil {
+		panic(fmt.Sprintf("issuertest: failed to marshal identity %s: %v", name, err))
+	}
+	return &Identity{MSPID: mspID, Name: name, Attrs: attrs, creator: creator}
+}
+
+// Creator is the serialized identity returned by GetCreator.
+func (identity *Identity) Creator() []byte {
+	return identity.creator
+}
+
+// ClientID is the decoded ID cid returns for the identity, as stored by
+// chaincodes that record raw owners.
+func (identity *Identity) ClientID() string {
+	return fmt.Sprintf("x509::CN=%s,OU=client,O=%s::CN=ca,O=%s", identity.Name, identity.MSPID, identity.MSPID)
+}
+
+func newCert(mspID, name string, attrs map[string]string) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	attrJSON, err := json.Marshal(map[string]interface{}{"attrs": attrs})
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject: pkix.Name{
+			CommonName:         name,
+			OrganizationalUnit: []string{"client"},
+			Organization:       []string{mspID},
+		},
+		Issuer:    pkix.Name{CommonName: "ca", Organization: []string{mspID}},
+		NotBefore: time.Unix(0, 0),
+		NotAfter:  time.Now().AddDate(100, 0, 0),
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{
+			{Id: attrOID, Value: attrJSON},
+		},
+	}
+	// The certificate is signed with its own key but under a separate CA
+	// name, so the client ID has a distinct issuer DN as with Fabric CA.
+	parent := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      template.Issuer,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
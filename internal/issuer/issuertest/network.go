@@ -0,0 +1,179 @@
+package issuertest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// tx is the transaction being simulated. Chaincodes it reaches through
+// InvokeChaincode join it and commit or roll back with it.
+type tx struct {
+	id        string
+	timestamp time.Time
+	identity  *Identity
+	transient map[string][]byte
+	chaincode string
+	args      [][]byte
+	stubs     []*MockStub
+
+	paginated bool
+	written   bool
+}
+
+// Network is a channel of chaincodes sharing one in-memory ledger. Each
+// transaction is stamped with Clock, which then moves on by Step.
+type Network struct {
+	ChannelID string
+	Clock     time.Time
+	Step      time.Duration
+
+	stubs   map[string]*MockStub
+	events  []*peer.ChaincodeEvent
+	txCount int
+	current *tx
+}
+
+func NewNetwork() *Network {
+	return &Network{
+		ChannelID: "mychannel",
+		Clock:     time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Step:      time.Second,
+		stubs:     map[string]*MockStub{},
+	}
+}
+
+// Deploy installs chaincode under name, replacing any chaincode of that name
+// but keeping its state.
+func (network *Network) Deploy(name string, chaincode shim.Chaincode) *MockStub {
+	stub := network.Stub(name)
+	stub.chaincode = chaincode
+	return stub
+}
+
+// Stub returns the state of the named chaincode, created empty when nothing
+// was deployed under the name.
+func (network *Network) Stub(name string) *MockStub {
+	stub, ok := network.stubs[name]
+	if !ok {
+		stub = newMockStub(network, name, nil)
+		network.stubs[name] = stub
+	}
+	return stub
+}
+
+// Events returns the events of the committed transactions in order.
+func (network *Network) Events() []*peer.ChaincodeEvent {
+	return network.events
+}
+
+// LastEvent returns the event of the latest committed transaction that set
+// one, nil if none did.
+func (network *Network) LastEvent() *peer.ChaincodeEvent {
+	if len(network.events) == 0 {
+		return nil
+	}
+	return network.events[len(network.events)-1]
+}
+
+// Submit invokes function of chaincode as identity and commits the writes of
+// every chaincode involved if it succeeds. The error is the chaincode's
+// message when it fails.
+func (network *Network) Submit(identity *Identity, chaincode, function string, args ...string) ([]byte, error) {
+	return network.SubmitTransient(identity, chaincode, nil, function, args...)
+}
+
+// SubmitTransient is Submit with transient data, e.g. private data inputs.
+func (network *Network) SubmitTransient(identity *Identity, chaincode string, transient map[string][]byte, function string, args ...string) ([]byte, error) {
+	return network.invoke(identity, chaincode, transient, true, function, args)
+}
+
+// Evaluate invokes function of chaincode as identity and discards its
+// writes, like a query that is never sent for ordering.
+func (network *Network) Evaluate(identity *Identity, chaincode, function string, args ...string) ([]byte, error) {
+	return network.invoke(identity, chaincode, nil, false, function, args)
+}
+
+func (network *Network) invoke(identity *Identity, chaincode string, transient map[string][]byte, submit bool, function string, args []string) ([]byte, error) {
+	stub, ok := network.stubs[chaincode]
+	if !ok || stub.chaincode == nil {
+		return nil, fmt.Errorf("chaincode %s is not deployed", chaincode)
+	}
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	response := network.execute(identity, stub, transient, invokeArgs, submit, func() peer.Response {
+		return stub.chaincode.Invoke(stub)
+	})
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return nil, errors.New(response.Message)
+	}
+	return response.Payload, nil
+}
+
+// Run calls fn with a transaction context of chaincode for identity, to test
+// helpers that take a context directly. The writes are committed when submit
+// is set and fn succeeds.
+func (network *Network) Run(identity *Identity, chaincode string, submit bool, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	stub := network.Stub(chaincode)
+	var err error
+	network.execute(identity, stub, nil, [][]byte{[]byte("Run")}, submit, func() peer.Response {
+		clientIdentity, cidErr := cid.New(stub)
+		if cidErr != nil {
+			err = cidErr
+			return shim.Error(err.Error())
+		}
+		ctx := &contractapi.TransactionContext{}
+		ctx.SetStub(stub)
+		ctx.SetClientIdentity(clientIdentity)
+		if err = fn(ctx); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	})
+	return err
+}
+
+func (network *Network) execute(identity *Identity, stub *MockStub, transient map[string][]byte, args [][]byte, submit bool, run func() peer.Response) peer.Response {
+	if network.current != nil {
+		panic("issuertest: a transaction is already running")
+	}
+	network.txCount++
+	txID := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", network.ChannelID, network.txCount)))
+	t := &tx{
+		id:        hex.EncodeToString(txID[:]),
+		timestamp: network.Clock,
+		identity:  identity,
+		transient: transient,
+		chaincode: stub.Name,
+		args:      args,
+	}
+	network.Clock = network.Clock.Add(network.Step)
+	network.current = t
+	defer func() { network.current = nil }()
+
+	stub.join(t, args)
+	response := run()
+
+	committed := submit && response.Status < shim.ERRORTHRESHOLD
+	if committed && stub.event != nil {
+		network.events = append(network.events, stub.event)
+	}
+	for _, joined := range t.stubs {
+		if committed {
+			joined.commit()
+		} else {
+			joined.end()
+		}
+	}
+	return response
+}
@@ -0,0 +1,276 @@
+package issuertest_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+func TestIdentity(t *testing.T) {
+	network := issuertest.NewNetwork()
+	admin := issuertest.NewIdentity("Org1MSP", "alice", map[string]string{"nstda.admin": "true"})
+
+	err := network.Run(admin, "cc", false, func(ctx contractapi.TransactionContextInterface) error {
+		mspID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return err
+		}
+		if mspID != "Org1MSP" {
+			return fmt.Errorf("MSP ID = %s", mspID)
+		}
+		if err := ctx.GetClientIdentity().AssertAttributeValue("nstda.admin", "true"); err != nil {
+			return err
+		}
+		id, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return err
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(id)
+		if string(decoded) != admin.ClientID() {
+			return fmt.Errorf("client ID = %s, want %s", decoded, admin.ClientID())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWritesCommitWithTransaction(t *testing.T) {
+	network := issuertest.NewNetwork()
+	client := issuertest.NewIdentity("Org1MSP", "bob", nil)
+
+	err := network.Run(client, "cc", true, func(ctx contractapi.TransactionContextInterface) error {
+		if err := ctx.GetStub().PutState("a", []byte("1")); err != nil {
+			return err
+		}
+		value, err := ctx.GetStub().GetState("a")
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			return fmt.Errorf("read own write %s", value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(network.Stub("cc").State("a")); got != "1" {
+		t.Fatalf("committed a = %q, want 1", got)
+	}
+
+	err = network.Run(client, "cc", true, func(ctx contractapi.TransactionContextInterface) error {
+		if err := ctx.GetStub().PutState("a", []byte("2")); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil || err.Error() != "abort" {
+		t.Fatalf("err = %v, want abort", err)
+	}
+	if got := string(network.Stub("cc").State("a")); got != "1" {
+		t.Fatalf("a after failed transaction = %q, want 1", got)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	network := issuertest.NewNetwork()
+	client := issuertest.NewIdentity("Org1MSP", "bob", nil)
+	stub := network.Stub("cc")
+	stub.Seed("p1", []byte(`{"name":"mango","origin":{"province":"Chanthaburi"}}`))
+	stub.Seed("p2", []byte(`{"name":"durian","origin":{"province":"Rayong"}}`))
+	stub.Seed("p3", []byte(`{"name":"mango","origin":{"province":"Rayong"},"docType":"aux"}`))
+	key, _ := stub.CreateCompositeKey("idx", []string{"mango", "p1"})
+	stub.Seed(key, []byte(`{}`))
+
+	tests := []struct {
+		name    string
+		query   func(ctx contractapi.TransactionContextInterface) ([]string, error)
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "range skips composite keys",
+			query: func(ctx contractapi.TransactionContextInterface) ([]string, error) {
+				iterator, err := ctx.GetStub().GetStateByRange("", "")
+				if err != nil {
+					return nil, err
+				}
+				return keysOf(iterator)
+			},
+			want: []string{"p1", "p2", "p3"},
+		},
+		{
+			name: "partial composite key",
+			query: func(ctx contractapi.TransactionContextInterface) ([]string, error) {
+				iterator, err := ctx.GetStub().GetStateByPartialCompositeKey("idx", []string{"mango"})
+				if err != nil {
+					return nil, err
+				}
+				var ids []string
+				for iterator.HasNext() {
+					kv, _ := iterator.Next()
+					_, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+					if err != nil {
+						return nil, err
+					}
+					ids = append(ids, attributes[1])
+				}
+				return ids, nil
+			},
+			want: []string{"p1"},
+		},
+		{
+			name:  "selector with nested field and $exists",
+			query: richQuery(`{"selector":{"origin.province":"Rayong","docType":{"$exists":false}}}`),
+			want:  []string{"p2"},
+		},
+		{
+			name:  "nested object selector",
+			query: richQuery(`{"selector":{"name":{"$in":["mango"]},"origin":{"province":"Rayong"}}}`),
+			want:  []string{"p3"},
+		},
+		{
+			name:    "unsupported operator",
+			query:   richQuery(`{"selector":{"name":{"$foo":"x"}}}`),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			err := network.Run(client, "cc", false, func(ctx contractapi.TransactionContextInterface) error {
+				var err error
+				got, err = test.query(ctx)
+				return err
+			})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPaginationAndHistory(t *testing.T) {
+	network := issuertest.NewNetwork()
+	client := issuertest.NewIdentity("Org1MSP", "bob", nil)
+	for _, value := range []string{"1", "2", "3"} {
+		value := value
+		if err := network.Run(client, "cc", true, func(ctx contractapi.TransactionContextInterface) error {
+			return ctx.GetStub().PutState("k"+value, []byte(`{"v":`+value+`}`))
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := network.Run(client, "cc", true, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().DelState("k1")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := network.Run(client, "cc", false, func(ctx contractapi.TransactionContextInterface) error {
+		var pages [][]string
+		bookmark := ""
+		for {
+			iterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(`{"selector":{}}`, 1, bookmark)
+			if err != nil {
+				return err
+			}
+			keys, err := keysOf(iterator)
+			if err != nil {
+				return err
+			}
+			if metadata.FetchedRecordsCount == 0 {
+				break
+			}
+			pages = append(pages, keys)
+			bookmark = metadata.Bookmark
+		}
+		if fmt.Sprint(pages) != "[[k2] [k3]]" {
+			return fmt.Errorf("pages = %v", pages)
+		}
+
+		history, err := ctx.GetStub().GetHistoryForKey("k1")
+		if err != nil {
+			return err
+		}
+		first, _ := history.Next()
+		second, _ := history.Next()
+		if !first.IsDelete || second.IsDelete || string(second.Value) != `{"v":1}` || history.HasNext() {
+			return fmt.Errorf("history of k1 = %v, %v", first, second)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvokeChaincode(t *testing.T) {
+	network := issuertest.NewNetwork()
+	client := issuertest.NewIdentity("Org1MSP", "bob", nil)
+	fake := issuertest.NewFake().
+		Returns("ReadAsset", map[string]string{"id": "g1"}).
+		Fails("Broken", "broken")
+	network.Deploy("gap", fake)
+
+	err := network.Run(client, "farmer", true, func(ctx contractapi.TransactionContextInterface) error {
+		response := ctx.GetStub().InvokeChaincode("gap", [][]byte{[]byte("ReadAsset"), []byte("g1")}, "")
+		if string(response.Payload) != `{"id":"g1"}` {
+			return fmt.Errorf("payload = %s", response.Payload)
+		}
+		if response := ctx.GetStub().InvokeChaincode("gap", [][]byte{[]byte("Broken")}, ""); response.Message != "broken" {
+			return fmt.Errorf("message = %s", response.Message)
+		}
+		if response := ctx.GetStub().InvokeChaincode("missing", nil, ""); response.Status == 200 {
+			return fmt.Errorf("invoked a chaincode that is not deployed")
+		}
+		return ctx.GetStub().SetEvent("farmer.linked", []byte(`{}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.CallsTo("ReadAsset"); len(calls) != 1 || calls[0][0] != "g1" {
+		t.Fatalf("calls = %v", calls)
+	}
+	if event := network.LastEvent(); event == nil || event.EventName != "farmer.linked" || event.ChaincodeId != "farmer" {
+		t.Fatalf("event = %v", event)
+	}
+}
+
+func richQuery(query string) func(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return func(ctx contractapi.TransactionContextInterface) ([]string, error) {
+		iterator, err := ctx.GetStub().GetQueryResult(query)
+		if err != nil {
+			return nil, err
+		}
+		return keysOf(iterator)
+	}
+}
+
+func keysOf(iterator shim.StateQueryIteratorInterface) ([]string, error) {
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,351 @@
+package issuertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// mangoQuery is the part of a CouchDB query the stub understands. Other
+// fields, such as use_index and fields, are ignored.
+type mangoQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int32                  `json:"limit"`
+	Skip     int32                  `json:"skip"`
+}
+
+// sortField is one entry of a query's sort list.
+type sortField struct {
+	path       string
+	descending bool
+}
+
+// runQuery evaluates a CouchDB query against every JSON document in values.
+// Matches come in key order unless the query sorts them. The page starts
+// after the result keyed bookmark, drops skip results and holds at most
+// pageSize of them; like Fabric, a non-zero pageSize replaces the query's
+// limit.
+func runQuery(values map[string][]byte, query string, pageSize int32, bookmark string) ([]*queryresult.KV, *peer.QueryResponseMetadata, error) {
+	var parsed mangoQuery
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+	if parsed.Selector == nil {
+		return nil, nil, fmt.Errorf("query %s has no selector", query)
+	}
+	if parsed.Skip < 0 || parsed.Limit < 0 {
+		return nil, nil, fmt.Errorf("query %s has a negative skip or limit", query)
+	}
+	fields, err := parseSort(parsed.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kvs := []*queryresult.KV{}
+	docs := map[string]interface{}{}
+	for _, key := range sortedKeys(values) {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(values[key], &doc); err != nil || doc == nil {
+			continue
+		}
+		// Like CouchDB, selectors see the key as _id.
+		doc["_id"] = key
+		ok, err := matchSelector(doc, parsed.Selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok && hasFields(doc, fields) {
+			kvs = append(kvs, &queryresult.KV{Key: key, Value: values[key]})
+			docs[key] = doc
+		}
+	}
+	sortResults(kvs, docs, fields)
+
+	if pageSize == 0 {
+		pageSize = parsed.Limit
+	}
+	kvs, metadata := page(kvs, pageSize, bookmark, parsed.Skip)
+	return kvs, metadata, nil
+}
+
+// parseSort reads a sort list of field names and {"field": "asc|desc"}
+// objects. CouchDB requires every field to be sorted in the same direction.
+func parseSort(entries []interface{}) ([]sortField, error) {
+	fields := []sortField{}
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case string:
+			fields = append(fields, sortField{path: entry})
+		case map[string]interface{}:
+			if len(entry) != 1 {
+				return nil, fmt.Errorf("sort entry %v must name exactly one field", entry)
+			}
+			for path, direction := range entry {
+				switch direction {
+				case "asc":
+					fields = append(fields, sortField{path: path})
+				case "desc":
+					fields = append(fields, sortField{path: path, descending: true})
+				default:
+					return nil, fmt.Errorf("sort direction of %s must be asc or desc, got %v", path, direction)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort entry %v", entry)
+		}
+	}
+	for _, field := range fields {
+		if field.descending != fields[0].descending {
+			return nil, fmt.Errorf("sort fields must all use the same direction")
+		}
+	}
+	return fields, nil
+}
+
+// hasFields reports whether doc holds every sort field. CouchDB serves a
+// sorted query from an index, which leaves out documents without the field.
+func hasFields(doc interface{}, fields []sortField) bool {
+	for _, field := range fields {
+		if _, ok := lookup(doc, field.path); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sortResults orders kvs by the sort fields, breaking ties by key as an index
+// scan does. A descending sort reverses the whole order, ties included.
+func sortResults(kvs []*queryresult.KV, docs map[string]interface{}, fields []sortField) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(kvs, func(i, j int) bool {
+		for _, field := range fields {
+			a, _ := lookup(docs[kvs[i].Key], field.path)
+			b, _ := lookup(docs[kvs[j].Key], field.path)
+			if c := collate(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	if fields[0].descending {
+		for i, j := 0, len(kvs)-1; i < j; i, j = i+1, j-1 {
+			kvs[i], kvs[j] = kvs[j], kvs[i]
+		}
+	}
+}
+
+// matchSelector reports whether doc matches selector. It supports equality,
+// dotted paths and nested objects, the combinators $and, $or, $nor and $not,
+// and the operators $eq, $ne, $gt, $gte, $lt, $lte, $exists, $in, $nin,
+// $regex and $elemMatch.
+func matchSelector(doc interface{}, selector map[string]interface{}) (bool, error) {
+	return matchCondition(doc, true, selector)
+}
+
+// matchCondition matches value against a condition: a literal to compare
+// with, or an object whose $ keys are operators applied to value and whose
+// other keys select fields of value.
+func matchCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+	object, isObject := condition.(map[string]interface{})
+	if !isObject {
+		return exists && reflect.DeepEqual(value, condition), nil
+	}
+
+	for key, operand := range object {
+		var ok bool
+		var err error
+		if strings.HasPrefix(key, "$") {
+			ok, err = matchOperator(value, exists, key, operand)
+		} else {
+			field, fieldExists := lookup(value, key)
+			ok, err = matchCondition(field, fieldExists, operand)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(value interface{}, exists bool, operator string, operand interface{}) (bool, error) {
+	switch operator {
+	case "$and", "$or", "$nor":
+		conditions, isArray := operand.([]interface{})
+		if !isArray {
+			return false, fmt.Errorf("%s needs an array, got %v", operator, operand)
+		}
+		matched := 0
+		for _, condition := range conditions {
+			ok, err := matchCondition(value, exists, condition)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched++
+			}
+		}
+		switch operator {
+		case "$and":
+			return matched == len(conditions), nil
+		case "$or":
+			return matched > 0, nil
+		}
+		return matched == 0, nil
+	case "$not":
+		ok, err := matchCondition(value, exists, operand)
+		return !ok, err
+	case "$eq":
+		return exists && reflect.DeepEqual(value, operand), nil
+	case "$ne":
+		return exists && !reflect.DeepEqual(value, operand), nil
+	case "$gt":
+		return exists && collate(value, operand) > 0, nil
+	case "$gte":
+		return exists && collate(value, operand) >= 0, nil
+	case "$lt":
+		return exists && collate(value, operand) < 0, nil
+	case "$lte":
+		return exists && collate(value, operand) <= 0, nil
+	case "$exists":
+		want, isBool := operand.(bool)
+		if !isBool {
+			return false, fmt.Errorf("$exists needs a boolean, got %v", operand)
+		}
+		return exists == want, nil
+	case "$in", "$nin":
+		candidates, isArray := operand.([]interface{})
+		if !isArray {
+			return false, fmt.Errorf("%s needs an array, got %v", operator, operand)
+		}
+		found := false
+		for _, candidate := range candidates {
+			if reflect.DeepEqual(value, candidate) {
+				found = true
+				break
+			}
+		}
+		return exists && found == (operator == "$in"), nil
+	case "$regex":
+		pattern, isString := operand.(string)
+		if !isString {
+			return false, fmt.Errorf("$regex needs a string, got %v", operand)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid $regex %s: %v", pattern, err)
+		}
+		text, isString := value.(string)
+		return exists && isString && re.MatchString(text), nil
+	case "$elemMatch":
+		elements, isArray := value.([]interface{})
+		if !exists || !isArray {
+			return false, nil
+		}
+		for _, element := range elements {
+			ok, err := matchCondition(element, true, operand)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported selector operator %s", operator)
+}
+
+// collate compares two JSON values in CouchDB view order: null, false, true,
+// numbers, strings, arrays, then objects. Strings compare by byte rather than
+// by CouchDB's ICU collation, which only differs for mixed case and accents.
+func collate(a, b interface{}) int {
+	if ra, rb := collationRank(a), collationRank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := collate(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		keysA, keysB := sortedFields(a), sortedFields(b)
+		for i := 0; i < len(keysA) && i < len(keysB); i++ {
+			if c := strings.Compare(keysA[i], keysB[i]); c != 0 {
+				return c
+			}
+			if c := collate(a[keysA[i]], b[keysB[i]]); c != 0 {
+				return c
+			}
+		}
+		return len(keysA) - len(keysB)
+	}
+	return 0
+}
+
+func collationRank(value interface{}) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
+
+func sortedFields(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookup follows a dotted field path through nested objects.
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	value := doc
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
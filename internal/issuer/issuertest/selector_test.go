@@ -0,0 +1,122 @@
+package issuertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestMatchSelector(t *testing.T) {
+	doc := map[string]interface{}{}
+	err := json.Unmarshal([]byte(`{
+		"id": "K-1",
+		"gmp": "GMP-0042",
+		"packingHouseName": "Suan Mamuang",
+		"forecastWeight": 120,
+		"createdAt": "2024-03-15T08:00:00Z",
+		"farmerId": "",
+		"gapIds": ["G-1", "G-2"],
+		"farmerGaps": [{"certId": "GAP-001"}, {"certId": "GAP-002", "areaRai": 5}]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "equality", selector: `{"id":"K-1","farmerId":""}`, want: true},
+		{name: "equality on missing field", selector: `{"certId":"K-1"}`},
+		{name: "range", selector: `{"forecastWeight":{"$gte":100,"$lte":120}}`, want: true},
+		{name: "range excludes", selector: `{"forecastWeight":{"$gt":120}}`},
+		{name: "date range", selector: `{"createdAt":{"$gte":"2024-03-01","$lte":"2024-03-31"}}`, want: true},
+		{name: "numbers sort before strings", selector: `{"forecastWeight":{"$lt":"0"}}`, want: true},
+		{name: "$and", selector: `{"$and":[{"id":"K-1"},{"forecastWeight":{"$lt":100}}]}`},
+		{name: "$or", selector: `{"$or":[{"gmp":{"$regex":"^GMP-9"}},{"packingHouseName":{"$regex":"Mamuang"}}]}`, want: true},
+		{name: "$nor", selector: `{"$nor":[{"id":"K-2"},{"id":"K-3"}]}`, want: true},
+		{name: "$not", selector: `{"id":{"$not":{"$in":["K-1","K-2"]}}}`},
+		{name: "$nin", selector: `{"id":{"$nin":["K-2"]}}`, want: true},
+		{name: "$regex on a number", selector: `{"forecastWeight":{"$regex":"120"}}`},
+		{name: "$elemMatch with operator", selector: `{"gapIds":{"$elemMatch":{"$eq":"G-2"}}}`, want: true},
+		{name: "$elemMatch with fields", selector: `{"farmerGaps":{"$elemMatch":{"certId":"GAP-002","areaRai":{"$gte":5}}}}`, want: true},
+		{name: "$elemMatch needs one element", selector: `{"farmerGaps":{"$elemMatch":{"certId":"GAP-001","areaRai":5}}}`},
+		{name: "$elemMatch on a string", selector: `{"gmp":{"$elemMatch":{"$eq":"G"}}}`},
+		{name: "invalid $regex", selector: `{"gmp":{"$regex":"("}}`, wantErr: true},
+		{name: "$or needs an array", selector: `{"$or":{"id":"K-1"}}`, wantErr: true},
+		{name: "unsupported operator", selector: `{"gapIds":{"$size":2}}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var selector map[string]interface{}
+			if err := json.Unmarshal([]byte(test.selector), &selector); err != nil {
+				t.Fatal(err)
+			}
+			got, err := matchSelector(doc, selector)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("match = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	values := map[string][]byte{
+		"a": []byte(`{"weight":30,"name":"a"}`),
+		"b": []byte(`{"weight":10,"name":"b"}`),
+		"c": []byte(`{"weight":20,"name":"c"}`),
+		"d": []byte(`{"weight":10,"name":"d"}`),
+		"e": []byte(`{"name":"e"}`),
+		"f": []byte(`not json`),
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		pageSize     int32
+		bookmark     string
+		want         string
+		wantBookmark string
+		wantErr      bool
+	}{
+		{name: "key order", query: `{"selector":{}}`, want: "[a b c d e]", wantBookmark: "e"},
+		{name: "ascending sort leaves out missing fields", query: `{"selector":{},"sort":["weight"]}`, want: "[b d c a]", wantBookmark: "a"},
+		{name: "descending sort", query: `{"selector":{},"sort":[{"weight":"desc"}]}`, want: "[a c d b]", wantBookmark: "b"},
+		{name: "skip and limit", query: `{"selector":{},"sort":[{"weight":"asc"}],"skip":1,"limit":2}`, want: "[d c]", wantBookmark: "c"},
+		{name: "page size replaces limit", query: `{"selector":{},"limit":1}`, pageSize: 3, want: "[a b c]", wantBookmark: "c"},
+		{name: "bookmark in sort order", query: `{"selector":{},"sort":["weight"]}`, pageSize: 2, bookmark: "d", want: "[c a]", wantBookmark: "a"},
+		{name: "skip after bookmark", query: `{"selector":{},"skip":1}`, pageSize: 2, bookmark: "a", want: "[c d]", wantBookmark: "d"},
+		{name: "past the end", query: `{"selector":{}}`, bookmark: "e", want: "[]", wantBookmark: "e"},
+		{name: "mixed directions", query: `{"selector":{},"sort":[{"weight":"asc"},{"name":"desc"}]}`, wantErr: true},
+		{name: "no selector", query: `{"sort":["weight"]}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kvs, metadata, err := runQuery(values, test.query, test.pageSize, test.bookmark)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := []string{}
+			for _, kv := range kvs {
+				keys = append(keys, kv.Key)
+			}
+			if got := fmt.Sprint(keys); got != test.want {
+				t.Fatalf("keys = %s, want %s", got, test.want)
+			}
+			if metadata.Bookmark != test.wantBookmark || int(metadata.FetchedRecordsCount) != len(keys) {
+				t.Fatalf("metadata = %v", metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,693 @@
+package issuertest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	compositeKeyNamespace = "\x00"
+	emptyKeySubstitute    = "\x01"
+)
+
+var _ shim.ChaincodeStubInterface = (*MockStub)(nil)
+
+// MockStub is the world state of one chaincode. Like a peer, it buffers a
+// transaction's writes until the transaction commits, so reads within the
+// transaction see the state it started from.
+type MockStub struct {
+	Name string
+
+	network   *Network
+	chaincode shim.Chaincode
+
+	state   map[string][]byte
+	private map[string]map[string][]byte
+	params  map[string][]byte
+	history map[string][]*queryresult.KeyModification
+
+	tx            *tx
+	args          [][]byte
+	writes        map[string][]byte
+	privateWrites map[string]map[string][]byte
+	paramWrites   map[string][]byte
+	event         *peer.ChaincodeEvent
+}
+
+func newMockStub(network *Network, name string, chaincode shim.Chaincode) *MockStub {
+	return &MockStub{
+		Name:      name,
+		network:   network,
+		chaincode: chaincode,
+		state:     map[string][]byte{},
+		private:   map[string]map[string][]byte{},
+		params:    map[string][]byte{},
+		history:   map[string][]*queryresult.KeyModification{},
+	}
+}
+
+// Seed writes key straight into the committed state, outside a transaction.
+func (stub *MockStub) Seed(key string, value []byte) {
+	stub.state[key] = value
+}
+
+// State returns the committed value of key, nil if it does not exist.
+func (stub *MockStub) State(key string) []byte {
+	return stub.state[key]
+}
+
+// Keys returns the committed keys in order, composite keys included.
+func (stub *MockStub) Keys() []string {
+	return sortedKeys(stub.state)
+}
+
+// PrivateState returns the committed value of key in collection.
+func (stub *MockStub) PrivateState(collection, key string) []byte {
+	return stub.private[collection][key]
+}
+
+// join adds the stub to t, whose client or another chaincode calls it with
+// args.
+func (stub *MockStub) join(t *tx, args [][]byte) {
+	if stub.tx != t {
+		stub.tx = t
+		stub.writes = map[string][]byte{}
+		stub.privateWrites = map[string]map[string][]byte{}
+		stub.paramWrites = map[string][]byte{}
+		stub.event = nil
+		t.stubs = append(t.stubs, stub)
+	}
+	stub.args = args
+}
+
+func (stub *MockStub) commit() {
+	for _, key := range sortedKeys(stub.writes) {
+		value := stub.writes[key]
+		stub.history[key] = append(stub.history[key], &queryresult.KeyModification{
+			TxId:      stub.tx.id,
+			Value:     value,
+			Timestamp: timestamppb.New(stub.tx.timestamp),
+			IsDelete:  value == nil,
+		})
+		if value == nil {
+			delete(stub.state, key)
+		} else {
+			stub.state[key] = value
+		}
+	}
+	for collection, writes := range stub.privateWrites {
+		if stub.private[collection] == nil {
+			stub.private[collection] = map[string][]byte{}
+		}
+		for key, value := range writes {
+			if value == nil {
+				delete(stub.private[collection], key)
+			} else {
+				stub.private[collection][key] = value
+			}
+		}
+	}
+	for key, ep := range stub.paramWrites {
+		stub.params[key] = ep
+	}
+	stub.end()
+}
+
+func (stub *MockStub) end() {
+	stub.tx = nil
+	stub.args = nil
+	stub.writes = nil
+	stub.privateWrites = nil
+	stub.paramWrites = nil
+	stub.event = nil
+}
+
+func (stub *MockStub) assertTx() error {
+	if stub.tx == nil {
+		return fmt.Errorf("chaincode %s is not running a transaction", stub.Name)
+	}
+	return nil
+}
+
+// assertWrite fails a write after a paginated query: like a peer, the stub
+// only runs those in read-only transactions.
+func (stub *MockStub) assertWrite() error {
+	if err := stub.assertTx(); err != nil {
+		return err
+	}
+	if stub.tx.paginated {
+		return fmt.Errorf("transaction %s ran a paginated query and must not write", stub.tx.id)
+	}
+	stub.tx.written = true
+	return nil
+}
+
+func (stub *MockStub) assertPaginated() error {
+	if err := stub.assertTx(); err != nil {
+		return err
+	}
+	if stub.tx.written {
+		return fmt.Errorf("transaction %s wrote state and must not run a paginated query", stub.tx.id)
+	}
+	stub.tx.paginated = true
+	return nil
+}
+
+func (stub *MockStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *MockStub) GetStringArgs() []string {
+	args := make([]string, len(stub.args))
+	for i, arg := range stub.args {
+		args[i] = string(arg)
+	}
+	return args
+}
+
+func (stub *MockStub) GetFunctionAndParameters() (string, []string) {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (stub *MockStub) GetArgsSlice() ([]byte, error) {
+	var slice []byte
+	for _, arg := range stub.args {
+		slice = append(slice, arg...)
+	}
+	return slice, nil
+}
+
+func (stub *MockStub) GetTxID() string {
+	if stub.tx == nil {
+		return ""
+	}
+	return stub.tx.id
+}
+
+func (stub *MockStub) GetChannelID() string {
+	return stub.network.ChannelID
+}
+
+// InvokeChaincode runs another chaincode deployed on the network as part of
+// the current transaction.
+func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	if err := stub.assertTx(); err != nil {
+		return shim.Error(err.Error())
+	}
+	if channel != "" && channel != stub.network.ChannelID {
+		return shim.Error(fmt.Sprintf("channel %s is not known", channel))
+	}
+	callee, ok := stub.network.stubs[chaincodeName]
+	if !ok || callee.chaincode == nil {
+		return shim.Error(fmt.Sprintf("chaincode %s is not deployed", chaincodeName))
+	}
+
+	callerArgs := callee.args
+	callee.join(stub.tx, args)
+	defer func() { callee.args = callerArgs }()
+	return callee.chaincode.Invoke(callee)
+}
+
+func (stub *MockStub) GetState(key string) ([]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	return stub.state[key], nil
+}
+
+func (stub *MockStub) PutState(key string, value []byte) error {
+	if err := stub.assertWrite(); err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	stub.writes[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (stub *MockStub) DelState(key string) error {
+	if err := stub.assertWrite(); err != nil {
+		return err
+	}
+	stub.writes[key] = nil
+	return nil
+}
+
+func (stub *MockStub) SetStateValidationParameter(key string, ep []byte) error {
+	return stub.SetPrivateDataValidationParameter("", key, ep)
+}
+
+func (stub *MockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return stub.GetPrivateDataValidationParameter("", key)
+}
+
+func (stub *MockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	iterator, _, err := stub.getStateByRange(startKey, endKey, 0, "")
+	return iterator, err
+}
+
+func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertPaginated(); err != nil {
+		return nil, nil, err
+	}
+	return stub.getStateByRange(startKey, endKey, pageSize, bookmark)
+}
+
+func (stub *MockStub) getStateByRange(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, nil, err
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	kvs, metadata := page(rangeKVs(stub.state, startKey, endKey), pageSize, bookmark, 0)
+	return newStateIterator(kvs), metadata, nil
+}
+
+func (stub *MockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	iterator, _, err := stub.getStateByPartialCompositeKey(objectType, keys, 0, "")
+	return iterator, err
+}
+
+func (stub *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertPaginated(); err != nil {
+		return nil, nil, err
+	}
+	return stub.getStateByPartialCompositeKey(objectType, keys, pageSize, bookmark)
+}
+
+func (stub *MockStub) getStateByPartialCompositeKey(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, nil, err
+	}
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	kvs, metadata := page(rangeKVs(stub.state, startKey, endKey), pageSize, bookmark, 0)
+	return newStateIterator(kvs), metadata, nil
+}
+
+func (stub *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (stub *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	return splitCompositeKey(compositeKey)
+}
+
+func (stub *MockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	iterator, _, err := stub.getQueryResult(query, 0, "")
+	return iterator, err
+}
+
+func (stub *MockStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertPaginated(); err != nil {
+		return nil, nil, err
+	}
+	return stub.getQueryResult(query, pageSize, bookmark)
+}
+
+func (stub *MockStub) getQueryResult(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, nil, err
+	}
+	kvs, metadata, err := runQuery(stub.state, query, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newStateIterator(kvs), metadata, nil
+}
+
+// GetHistoryForKey returns the committed values of key, newest first.
+func (stub *MockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	modifications := stub.history[key]
+	reversed := make([]*queryresult.KeyModification, 0, len(modifications))
+	for i := len(modifications) - 1; i >= 0; i-- {
+		reversed = append(reversed, modifications[i])
+	}
+	return &historyIterator{modifications: reversed}, nil
+}
+
+func (stub *MockStub) GetPrivateData(collection, key string) ([]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	if collection == "" {
+		return nil, fmt.Errorf("collection must not be an empty string")
+	}
+	return stub.private[collection][key], nil
+}
+
+func (stub *MockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, err := stub.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (stub *MockStub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := stub.assertWrite(); err != nil {
+		return err
+	}
+	if collection == "" {
+		return fmt.Errorf("collection must not be an empty string")
+	}
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if stub.privateWrites[collection] == nil {
+		stub.privateWrites[collection] = map[string][]byte{}
+	}
+	stub.privateWrites[collection][key] = append([]byte{}, value...)
+	return nil
+}
+
+func (stub *MockStub) DelPrivateData(collection, key string) error {
+	if err := stub.assertWrite(); err != nil {
+		return err
+	}
+	if collection == "" {
+		return fmt.Errorf("collection must not be an empty string")
+	}
+	if stub.privateWrites[collection] == nil {
+		stub.privateWrites[collection] = map[string][]byte{}
+	}
+	stub.privateWrites[collection][key] = nil
+	return nil
+}
+
+// PurgePrivateData deletes the key; there is no history of private data to
+// purge.
+func (stub *MockStub) PurgePrivateData(collection, key string) error {
+	return stub.DelPrivateData(collection, key)
+}
+
+func (stub *MockStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	if err := stub.assertWrite(); err != nil {
+		return err
+	}
+	stub.paramWrites[collection+compositeKeyNamespace+key] = append([]byte{}, ep...)
+	return nil
+}
+
+func (stub *MockStub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	return stub.params[collection+compositeKeyNamespace+key], nil
+}
+
+func (stub *MockStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	return newStateIterator(rangeKVs(stub.private[collection], startKey, endKey)), nil
+}
+
+func (stub *MockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeKVs(stub.private[collection], startKey, endKey)), nil
+}
+
+func (stub *MockStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	kvs, _, err := runQuery(stub.private[collection], query, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(kvs), nil
+}
+
+func (stub *MockStub) GetCreator() ([]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	return stub.tx.identity.Creator(), nil
+}
+
+func (stub *MockStub) GetTransient() (map[string][]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	return stub.tx.transient, nil
+}
+
+func (stub *MockStub) GetBinding() ([]byte, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	binding := sha256.Sum256(append([]byte(stub.tx.id), stub.tx.identity.Creator()...))
+	return binding[:], nil
+}
+
+func (stub *MockStub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+// GetSignedProposal returns an unsigned proposal carrying the channel header,
+// the creator and the client's invocation of the top-level chaincode.
+func (stub *MockStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+		ChannelId: stub.network.ChannelID,
+		TxId:      stub.tx.id,
+		Timestamp: timestamppb.New(stub.tx.timestamp),
+	})
+	if err != nil {
+		return nil, err
+	}
+	signatureHeader, err := proto.Marshal(&common.SignatureHeader{Creator: stub.tx.identity.Creator()})
+	if err != nil {
+		return nil, err
+	}
+	header, err := proto.Marshal(&common.Header{ChannelHeader: channelHeader, SignatureHeader: signatureHeader})
+	if err != nil {
+		return nil, err
+	}
+	input, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			Type:        peer.ChaincodeSpec_GOLANG,
+			ChaincodeId: &peer.ChaincodeID{Name: stub.tx.chaincode},
+			Input:       &peer.ChaincodeInput{Args: stub.tx.args},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: input, TransientMap: stub.tx.transient})
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := proto.Marshal(&peer.Proposal{Header: header, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return &peer.SignedProposal{ProposalBytes: proposal}, nil
+}
+
+func (stub *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if err := stub.assertTx(); err != nil {
+		return nil, err
+	}
+	return timestamppb.New(stub.tx.timestamp), nil
+}
+
+// SetEvent sets the transaction's event. As on a peer, a later call replaces
+// an earlier one and only the event of the chaincode the client invoked is
+// emitted.
+func (stub *MockStub) SetEvent(name string, payload []byte) error {
+	if err := stub.assertTx(); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	stub.event = &peer.ChaincodeEvent{
+		ChaincodeId: stub.Name,
+		TxId:        stub.tx.id,
+		EventName:   name,
+		Payload:     append([]byte{}, payload...),
+	}
+	return nil
+}
+
+func validateSimpleKeys(keys ...string) error {
+	for _, key := range keys {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+	return nil
+}
+
+func partialCompositeKeyRange(objectType string, attributes []string) (string, string, error) {
+	startKey, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", "", err
+	}
+	return startKey, startKey + string(utf8.MaxRune), nil
+}
+
+func splitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+	parts := strings.Split(compositeKey[1:], compositeKeyNamespace)
+	if len(parts) < 2 || parts[len(parts)-1] != "" {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+	return parts[0], parts[1 : len(parts)-1], nil
+}
+
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rangeKVs returns the entries from startKey (inclusive) to endKey
+// (exclusive, unbounded when empty) in key order.
+func rangeKVs(values map[string][]byte, startKey, endKey string) []*queryresult.KV {
+	kvs := []*queryresult.KV{}
+	for _, key := range sortedKeys(values) {
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: values[key]})
+	}
+	return kvs
+}
+
+// page returns at most pageSize results (all of them for 0) following the
+// one keyed bookmark, after dropping the first skip of them. Like CouchDB, a
+// bookmark whose record no longer matches resumes at the next key. The
+// returned bookmark is the key of the last result, so the page after the last
+// one is empty.
+func page(kvs []*queryresult.KV, pageSize int32, bookmark string, skip int32) ([]*queryresult.KV, *peer.QueryResponseMetadata) {
+	start := 0
+	if bookmark != "" {
+		start = len(kvs)
+		for i, kv := range kvs {
+			if kv.Key == bookmark {
+				start = i + 1
+				break
+			}
+		}
+		if start == len(kvs) {
+			for i, kv := range kvs {
+				if kv.Key > bookmark {
+					start = i
+					break
+				}
+			}
+		}
+	}
+	start += int(skip)
+	if start > len(kvs) {
+		start = len(kvs)
+	}
+	end := len(kvs)
+	if pageSize > 0 && start+int(pageSize) < end {
+		end = start + int(pageSize)
+	}
+	kvs = kvs[start:end]
+
+	next := bookmark
+	if len(kvs) > 0 {
+		next = kvs[len(kvs)-1].Key
+	}
+	return kvs, &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(kvs)), Bookmark: next}
+}
+
+type stateIterator struct {
+	kvs []*queryresult.KV
+	i   int
+}
+
+func newStateIterator(kvs []*queryresult.KV) *stateIterator {
+	return &stateIterator{kvs: kvs}
+}
+
+func (iterator *stateIterator) HasNext() bool {
+	return iterator.i < len(iterator.kvs)
+}
+
+func (iterator *stateIterator) Next() (*queryresult.KV, error) {
+	if !iterator.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	kv := iterator.kvs[iterator.i]
+	iterator.i++
+	return kv, nil
+}
+
+func (iterator *stateIterator) Close() error {
+	return nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	i             int
+}
+
+func (iterator *historyIterator) HasNext() bool {
+	return iterator.i < len(iterator.modifications)
+}
+
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !iterator.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	modification := iterator.modifications[iterator.i]
+	iterator.i++
+	return modification, nil
+}
+
+func (iterator *historyIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,521 @@
+package issuer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// When several contracts share one chaincode, each keeps its state under its
+// own namespace, the contract name: simple keys are stored as <namespace>:key
+// and composite keys get the namespace in front of their object type. JSON
+// object values also carry the namespace in NAMESPACEFIELD, which rich
+// queries select on so that CouchDB indexes starting with it apply.
+const (
+	NAMESPACESEPARATOR string = ":"
+	NAMESPACEFIELD     string = "contractNamespace"
+
+	// namespaceEnd follows NAMESPACESEPARATOR, so <namespace>; ends the range
+	// of the namespace's simple keys.
+	namespaceEnd string = ";"
+
+	compositeKeyNamespace string = "\x00"
+)
+
+// NamespacedChaincode runs several contracts as one chaincode. Every call
+// sees the state through a NamespacedStub of the contract it addresses, and
+// calls to another of the contracts through InvokeChaincode run in-process,
+// reading and writing that contract's state directly.
+//
+// A transaction carries a single event, and Fabric ignores the events of
+// chaincodes reached through InvokeChaincode. In-process calls behave the
+// same, so the event is always that of the contract the client invoked: a
+// farmer:LinkGapToFarmer call emits farmer.updated but not the gap.updated
+// of the GAP it links. Listeners that follow a contract's records must also
+// follow the contracts calling into it.
+type NamespacedChaincode struct {
+	chaincode *contractapi.ContractChaincode
+	contracts map[string]bool
+}
+
+// NewNamespacedChaincode registers contracts under their names, which become
+// both the contract and the state namespace. Name each contract after the
+// chaincode it replaces, so that InvokeChaincode calls between them resolve
+// in-process.
+func NewNamespacedChaincode(contracts ...contractapi.ContractInterface) (*NamespacedChaincode, error) {
+	names := map[string]bool{}
+	for _, contract := range contracts {
+		name := contract.GetName()
+		if name == "" || strings.Contains(name, NAMESPACESEPARATOR) {
+			return nil, fmt.Errorf("contract name %q must be non-empty and must not contain %s", name, NAMESPACESEPARATOR)
+		}
+		names[name] = true
+	}
+
+	chaincode, err := contractapi.NewChaincode(contracts...)
+	if err != nil {
+		return nil, err
+	}
+	return &NamespacedChaincode{chaincode: chaincode, contracts: names}, nil
+}
+
+func (c *NamespacedChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return c.chaincode.Init(c.newStub(stub))
+}
+
+func (c *NamespacedChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	return c.chaincode.Invoke(c.newStub(stub))
+}
+
+// newStub wraps the stub of a client call in the namespace of the contract
+// its function names, found the way contractapi routes the call.
+func (c *NamespacedChaincode) newStub(stub shim.ChaincodeStubInterface) *NamespacedStub {
+	function, _ := stub.GetFunctionAndParameters()
+	namespace := c.chaincode.DefaultContract
+	if i := strings.LastIndex(function, NAMESPACESEPARATOR); i >= 0 {
+		namespace = function[:i]
+	}
+	return &NamespacedStub{ChaincodeStubInterface: stub, chaincode: c, namespace: namespace, invoked: namespace}
+}
+
+// NamespacedStub confines a contract to its namespace of the shared state.
+// Keys, ranges and rich queries are translated, and keys read back have the
+// namespace removed, so contracts run unchanged.
+type NamespacedStub struct {
+	shim.ChaincodeStubInterface
+	chaincode *NamespacedChaincode
+	namespace string
+	invoked   string
+	// args replaces the arguments of the transaction for in-process calls.
+	args [][]byte
+}
+
+// Namespace is the contract whose state the stub reads and writes.
+func (s *NamespacedStub) Namespace() string {
+	return s.namespace
+}
+
+// InvokedContract is the contract the client called, which differs from
+// Namespace in calls made through InvokeChaincode.
+func (s *NamespacedStub) InvokedContract() string {
+	return s.invoked
+}
+
+func (s *NamespacedStub) GetArgs() [][]byte {
+	if s.args == nil {
+		return s.ChaincodeStubInterface.GetArgs()
+	}
+	return s.args
+}
+
+func (s *NamespacedStub) GetStringArgs() []string {
+	if s.args == nil {
+		return s.ChaincodeStubInterface.GetStringArgs()
+	}
+	args := make([]string, len(s.args))
+	for i, arg := range s.args {
+		args[i] = string(arg)
+	}
+	return args
+}
+
+func (s *NamespacedStub) GetFunctionAndParameters() (string, []string) {
+	if s.args == nil {
+		return s.ChaincodeStubInterface.GetFunctionAndParameters()
+	}
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *NamespacedStub) GetArgsSlice() ([]byte, error) {
+	if s.args == nil {
+		return s.ChaincodeStubInterface.GetArgsSlice()
+	}
+	return bytes.Join(s.args, nil), nil
+}
+
+// InvokeChaincode calls another contract of the chaincode in-process, in its
+// own namespace. Other chaincodes are invoked through the peer as usual.
+func (s *NamespacedStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	if channel != "" || !s.chaincode.contracts[chaincodeName] || len(args) == 0 {
+		return s.ChaincodeStubInterface.InvokeChaincode(chaincodeName, args, channel)
+	}
+	callArgs := append([][]byte{[]byte(chaincodeName + NAMESPACESEPARATOR + string(args[0]))}, args[1:]...)
+	return s.chaincode.chaincode.Invoke(&NamespacedStub{
+		ChaincodeStubInterface: s.ChaincodeStubInterface,
+		chaincode:              s.chaincode,
+		namespace:              chaincodeName,
+		invoked:                s.invoked,
+		args:                   callArgs,
+	})
+}
+
+// SetEvent drops the events of in-process calls, as Fabric drops those of
+// chaincodes reached through InvokeChaincode; see NamespacedChaincode.
+func (s *NamespacedStub) SetEvent(name string, payload []byte) error {
+	if s.args != nil {
+		return nil
+	}
+	return s.ChaincodeStubInterface.SetEvent(name, payload)
+}
+
+func (s *NamespacedStub) prefix() string {
+	return s.namespace + NAMESPACESEPARATOR
+}
+
+// stateKey is where key lives in the shared state. Composite keys already
+// carry the namespace in their object type.
+func (s *NamespacedStub) stateKey(key string) string {
+	if key == "" || strings.HasPrefix(key, compositeKeyNamespace) {
+		return key
+	}
+	return s.prefix() + key
+}
+
+// localKey is stateKey reversed.
+func (s *NamespacedStub) localKey(key string) string {
+	return strings.TrimPrefix(key, s.prefix())
+}
+
+func (s *NamespacedStub) keyRange(startKey, endKey string) (string, string) {
+	if endKey == "" {
+		return s.prefix() + startKey, s.namespace + namespaceEnd
+	}
+	return s.prefix() + startKey, s.prefix() + endKey
+}
+
+// namespaceField is NAMESPACEFIELD set to the namespace, as JSON.
+func (s *NamespacedStub) namespaceField() []byte {
+	namespace, _ := json.Marshal(s.namespace)
+	return append([]byte(`"`+NAMESPACEFIELD+`":`), namespace...)
+}
+
+// stateValue adds NAMESPACEFIELD in front of the fields of a JSON object
+// value, leaving the rest of it byte for byte. Other values are stored as
+// they are, which CouchDB does not query anyway.
+func (s *NamespacedStub) stateValue(value []byte) []byte {
+	if len(value) == 0 || value[0] != '{' {
+		return value
+	}
+	stored := append([]byte{'{'}, s.namespaceField()...)
+	if rest := bytes.TrimLeft(value[1:], " \t\r\n"); len(rest) > 0 && rest[0] != '}' {
+		stored = append(stored, ',')
+	}
+	return append(stored, value[1:]...)
+}
+
+// localValue is stateValue reversed.
+func (s *NamespacedStub) localValue(value []byte) []byte {
+	prefix := append([]byte{'{'}, s.namespaceField()...)
+	if !bytes.HasPrefix(value, prefix) {
+		return value
+	}
+	rest := value[len(prefix):]
+	if len(rest) > 0 && rest[0] == ',' {
+		rest = rest[1:]
+	}
+	return append([]byte{'{'}, rest...)
+}
+
+// query restricts a CouchDB query to the documents of the namespace by
+// NAMESPACEFIELD, which also leads its sort. An _id condition at the top of the selector compares
+// against keys with the namespace, like every other key the contract uses.
+func (s *NamespacedStub) query(query string) (string, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	selector, ok := parsed["selector"].(map[string]interface{})
+	if !ok && parsed["selector"] != nil {
+		selector = map[string]interface{}{"$and": []interface{}{parsed["selector"]}}
+	}
+	if selector == nil {
+		selector = map[string]interface{}{}
+	}
+	if condition, ok := selector["_id"]; ok {
+		selector["_id"] = s.idCondition(condition)
+	}
+	if _, ok := selector[NAMESPACEFIELD]; ok {
+		selector = map[string]interface{}{
+			NAMESPACEFIELD: s.namespace,
+			"$and":         []interface{}{selector},
+		}
+	} else {
+		selector[NAMESPACEFIELD] = s.namespace
+	}
+	parsed["selector"] = selector
+	// Sorting needs an index on the sort fields in order, which the indexes
+	// of a namespaced chaincode start with NAMESPACEFIELD. Being the same for
+	// every document it selects, sorting on it first changes nothing.
+	if sort, ok := parsed["sort"].([]interface{}); ok && len(sort) > 0 {
+		direction := "asc"
+		if first, ok := sort[0].(map[string]interface{}); ok {
+			for _, value := range first {
+				direction, _ = value.(string)
+			}
+		}
+		parsed["sort"] = append([]interface{}{map[string]interface{}{NAMESPACEFIELD: direction}}, sort...)
+	}
+
+	namespaced, err := json.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	return string(namespaced), nil
+}
+
+// privateQuery restricts a CouchDB query on private data, whose values are
+// stored as written so that their hashes hold, to the namespace by key. No
+// index serves that, so private data queries scan the collection.
+func (s *NamespacedStub) privateQuery(query string) (string, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	namespace := map[string]interface{}{
+		"_id": map[string]interface{}{
+			"$regex": "^" + compositeKeyNamespace + "?" + regexp.QuoteMeta(s.prefix()),
+		},
+	}
+	if selector, ok := parsed["selector"]; ok {
+		parsed["selector"] = map[string]interface{}{"$and": []interface{}{namespace, selector}}
+	} else {
+		parsed["selector"] = namespace
+	}
+
+	namespaced, err := json.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	return string(namespaced), nil
+}
+
+func parseQuery(query string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(query))
+	decoder.UseNumber()
+	var parsed map[string]interface{}
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+	return parsed, nil
+}
+
+// idCondition moves the keys compared against _id into the namespace.
+func (s *NamespacedStub) idCondition(condition interface{}) interface{} {
+	switch condition := condition.(type) {
+	case string:
+		return s.stateKey(condition)
+	case []interface{}:
+		keys := make([]interface{}, len(condition))
+		for i, key := range condition {
+			keys[i] = s.idCondition(key)
+		}
+		return keys
+	case map[string]interface{}:
+		translated := map[string]interface{}{}
+		for operator, operand := range condition {
+			switch operator {
+			case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte", "$in", "$nin":
+				translated[operator] = s.idCondition(operand)
+			default:
+				translated[operator] = operand
+			}
+		}
+		return translated
+	}
+	return condition
+}
+
+func (s *NamespacedStub) iterator(iterator shim.StateQueryIteratorInterface, err error) (shim.StateQueryIteratorInterface, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedIterator{StateQueryIteratorInterface: iterator, stub: s}, nil
+}
+
+func (s *NamespacedStub) GetState(key string) ([]byte, error) {
+	value, err := s.ChaincodeStubInterface.GetState(s.stateKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return s.localValue(value), nil
+}
+
+func (s *NamespacedStub) PutState(key string, value []byte) error {
+	return s.ChaincodeStubInterface.PutState(s.stateKey(key), s.stateValue(value))
+}
+
+func (s *NamespacedStub) DelState(key string) error {
+	return s.ChaincodeStubInterface.DelState(s.stateKey(key))
+}
+
+func (s *NamespacedStub) SetStateValidationParameter(key string, ep []byte) error {
+	return s.ChaincodeStubInterface.SetStateValidationParameter(s.stateKey(key), ep)
+}
+
+func (s *NamespacedStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.ChaincodeStubInterface.GetStateValidationParameter(s.stateKey(key))
+}
+
+func (s *NamespacedStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey = s.keyRange(startKey, endKey)
+	return s.iterator(s.ChaincodeStubInterface.GetStateByRange(startKey, endKey))
+}
+
+// GetStateByRangeWithPagination takes and returns bookmarks, which are keys,
+// without the namespace like every other key.
+func (s *NamespacedStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	startKey, endKey = s.keyRange(startKey, endKey)
+	iterator, metadata, err := s.ChaincodeStubInterface.GetStateByRangeWithPagination(startKey, endKey, pageSize, s.stateKey(bookmark))
+	if err != nil {
+		return nil, nil, err
+	}
+	if metadata != nil {
+		metadata = &peer.QueryResponseMetadata{FetchedRecordsCount: metadata.FetchedRecordsCount, Bookmark: s.localKey(metadata.Bookmark)}
+	}
+	wrapped, err := s.iterator(iterator, nil)
+	return wrapped, metadata, err
+}
+
+func (s *NamespacedStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return s.ChaincodeStubInterface.CreateCompositeKey(s.prefix()+objectType, attributes)
+}
+
+func (s *NamespacedStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	objectType, attributes, err := s.ChaincodeStubInterface.SplitCompositeKey(compositeKey)
+	return s.localKey(objectType), attributes, err
+}
+
+func (s *NamespacedStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return s.iterator(s.ChaincodeStubInterface.GetStateByPartialCompositeKey(s.prefix()+objectType, keys))
+}
+
+func (s *NamespacedStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	iterator, metadata, err := s.ChaincodeStubInterface.GetStateByPartialCompositeKeyWithPagination(s.prefix()+objectType, keys, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := s.iterator(iterator, nil)
+	return wrapped, metadata, err
+}
+
+func (s *NamespacedStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	namespaced, err := s.query(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.iterator(s.ChaincodeStubInterface.GetQueryResult(namespaced))
+}
+
+func (s *NamespacedStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	namespaced, err := s.query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	iterator, metadata, err := s.ChaincodeStubInterface.GetQueryResultWithPagination(namespaced, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := s.iterator(iterator, nil)
+	return wrapped, metadata, err
+}
+
+func (s *NamespacedStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	iterator, err := s.ChaincodeStubInterface.GetHistoryForKey(s.stateKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedHistoryIterator{HistoryQueryIteratorInterface: iterator, stub: s}, nil
+}
+
+func (s *NamespacedStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.ChaincodeStubInterface.GetPrivateData(collection, s.stateKey(key))
+}
+
+func (s *NamespacedStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	return s.ChaincodeStubInterface.GetPrivateDataHash(collection, s.stateKey(key))
+}
+
+func (s *NamespacedStub) PutPrivateData(collection string, key string, value []byte) error {
+	return s.ChaincodeStubInterface.PutPrivateData(collection, s.stateKey(key), value)
+}
+
+func (s *NamespacedStub) DelPrivateData(collection, key string) error {
+	return s.ChaincodeStubInterface.DelPrivateData(collection, s.stateKey(key))
+}
+
+func (s *NamespacedStub) PurgePrivateData(collection, key string) error {
+	return s.ChaincodeStubInterface.PurgePrivateData(collection, s.stateKey(key))
+}
+
+func (s *NamespacedStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return s.ChaincodeStubInterface.SetPrivateDataValidationParameter(collection, s.stateKey(key), ep)
+}
+
+func (s *NamespacedStub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return s.ChaincodeStubInterface.GetPrivateDataValidationParameter(collection, s.stateKey(key))
+}
+
+func (s *NamespacedStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey = s.keyRange(startKey, endKey)
+	return s.iterator(s.ChaincodeStubInterface.GetPrivateDataByRange(collection, startKey, endKey))
+}
+
+func (s *NamespacedStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return s.iterator(s.ChaincodeStubInterface.GetPrivateDataByPartialCompositeKey(collection, s.prefix()+objectType, keys))
+}
+
+func (s *NamespacedStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	namespaced, err := s.privateQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.iterator(s.ChaincodeStubInterface.GetPrivateDataQueryResult(collection, namespaced))
+}
+
+// namespacedIterator removes the namespace from the keys and values of query
+// results.
+type namespacedIterator struct {
+	shim.StateQueryIteratorInterface
+	stub *NamespacedStub
+}
+
+func (it *namespacedIterator) Next() (*queryresult.KV, error) {
+	kv, err := it.StateQueryIteratorInterface.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &queryresult.KV{Namespace: kv.Namespace, Key: it.stub.localKey(kv.Key), Value: it.stub.localValue(kv.Value)}, nil
+}
+
+// namespacedHistoryIterator removes the namespace from the values of a key's
+// history.
+type namespacedHistoryIterator struct {
+	shim.HistoryQueryIteratorInterface
+	stub *NamespacedStub
+}
+
+func (it *namespacedHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	modification, err := it.HistoryQueryIteratorInterface.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &queryresult.KeyModification{
+		TxId:      modification.TxId,
+		Value:     it.stub.localValue(modification.Value),
+		Timestamp: modification.Timestamp,
+		IsDelete:  modification.IsDelete,
+	}, nil
+}
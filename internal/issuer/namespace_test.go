@@ -0,0 +1,231 @@
+package issuer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+// store is a contract keeping values under simple keys, indexed by value
+// under composite keys.
+type store struct {
+	contractapi.Contract
+}
+
+type storeValue struct {
+	Value string `json:"value"`
+}
+
+func (s *store) Put(ctx contractapi.TransactionContextInterface, key, value string) error {
+	data, _ := json.Marshal(storeValue{Value: value})
+	if err := ctx.GetStub().PutState(key, data); err != nil {
+		return err
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey("value~key", []string{value, key})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(indexKey, []byte{0}); err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent("store.put", []byte(key))
+}
+
+func (s *store) Get(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil || data == nil {
+		return "", fmt.Errorf("%s not found", key)
+	}
+	var value storeValue
+	err = json.Unmarshal(data, &value)
+	return value.Value, err
+}
+
+// Keys lists the simple keys by range.
+func (s *store) Keys(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	iterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys, nil
+}
+
+// Raw returns the value of key as the contract reads it.
+func (s *store) Raw(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	data, err := ctx.GetStub().GetState(key)
+	return string(data), err
+}
+
+// Find lists the keys holding value by rich query.
+func (s *store) Find(ctx contractapi.TransactionContextInterface, value string) ([]string, error) {
+	return s.FindAfter(ctx, value, "")
+}
+
+// FindAfter lists, in order, the keys after the key after holding value by
+// rich query.
+func (s *store) FindAfter(ctx contractapi.TransactionContextInterface, value, after string) ([]string, error) {
+	iterator, err := ctx.GetStub().GetQueryResult(fmt.Sprintf(`{"selector":{"value":%q,"_id":{"$gt":%q}},"sort":[{"_id":"asc"}]}`, value, after))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys, nil
+}
+
+// ByValue lists the keys holding value through the composite key index.
+func (s *store) ByValue(ctx contractapi.TransactionContextInterface, value string) ([]string, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey("value~key", []string{value})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		objectType, attributes, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		if objectType != "value~key" {
+			return nil, fmt.Errorf("object type %s", objectType)
+		}
+		keys = append(keys, attributes[1])
+	}
+	return keys, nil
+}
+
+// PutVia stores the value in another contract.
+func (s *store) PutVia(ctx contractapi.TransactionContextInterface, contract, key, value string) error {
+	_, err := issuer.Invoke(ctx, contract, "Put", key, value)
+	return err
+}
+
+func (s *store) GetVia(ctx contractapi.TransactionContextInterface, contract, key string) (string, error) {
+	payload, err := issuer.InvokeQuery(ctx, contract, "Get", key)
+	return string(payload), err
+}
+
+func (s *store) Invoked(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.GetInvokedChaincode(ctx)
+}
+
+func (s *store) InvokedVia(ctx contractapi.TransactionContextInterface, contract string) (string, error) {
+	payload, err := issuer.InvokeQuery(ctx, contract, "Invoked")
+	return string(payload), err
+}
+
+func newStores(t *testing.T) *issuertest.Network {
+	t.Helper()
+	chaincode, err := issuer.NewNamespacedChaincode(
+		&store{Contract: contractapi.Contract{Name: "a"}},
+		&store{Contract: contractapi.Contract{Name: "b"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy("stores", chaincode)
+	return network
+}
+
+func TestNewNamespacedChaincode(t *testing.T) {
+	for _, name := range []string{"", "a:b"} {
+		if _, err := issuer.NewNamespacedChaincode(&store{Contract: contractapi.Contract{Name: name}}); err == nil {
+			t.Errorf("contract %q accepted", name)
+		}
+	}
+}
+
+func TestNamespacedStub(t *testing.T) {
+	network := newStores(t)
+	for _, put := range [][]string{
+		{"a:Put", "k1", "red"},
+		{"a:Put", "k2", "blue"},
+		{"b:Put", "k1", "green"},
+		{"b:Put", "k3", "red"},
+	} {
+		if _, err := network.Submit(member, "stores", put[0], put[1:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if network.Stub("stores").State("a:k1") == nil || network.Stub("stores").State("k1") != nil {
+		t.Errorf("state keys = %q, want namespaced keys", network.Stub("stores").Keys())
+	}
+	if got := string(network.Stub("stores").State("b:k3")); got != `{"contractNamespace":"b","value":"red"}` {
+		t.Errorf("stored value = %s, want the namespace field added", got)
+	}
+
+	tests := []struct {
+		function string
+		args     []string
+		want     string
+	}{
+		{function: "a:Get", args: []string{"k1"}, want: "red"},
+		{function: "b:Get", args: []string{"k1"}, want: "green"},
+		{function: "Get", args: []string{"k2"}, want: "blue"},
+		{function: "a:Keys", want: `["k1","k2"]`},
+		{function: "b:Keys", want: `["k1","k3"]`},
+		{function: "a:Find", args: []string{"red"}, want: `["k1"]`},
+		{function: "b:Find", args: []string{"red"}, want: `["k3"]`},
+		{function: "b:FindAfter", args: []string{"red", "k1"}, want: `["k3"]`},
+		{function: "b:FindAfter", args: []string{"red", "k3"}, want: `[]`},
+		{function: "b:Raw", args: []string{"k3"}, want: `{"value":"red"}`},
+		{function: "a:ByValue", args: []string{"red"}, want: `["k1"]`},
+		{function: "b:ByValue", args: []string{"blue"}, want: `[]`},
+		{function: "a:GetVia", args: []string{"b", "k3"}, want: "red"},
+		{function: "b:Invoked", want: "b"},
+		{function: "a:InvokedVia", args: []string{"b"}, want: "a"},
+	}
+	for _, test := range tests {
+		t.Run(test.function, func(t *testing.T) {
+			payload, err := network.Evaluate(member, "stores", test.function, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != test.want {
+				t.Errorf("%s%v = %s, want %s", test.function, test.args, payload, test.want)
+			}
+		})
+	}
+}
+
+func TestNamespacedStubInProcessCall(t *testing.T) {
+	network := newStores(t)
+	if _, err := network.Submit(member, "stores", "a:PutVia", "b", "k1", "red"); err != nil {
+		t.Fatal(err)
+	}
+	if network.Stub("stores").State("b:k1") == nil || network.Stub("stores").State("a:k1") != nil {
+		t.Errorf("state keys = %q, want the write in b", network.Stub("stores").Keys())
+	}
+	if event := network.LastEvent(); event != nil {
+		t.Errorf("event %s of the in-process call kept", event.EventName)
+	}
+	if _, err := network.Evaluate(member, "stores", "a:GetVia", "b", "k2"); err == nil {
+		t.Error("want the error of the in-process call")
+	}
+}
@@ -0,0 +1,128 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Registration lifecycle of farmers, packers and exporters: a participant is
+// PENDING when created, an administrator APPROVES or REJECTS it, and may later
+// SUSPEND an approved participant and approve it again.
+const (
+	REGPENDING   string = "PENDING"
+	REGAPPROVED  string = "APPROVED"
+	REGREJECTED  string = "REJECTED"
+	REGSUSPENDED string = "SUSPENDED"
+)
+
+var registrationTransitions = map[string][]string{
+	REGPENDING:   {REGAPPROVED, REGREJECTED},
+	REGAPPROVED:  {REGSUSPENDED},
+	REGSUSPENDED: {REGAPPROVED},
+}
+
+// Registration is embedded in participant records. DecidedBy and DecidedAt
+// name the last administrator to change the status; ApprovedBy and ApprovedAt
+// the last to approve it. Records created before the lifecycle existed have
+// an empty Status and count as approved.
+type Registration struct {
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason"`
+	DecidedBy  string    `json:"decidedBy"`
+	DecidedAt  time.Time `json:"decidedAt"`
+	ApprovedBy string    `json:"approvedBy"`
+	ApprovedAt time.Time `json:"approvedAt"`
+}
+
+func NewRegistration() Registration {
+	return Registration{Status: REGPENDING}
+}
+
+func (registration *Registration) CurrentStatus() string {
+	if registration.Status == "" {
+		return REGAPPROVED
+	}
+	return registration.Status
+}
+
+// SetRegistrationStatus moves registration to status on behalf of the
+// submitting client, who must be an administrator.
+func SetRegistrationStatus(ctx contractapi.TransactionContextInterface, registration *Registration, status, reason string) error {
+	if err := AssertAdmin(ctx); err != nil {
+		return err
+	}
+
+	current := registration.CurrentStatus()
+	if !containsString(registrationTransitions[current], status) {
+		return fmt.Errorf("registration cannot go from %s to %s", current, status)
+	}
+
+	actor, err := GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	registration.Status = status
+	registration.Reason = reason
+	registration.DecidedBy = actor
+	registration.DecidedAt = now
+	if status == REGAPPROVED {
+		registration.ApprovedBy = actor
+		registration.ApprovedAt = now
+	}
+	return nil
+}
+
+// participant is the part of a farmer, packer or exporter record the
+// registration checks read.
+type participant struct {
+	Owner        string       `json:"owner"`
+	Registration Registration `json:"registration"`
+}
+
+// getApproved reads the participant id held by chaincodeName and fails
+// unless it is approved.
+func getApproved(ctx contractapi.TransactionContextInterface, chaincodeName, id string) (*participant, error) {
+	payload, err := InvokeQuery(ctx, chaincodeName, "ReadAsset", id)
+	if err != nil {
+		return nil, err
+	}
+	var record participant
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	if status := record.Registration.CurrentStatus(); status != REGAPPROVED {
+		return nil, fmt.Errorf("%s %s is not approved (%s)", chaincodeName, id, status)
+	}
+	return &record, nil
+}
+
+// AssertApproved fails unless the participant id held by chaincodeName is
+// approved. An empty id is not checked.
+func AssertApproved(ctx contractapi.TransactionContextInterface, chaincodeName, id string) error {
+	if id == "" {
+		return nil
+	}
+	_, err := getApproved(ctx, chaincodeName, id)
+	return err
+}
+
+// AssertApprovedOwner fails unless the participant id held by chaincodeName
+// is approved and the submitting client owns it, e.g. the packer a packing
+// order is filed under. The id is required.
+func AssertApprovedOwner(ctx contractapi.TransactionContextInterface, chaincodeName, id string) error {
+	if id == "" {
+		return fmt.Errorf("the %s id is required", chaincodeName)
+	}
+	record, err := getApproved(ctx, chaincodeName, id)
+	if err != nil {
+		return err
+	}
+	return AssertIdentity(ctx, record.Owner)
+}
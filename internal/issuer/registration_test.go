@@ -0,0 +1,125 @@
+package issuer_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+var (
+	admin  = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	member = issuertest.NewIdentity("Org1MSP", "member", nil)
+)
+
+func TestSetRegistrationStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		from     string
+		to       string
+		wantErr  bool
+	}{
+		{name: "approve pending", identity: admin, from: issuer.REGPENDING, to: issuer.REGAPPROVED},
+		{name: "reject pending", identity: admin, from: issuer.REGPENDING, to: issuer.REGREJECTED},
+		{name: "suspend approved", identity: admin, from: issuer.REGAPPROVED, to: issuer.REGSUSPENDED},
+		{name: "reinstate suspended", identity: admin, from: issuer.REGSUSPENDED, to: issuer.REGAPPROVED},
+		{name: "suspend legacy record", identity: admin, from: "", to: issuer.REGSUSPENDED},
+		{name: "approve rejected", identity: admin, from: issuer.REGREJECTED, to: issuer.REGAPPROVED, wantErr: true},
+		{name: "suspend pending", identity: admin, from: issuer.REGPENDING, to: issuer.REGSUSPENDED, wantErr: true},
+		{name: "not an administrator", identity: member, from: issuer.REGPENDING, to: issuer.REGAPPROVED, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			network := issuertest.NewNetwork()
+			registration := issuer.Registration{Status: test.from}
+			err := network.Run(test.identity, "farmer", false, func(ctx contractapi.TransactionContextInterface) error {
+				return issuer.SetRegistrationStatus(ctx, &registration, test.to, "checked")
+			})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("moved from %q to %s", test.from, test.to)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registration.Status != test.to || registration.DecidedAt.IsZero() || registration.Reason != "checked" {
+				t.Fatalf("registration = %+v", registration)
+			}
+			if approved := !registration.ApprovedAt.IsZero(); approved != (test.to == issuer.REGAPPROVED) {
+				t.Fatalf("approvedAt = %v after moving to %s", registration.ApprovedAt, test.to)
+			}
+		})
+	}
+}
+
+func TestAssertApproved(t *testing.T) {
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCPACKER, issuertest.NewFake().On("ReadAsset", func(args []string) ([]byte, error) {
+		switch args[0] {
+		case "approved":
+			return []byte(`{"registration":{"status":"APPROVED"}}`), nil
+		case "legacy":
+			return []byte(`{}`), nil
+		case "pending":
+			return []byte(`{"registration":{"status":"PENDING"}}`), nil
+		}
+		return nil, issuer.ReturnError("the asset does not exist")
+	}))
+
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{id: ""},
+		{id: "approved"},
+		{id: "legacy"},
+		{id: "pending", wantErr: true},
+		{id: "missing", wantErr: true},
+	}
+	for _, test := range tests {
+		err := network.Run(member, issuer.CCPACKING, false, func(ctx contractapi.TransactionContextInterface) error {
+			return issuer.AssertApproved(ctx, issuer.CCPACKER, test.id)
+		})
+		if (err != nil) != test.wantErr {
+			t.Errorf("AssertApproved(%q) = %v, want error %v", test.id, err, test.wantErr)
+		}
+	}
+}
+
+func TestAssertApprovedOwner(t *testing.T) {
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCPACKER, issuertest.NewFake().On("ReadAsset", func(args []string) ([]byte, error) {
+		switch args[0] {
+		case "own":
+			return []byte(`{"owner":"` + member.ClientID() + `","registration":{"status":"APPROVED"}}`), nil
+		case "other":
+			return []byte(`{"owner":"` + admin.ClientID() + `","registration":{"status":"APPROVED"}}`), nil
+		case "own-pending":
+			return []byte(`{"owner":"` + member.ClientID() + `","registration":{"status":"PENDING"}}`), nil
+		}
+		return nil, issuer.ReturnError("the asset does not exist")
+	}))
+
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{id: "own"},
+		{id: "", wantErr: true},
+		{id: "other", wantErr: true},
+		{id: "own-pending", wantErr: true},
+		{id: "missing", wantErr: true},
+	}
+	for _, test := range tests {
+		err := network.Run(member, issuer.CCPACKING, false, func(ctx contractapi.TransactionContextInterface) error {
+			return issuer.AssertApprovedOwner(ctx, issuer.CCPACKER, test.id)
+		})
+		if (err != nil) != test.wantErr {
+			t.Errorf("AssertApprovedOwner(%q) = %v, want error %v", test.id, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Chaincode names as deployed on the channel, for InvokeChaincode.
+const (
+	CCGAP        string = "gap"
+	CCGMP        string = "gmp"
+	CCFARMER     string = "farmer"
+	CCPACKER     string = "packer"
+	CCPACKING    string = "packing"
+	CCEXPORTER   string = "exporter"
+	CCREGULATOR  string = "regulator"
+	CCNSTDASTAFF string = "nstda-staff"
+)
+
+const (
+	TARGETGAP     string = "gap"
+	TARGETGMP     string = "gmp"
+	TARGETPACKING string = "packing"
+
+	CERTACTIVE    string = "ACTIVE"
+	CERTSUSPENDED string = "SUSPENDED"
+	CERTREVOKED   string = "REVOKED"
+
+	INSPECTIONPASS        string = "PASS"
+	INSPECTIONCONDITIONAL string = "CONDITIONAL"
+	INSPECTIONFAIL        string = "FAIL"
+
+	REGULATORYSTATUSDOCTYPE string = "regulatoryStatus"
+)
+
+// RegulatoryStatus is the regulator's current view of a GAP certificate
+// (by CertID), a GMP certificate (by PackingHouseRegisterNumber) or a packing
+// order (by Id). Targets the regulator never acted on are ACTIVE.
+type RegulatoryStatus struct {
+	DocType              string    `json:"docType"`
+	TargetType           string    `json:"targetType"`
+	TargetID             string    `json:"targetId"`
+	Status               string    `json:"status"`
+	PreviousStatus       string    `json:"previousStatus"`
+	EffectiveAt          time.Time `json:"effectiveAt"`
+	ActionID             string    `json:"actionId"`
+	ReasonCode           string    `json:"reasonCode"`
+	Reason               string    `json:"reason"`
+	RecordedBy           string    `json:"recordedBy"`
+	ReinstateAt          time.Time `json:"reinstateAt"`
+	LastInspectionID     string    `json:"lastInspectionId"`
+	LastInspectionResult string    `json:"lastInspectionResult"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
+
+// StatusAt is the status in force at now: an action dated in the future does
+// not apply yet, and a suspension ends at its reinstatement date.
+func (status *RegulatoryStatus) StatusAt(now time.Time) string {
+	if now.Before(status.EffectiveAt) {
+		return status.PreviousStatus
+	}
+	if status.Status == CERTSUSPENDED && !status.ReinstateAt.IsZero() && !now.Before(status.ReinstateAt) {
+		return CERTACTIVE
+	}
+	return status.Status
+}
+
+// Invoke calls a function of another chaincode on the same channel and
+// returns its payload. The callee runs as the same client, and its reads and
+// writes become part of this transaction, checked against the callee's own
+// endorsement policy.
+func Invoke(ctx contractapi.TransactionContextInterface, chaincodeName, function string, args ...string) ([]byte, error) {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	response := ctx.GetStub().InvokeChaincode(chaincodeName, invokeArgs, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("%s.%s failed: %s", chaincodeName, function, response.Message)
+	}
+	return response.Payload, nil
+}
+
+// InvokeQuery is Invoke for functions that only read.
+func InvokeQuery(ctx contractapi.TransactionContextInterface, chaincodeName, function string, args ...string) ([]byte, error) {
+	return Invoke(ctx, chaincodeName, function, args...)
+}
+
+func GetRegulatoryStatus(ctx contractapi.TransactionContextInterface, targetType, targetID string) (*RegulatoryStatus, error) {
+	payload, err := InvokeQuery(ctx, CCREGULATOR, "GetRegulatoryStatus", targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	var status RegulatoryStatus
+	if err := json.Unmarshal(payload, &status); err != nil {
+		return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	return &status, nil
+}
+
+// AssertNotRevoked fails if the regulator revoked the target. An empty
+// targetID is not checked.
+func AssertNotRevoked(ctx contractapi.TransactionContextInterface, targetType, targetID string) error {
+	if targetID == "" {
+		return nil
+	}
+	status, err := GetRegulatoryStatus(ctx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if status.Status == CERTREVOKED {
+		return fmt.Errorf("%s %s is revoked by the regulator", targetType, targetID)
+	}
+	return nil
+}
+
+// AssertCertificateActive fails if the regulator suspended or revoked the
+// target. An empty targetID is not checked.
+func AssertCertificateActive(ctx contractapi.TransactionContextInterface, targetType, targetID string) error {
+	if targetID == "" {
+		return nil
+	}
+	status, err := GetRegulatoryStatus(ctx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if status.Status != CERTACTIVE {
+		return fmt.Errorf("%s %s is %s by the regulator", targetType, targetID, status.Status)
+	}
+	return nil
+}
+
+// AssertInspectionPassed fails if the latest inspection of the target failed.
+func AssertInspectionPassed(ctx contractapi.TransactionContextInterface, targetType, targetID string) error {
+	status, err := GetRegulatoryStatus(ctx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if status.LastInspectionResult == INSPECTIONFAIL {
+		return fmt.Errorf("%s %s failed inspection %s", targetType, targetID, status.LastInspectionID)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package issuer
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Roles are granted as Fabric CA attributes on the enrollment certificate,
+// e.g. fabric-ca-client register --id.attrs 'nstda.admin=true:ecert'.
+const (
+	ADMINROLE     string = "nstda.admin"
+	REGULATORROLE string = "nstda.regulator"
+)
+
+func HasRole(ctx contractapi.TransactionContextInterface, role string) bool {
+	return ctx.GetClientIdentity().AssertAttributeValue(role, "true") == nil
+}
+
+func AssertRole(ctx contractapi.TransactionContextInterface, role string) error {
+	if !HasRole(ctx, role) {
+		return fmt.Errorf("submitting client does not have the %s role", role)
+	}
+	return nil
+}
+
+func AssertAdmin(ctx contractapi.TransactionContextInterface) error {
+	return AssertRole(ctx, ADMINROLE)
+}
+
+// AssertRegulator accepts members of the regulator org and clients holding
+// the regulator role.
+func AssertRegulator(ctx contractapi.TransactionContextInterface) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	if mspID == REGULATORMSP {
+		return nil
+	}
+	return AssertRole(ctx, REGULATORROLE)
+}
@@ -0,0 +1,144 @@
+package issuer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Asset records carry the version of their entity's schema in schemaVersion;
+// records written before it existed are version 0. A Schema upgrades older
+// records when they are read, so contracts only ever handle the current
+// shape and write records back current. MigrateBatch upgrades the stored
+// records in chunks, for queries that select on the fields migrations fill.
+const SCHEMAVERSION string = "schemaVersion"
+
+// Migration upgrades a decoded record by one schema version. It may read
+// the ledger, and other chaincodes, through ctx but must not write: records
+// are also upgraded on read, in queries that cannot.
+type Migration func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error
+
+type Schema struct {
+	migrations []Migration
+}
+
+func NewSchema() *Schema {
+	return &Schema{}
+}
+
+// Register adds the migration producing version from the version before.
+// Versions start at 1 and must be registered in order.
+func (schema *Schema) Register(version int, migration Migration) *Schema {
+	if version != len(schema.migrations)+1 {
+		panic(fmt.Sprintf("schema version %d registered after version %d", version, len(schema.migrations)))
+	}
+	schema.migrations = append(schema.migrations, migration)
+	return schema
+}
+
+// Version is the current schema version, that of records written now.
+func (schema *Schema) Version() int {
+	return len(schema.migrations)
+}
+
+// Upgrade returns the record stored as data at the current version, and
+// whether that differs from data.
+func (schema *Schema) Upgrade(ctx contractapi.TransactionContextInterface, data []byte) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		return nil, false, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+
+	version := 0
+	if value, ok := record[SCHEMAVERSION]; ok {
+		number, isNumber := value.(json.Number)
+		parsed, err := number.Int64()
+		if !isNumber || err != nil || parsed < 0 {
+			return nil, false, fmt.Errorf("invalid %s %v", SCHEMAVERSION, value)
+		}
+		version = int(parsed)
+	}
+	if version == schema.Version() {
+		return data, false, nil
+	}
+	if version > schema.Version() {
+		return nil, false, fmt.Errorf("record has schema version %d, newer than %d", version, schema.Version())
+	}
+
+	for ; version < schema.Version(); version++ {
+		if err := schema.migrations[version](ctx, record); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate record to schema version %d: %v", version+1, err)
+		}
+	}
+	record[SCHEMAVERSION] = version
+
+	upgraded, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
+
+// Unmarshal decodes the record stored as data into v at the current version.
+func (schema *Schema) Unmarshal(ctx contractapi.TransactionContextInterface, data []byte, v interface{}) error {
+	upgraded, _, err := schema.Upgrade(ctx, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// MigrateBatch stores up to size asset records older than the current schema
+// upgraded. Call again with the returned bookmark until done.
+func MigrateBatch(ctx contractapi.TransactionContextInterface, entityName string, schema *Schema, bookmark string, size int) (*MigrationResult, error) {
+	if err := AssertAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > AGGREGATEMAXPAGESIZE {
+		size = AGGREGATEPAGESIZE
+	}
+	// Without migrations, records lacking a version are current.
+	if schema.Version() == 0 {
+		return &MigrationResult{Done: true}, nil
+	}
+
+	records, done, err := queryAssetsAfter(ctx, map[string]interface{}{
+		"$or": []interface{}{
+			map[string]interface{}{SCHEMAVERSION: map[string]interface{}{"$exists": false}},
+			map[string]interface{}{SCHEMAVERSION: map[string]interface{}{"$lt": schema.Version()}},
+		},
+	}, bookmark, size)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{Done: done}
+	var eventItems []EventItem
+	for _, queryResponse := range records {
+		upgraded, changed, err := schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, fmt.Errorf("asset %s: %v", queryResponse.Key, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := ctx.GetStub().PutState(queryResponse.Key, upgraded); err != nil {
+			return nil, fmt.Errorf("failed to put asset %s: %v", queryResponse.Key, err)
+		}
+
+		eventItems = append(eventItems, EventItem{ID: queryResponse.Key})
+		result.Migrated++
+	}
+
+	if !result.Done {
+		result.Bookmark = records[len(records)-1].Key
+	}
+	if len(eventItems) == 0 {
+		return result, nil
+	}
+	return result, EmitEvent(ctx, "schema.migrated", entityName, eventItems...)
+}
@@ -0,0 +1,134 @@
+package issuer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+)
+
+// newSchema renames name to fullName at version 1 and adds tags at version 2.
+func newSchema() *issuer.Schema {
+	return issuer.NewSchema().
+		Register(1, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+			if name, ok := record["name"]; ok {
+				record["fullName"] = name
+				delete(record, "name")
+			}
+			return nil
+		}).
+		Register(2, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+			if _, ok := record["tags"]; !ok {
+				record["tags"] = []string{}
+			}
+			return nil
+		})
+}
+
+func TestSchemaUpgrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		record      string
+		want        string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "unversioned", record: `{"id":"1","name":"a"}`, want: `{"fullName":"a","id":"1","schemaVersion":2,"tags":[]}`, wantChanged: true},
+		{name: "version 1", record: `{"id":"1","fullName":"a","schemaVersion":1}`, want: `{"fullName":"a","id":"1","schemaVersion":2,"tags":[]}`, wantChanged: true},
+		{name: "current", record: `{"id":"1","fullName":"a","tags":["x"],"schemaVersion":2}`, want: `{"id":"1","fullName":"a","tags":["x"],"schemaVersion":2}`},
+		{name: "large numbers kept", record: `{"id":"1","weight":12345678901234567890}`, want: `{"id":"1","schemaVersion":2,"tags":[],"weight":12345678901234567890}`, wantChanged: true},
+		{name: "newer", record: `{"id":"1","schemaVersion":3}`, wantErr: true},
+		{name: "invalid version", record: `{"id":"1","schemaVersion":"2"}`, wantErr: true},
+		{name: "not a record", record: `[]`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upgraded, changed, err := newSchema().Upgrade(nil, []byte(test.record))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if string(upgraded) != test.want || changed != test.wantChanged {
+				t.Errorf("Upgrade = %s, %v, want %s, %v", upgraded, changed, test.want, test.wantChanged)
+			}
+		})
+	}
+}
+
+func TestSchemaUpgradeWithoutMigrations(t *testing.T) {
+	record := `{"id":"1"}`
+	upgraded, changed, err := issuer.NewSchema().Upgrade(nil, []byte(record))
+	if err != nil || changed || string(upgraded) != record {
+		t.Errorf("Upgrade = %s, %v, %v, want the record unchanged", upgraded, changed, err)
+	}
+}
+
+func TestSchemaRegisterOutOfOrder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("version 2 registered without version 1")
+		}
+	}()
+	issuer.NewSchema().Register(2, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error { return nil })
+}
+
+func TestMigrateBatch(t *testing.T) {
+	network := issuertest.NewNetwork()
+	stub := network.Stub("records")
+	stub.Seed("1", []byte(`{"id":"1","name":"a"}`))
+	stub.Seed("2", []byte(`{"id":"2","fullName":"b","schemaVersion":1}`))
+	stub.Seed("3", []byte(`{"id":"3","fullName":"c","tags":[],"schemaVersion":2}`))
+	stub.Seed("4", []byte(`{"id":"4","name":"d"}`))
+	stub.Seed("5", []byte(`{"id":"5","docType":"transfer"}`))
+
+	migrate := func(identity *issuertest.Identity, bookmark string) (*issuer.MigrationResult, error) {
+		var result *issuer.MigrationResult
+		err := network.Run(identity, "records", true, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = issuer.MigrateBatch(ctx, "record", newSchema(), bookmark, 2)
+			return err
+		})
+		return result, err
+	}
+
+	if _, err := migrate(member, ""); err == nil {
+		t.Fatal("a member migrated records")
+	}
+
+	bookmark := ""
+	migrated := 0
+	for batches := 1; ; batches++ {
+		result, err := migrate(admin, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrated += result.Migrated
+		if result.Done {
+			break
+		}
+		if batches == 3 {
+			t.Fatalf("not done after %d batches", batches)
+		}
+		bookmark = result.Bookmark
+	}
+	if migrated != 3 {
+		t.Errorf("migrated %d records, want 3", migrated)
+	}
+
+	for _, key := range []string{"1", "2", "3", "4"} {
+		var record struct {
+			SchemaVersion int `json:"schemaVersion"`
+		}
+		if err := json.Unmarshal(stub.State(key), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.SchemaVersion != 2 {
+			t.Errorf("record %s = %s, want schema version 2", key, stub.State(key))
+		}
+	}
+	if got := fmt.Sprintf("%s", stub.State("5")); got != `{"id":"5","docType":"transfer"}` {
+		t.Errorf("supporting record rewritten: %s", got)
+	}
+}
@@ -0,0 +1,104 @@
+package issuer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Environment of a chaincode run as an external service. The peer connects
+// to CHAINCODE_SERVER_ADDRESS through the connection.json installed as the
+// chaincode package, built from each chaincode's ccaas directory:
+//
+//	cd ccaas && tar -czf code.tar.gz connection.json
+//	tar -czf ../gap.tgz metadata.json code.tar.gz && rm code.tar.gz
+//
+// CHAINCODE_ID is the package ID the peer reports on install. TLS is off
+// unless CHAINCODE_TLS_DISABLED is false; the server then presents the
+// CHAINCODE_TLS_CERT and CHAINCODE_TLS_KEY files and, when
+// CHAINCODE_CLIENT_CA_CERT names one, only accepts peers signed by that CA.
+const (
+	ENVCHAINCODEID     string = "CHAINCODE_ID"
+	ENVSERVERADDRESS   string = "CHAINCODE_SERVER_ADDRESS"
+	ENVTLSDISABLED     string = "CHAINCODE_TLS_DISABLED"
+	ENVTLSKEY          string = "CHAINCODE_TLS_KEY"
+	ENVTLSCERT         string = "CHAINCODE_TLS_CERT"
+	ENVTLSCLIENTCACERT string = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// NewChaincodeServer configures chaincode as an external chaincode server
+// from the environment. It returns nil when CHAINCODE_SERVER_ADDRESS is not
+// set, in which case the peer launches the chaincode.
+func NewChaincodeServer(chaincode shim.Chaincode) (*shim.ChaincodeServer, error) {
+	address := os.Getenv(ENVSERVERADDRESS)
+	if address == "" {
+		return nil, nil
+	}
+	ccid := os.Getenv(ENVCHAINCODEID)
+	if ccid == "" {
+		return nil, fmt.Errorf("%s must be set to run as a chaincode server", ENVCHAINCODEID)
+	}
+
+	tlsDisabled := true
+	if value := os.Getenv(ENVTLSDISABLED); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", ENVTLSDISABLED, value)
+		}
+		tlsDisabled = disabled
+	}
+
+	server := &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       chaincode,
+		TLSProps: shim.TLSProperties{Disabled: tlsDisabled},
+	}
+	if tlsDisabled {
+		return server, nil
+	}
+
+	var err error
+	if server.TLSProps.Key, err = readEnvFile(ENVTLSKEY, true); err != nil {
+		return nil, err
+	}
+	if server.TLSProps.Cert, err = readEnvFile(ENVTLSCERT, true); err != nil {
+		return nil, err
+	}
+	if server.TLSProps.ClientCACerts, err = readEnvFile(ENVTLSCLIENTCACERT, false); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// StartChaincode runs chaincode as an external chaincode server when the
+// environment configures one, and otherwise as a chaincode the peer
+// launched.
+func StartChaincode(chaincode shim.Chaincode) error {
+	server, err := NewChaincodeServer(chaincode)
+	if err != nil {
+		return err
+	}
+	if server == nil {
+		return shim.Start(chaincode)
+	}
+	return server.Start()
+}
+
+func readEnvFile(name string, required bool) ([]byte, error) {
+	path := os.Getenv(name)
+	if path == "" {
+		if required {
+			return nil, fmt.Errorf("%s must name a file when TLS is enabled", name)
+		}
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package issuer_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+func TestNewChaincodeServer(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"key.pem", "cert.pem", "ca.pem"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantServer bool
+		wantTLS    bool
+		wantErr    bool
+	}{
+		{name: "launched by the peer", env: map[string]string{issuer.ENVCHAINCODEID: "gap:1"}},
+		{
+			name:       "server without TLS",
+			env:        map[string]string{issuer.ENVSERVERADDRESS: "0.0.0.0:9999", issuer.ENVCHAINCODEID: "gap:1"},
+			wantServer: true,
+		},
+		{
+			name:    "server without an ID",
+			env:     map[string]string{issuer.ENVSERVERADDRESS: "0.0.0.0:9999"},
+			wantErr: true,
+		},
+		{
+			name: "server with TLS",
+			env: map[string]string{
+				issuer.ENVSERVERADDRESS:   "0.0.0.0:9999",
+				issuer.ENVCHAINCODEID:     "gap:1",
+				issuer.ENVTLSDISABLED:     "false",
+				issuer.ENVTLSKEY:          filepath.Join(dir, "key.pem"),
+				issuer.ENVTLSCERT:         filepath.Join(dir, "cert.pem"),
+				issuer.ENVTLSCLIENTCACERT: filepath.Join(dir, "ca.pem"),
+			},
+			wantServer: true,
+			wantTLS:    true,
+		},
+		{
+			name: "TLS without a key",
+			env: map[string]string{
+				issuer.ENVSERVERADDRESS: "0.0.0.0:9999",
+				issuer.ENVCHAINCODEID:   "gap:1",
+				issuer.ENVTLSDISABLED:   "false",
+				issuer.ENVTLSCERT:       filepath.Join(dir, "cert.pem"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing TLS file",
+			env: map[string]string{
+				issuer.ENVSERVERADDRESS: "0.0.0.0:9999",
+				issuer.ENVCHAINCODEID:   "gap:1",
+				issuer.ENVTLSDISABLED:   "false",
+				issuer.ENVTLSKEY:        filepath.Join(dir, "missing.pem"),
+				issuer.ENVTLSCERT:       filepath.Join(dir, "cert.pem"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid TLS switch",
+			env:     map[string]string{issuer.ENVSERVERADDRESS: "0.0.0.0:9999", issuer.ENVCHAINCODEID: "gap:1", issuer.ENVTLSDISABLED: "no"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{issuer.ENVCHAINCODEID, issuer.ENVSERVERADDRESS, issuer.ENVTLSDISABLED, issuer.ENVTLSKEY, issuer.ENVTLSCERT, issuer.ENVTLSCLIENTCACERT} {
+				t.Setenv(name, test.env[name])
+			}
+			server, err := issuer.NewChaincodeServer(calledVia{})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if (server != nil) != test.wantServer {
+				t.Fatalf("server = %+v, want a server %v", server, test.wantServer)
+			}
+			if server == nil {
+				return
+			}
+			if server.CCID != "gap:1" || server.Address != "0.0.0.0:9999" || server.TLSProps.Disabled == test.wantTLS {
+				t.Errorf("server = %+v", server)
+			}
+			if test.wantTLS && (string(server.TLSProps.Key) != "key.pem" || string(server.TLSProps.ClientCACerts) != "ca.pem") {
+				t.Errorf("TLS = %+v", server.TLSProps)
+			}
+		})
+	}
+}
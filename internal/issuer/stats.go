@@ -0,0 +1,168 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const STATDOCTYPE string = "stat"
+
+// Statistics are kept as delta rows under stat~<name>~<group...>~<txId>.
+// Writers only ever add rows with their own transaction ID and never read
+// them, so concurrent transactions cannot conflict on a shared counter;
+// GetStatistics sums the rows at query time and CompactStatistics folds them.
+type StatDelta struct {
+	DocType string             `json:"docType"`
+	Count   int                `json:"count"`
+	Sum     map[string]float64 `json:"sum"`
+}
+
+type Statistic struct {
+	Name  string             `json:"name"`
+	Group []string           `json:"group"`
+	Count int                `json:"count"`
+	Sum   map[string]float64 `json:"sum"`
+}
+
+// Stats collects the deltas of one transaction so that each group gets a
+// single row however many records the transaction touches.
+type Stats struct {
+	deltas map[string]*Statistic
+}
+
+func NewStats() *Stats {
+	return &Stats{deltas: map[string]*Statistic{}}
+}
+
+// Add counts count records (negative to remove them) with the given field
+// values into the group.
+func (s *Stats) Add(name string, group []string, count int, sum map[string]float64) {
+	key := name + "\x00" + strings.Join(group, "\x00")
+	delta, ok := s.deltas[key]
+	if !ok {
+		delta = &Statistic{Name: name, Group: group, Sum: map[string]float64{}}
+		s.deltas[key] = delta
+	}
+	delta.Count += count
+	for field, value := range sum {
+		delta.Sum[field] += value
+	}
+}
+
+func (statistic *Statistic) isZero() bool {
+	if statistic.Count != 0 {
+		return false
+	}
+	for _, value := range statistic.Sum {
+		if value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func putStatRow(ctx contractapi.TransactionContextInterface, statistic *Statistic) error {
+	attributes := append(append([]string{statistic.Name}, statistic.Group...), ctx.GetStub().GetTxID())
+	key, err := ctx.GetStub().CreateCompositeKey(STATDOCTYPE, attributes)
+	if err != nil {
+		return err
+	}
+	rowJSON, err := json.Marshal(StatDelta{DocType: STATDOCTYPE, Count: statistic.Count, Sum: statistic.Sum})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, rowJSON)
+}
+
+// Flush writes the non-zero deltas collected so far.
+func (s *Stats) Flush(ctx contractapi.TransactionContextInterface) error {
+	keys := make([]string, 0, len(s.deltas))
+	for key := range s.deltas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		delta := s.deltas[key]
+		if delta.isZero() {
+			continue
+		}
+		if err := putStatRow(ctx, delta); err != nil {
+			return fmt.Errorf("failed to put statistics %s: %v", delta.Name, err)
+		}
+	}
+	s.deltas = map[string]*Statistic{}
+	return nil
+}
+
+// rollUp sums the delta rows of a statistic by group and returns the keys of
+// the rows it read.
+func rollUp(ctx contractapi.TransactionContextInterface, name string) ([]*Statistic, []string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(STATDOCTYPE, []string{name})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resultsIterator.Close()
+
+	totals := NewStats()
+	var keys []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		var row StatDelta
+		if err := json.Unmarshal(queryResponse.Value, &row); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+		}
+		// attributes are name, group..., txId
+		totals.Add(name, attributes[1:len(attributes)-1], row.Count, row.Sum)
+		keys = append(keys, queryResponse.Key)
+	}
+
+	statistics := []*Statistic{}
+	for _, statistic := range totals.deltas {
+		if !statistic.isZero() {
+			statistics = append(statistics, statistic)
+		}
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		return strings.Join(statistics[i].Group, "\x00") < strings.Join(statistics[j].Group, "\x00")
+	})
+	return statistics, keys, nil
+}
+
+func GetStatistics(ctx contractapi.TransactionContextInterface, name string) ([]*Statistic, error) {
+	statistics, _, err := rollUp(ctx, name)
+	return statistics, err
+}
+
+// CompactStatistics replaces the delta rows of a statistic with one row per
+// group and returns the compacted statistics. It conflicts with writers that
+// commit while it runs, so it is meant to be run off-peak and retried on MVCC
+// failure.
+func CompactStatistics(ctx contractapi.TransactionContextInterface, name string) ([]*Statistic, error) {
+	statistics, keys, err := rollUp(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return nil, fmt.Errorf("failed to delete statistics row: %v", err)
+		}
+	}
+	for _, statistic := range statistics {
+		if err := putStatRow(ctx, statistic); err != nil {
+			return nil, fmt.Errorf("failed to put statistics %s: %v", name, err)
+		}
+	}
+	return statistics, nil
+}
@@ -0,0 +1,261 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	TRANSFERPENDING  string = "PENDING"
+	TRANSFERACCEPTED string = "ACCEPTED"
+	TRANSFERREJECTED string = "REJECTED"
+	TRANSFERCANCELED string = "CANCELED"
+
+	TRANSFERDOCTYPE string        = "transfer"
+	TRANSFEREXPIRE  time.Duration = 72 * time.Hour
+)
+
+var (
+	mspIDPattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+	ownerHashPattern = regexp.MustCompile(`^sha256:[0-9a-f]+:[0-9a-f]{64}$`)
+)
+
+// TransferRequest is the pending hand-over of an asset, kept under the
+// composite key transfer~<assetId> until it is accepted, rejected or canceled.
+type TransferRequest struct {
+	DocType    string    `json:"docType"`
+	AssetID    string    `json:"assetId"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Status     string    `json:"status"`
+	AcceptedBy string    `json:"acceptedBy"`
+	TxID       string    `json:"txId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ValidateOwner accepts a decoded X.509 client identity
+// (x509::<subject>::<issuer>), a hashed owner ID or an MSP ID.
+func ValidateOwner(owner string) error {
+	if strings.HasPrefix(owner, OWNERHASHPREFIX) {
+		if !ownerHashPattern.MatchString(owner) {
+			return fmt.Errorf("invalid owner ID %q", owner)
+		}
+		return nil
+	}
+	if strings.HasPrefix(owner, "x509::") {
+		parts := strings.Split(owner, "::")
+		if len(parts) != 3 || !strings.Contains(parts[1], "CN=") || !strings.Contains(parts[2], "CN=") {
+			return fmt.Errorf("invalid x509 identity %q", owner)
+		}
+		return nil
+	}
+	if !mspIDPattern.MatchString(owner) {
+		return fmt.Errorf("new owner %q is neither an x509 identity nor an MSP ID", owner)
+	}
+	return nil
+}
+
+func transferKey(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(TRANSFERDOCTYPE, []string{assetID})
+}
+
+func GetTransfer(ctx contractapi.TransactionContextInterface, assetID string) (*TransferRequest, error) {
+	key, err := transferKey(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	transferJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if transferJSON == nil {
+		return nil, fmt.Errorf("no transfer found for asset %s", assetID)
+	}
+
+	var transfer TransferRequest
+	if err := json.Unmarshal(transferJSON, &transfer); err != nil {
+		return nil, fmt.Errorf("%s: %v", DATAUNMARSHAL, err)
+	}
+	return &transfer, nil
+}
+
+func putTransfer(ctx contractapi.TransactionContextInterface, entityName string, transfer *TransferRequest, eventType string) error {
+	key, err := transferKey(ctx, transfer.AssetID)
+	if err != nil {
+		return err
+	}
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, transferJSON); err != nil {
+		return fmt.Errorf("failed to put transfer for asset %s: %v", transfer.AssetID, err)
+	}
+	return EmitEvent(ctx, eventType, entityName, EventItem{ID: transfer.AssetID, Payload: transferJSON})
+}
+
+// ProposeTransfer records a pending transfer of assetID from owner to
+// newOwner. Only the current owner may propose, and only one transfer can be
+// pending per asset at a time.
+func ProposeTransfer(ctx contractapi.TransactionContextInterface, entityName, assetID, owner, newOwner string, expireIn time.Duration) (*TransferRequest, error) {
+	clientID, err := GetIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !matchIdentity(clientID, owner) {
+		return nil, ReturnError(UNAUTHORIZE)
+	}
+	if err := ValidateOwner(newOwner); err != nil {
+		return nil, err
+	}
+	if matchIdentity(newOwner, owner) {
+		return nil, fmt.Errorf("asset %s is already owned by the new owner", assetID)
+	}
+	// Keep the receiver's X.509 subject off the ledger as well.
+	if IsLegacyOwner(newOwner) {
+		newOwner = HashIdentity(txSalt(ctx), newOwner)
+	}
+
+	now, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := GetTransfer(ctx, assetID)
+	if err == nil && existing.Status == TRANSFERPENDING && now.Before(existing.ExpiresAt) {
+		return nil, fmt.Errorf("asset %s already has a pending transfer to %s", assetID, existing.To)
+	}
+
+	if expireIn <= 0 {
+		expireIn = TRANSFEREXPIRE
+	}
+
+	transfer := &TransferRequest{
+		DocType:   TRANSFERDOCTYPE,
+		AssetID:   assetID,
+		From:      owner,
+		To:        newOwner,
+		Status:    TRANSFERPENDING,
+		TxID:      ctx.GetStub().GetTxID(),
+		ExpiresAt: now.Add(expireIn),
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+	return transfer, putTransfer(ctx, entityName, transfer, EVENTTRANSFERPROPOSED)
+}
+
+func pendingTransfer(ctx contractapi.TransactionContextInterface, assetID string) (*TransferRequest, time.Time, error) {
+	transfer, err := GetTransfer(ctx, assetID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if transfer.Status != TRANSFERPENDING {
+		return nil, time.Time{}, fmt.Errorf("transfer for asset %s is %s", assetID, transfer.Status)
+	}
+	now, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !now.Before(transfer.ExpiresAt) {
+		return nil, time.Time{}, fmt.Errorf("transfer for asset %s expired at %s", assetID, transfer.ExpiresAt.Format(TIMEFORMAT))
+	}
+	return transfer, now, nil
+}
+
+// isReceiver reports whether the submitting client is the proposed new
+// owner, either by identity or by membership of the target MSP, and returns
+// the owner ID to record for it.
+func isReceiver(ctx contractapi.TransactionContextInterface, transfer *TransferRequest) (string, bool, error) {
+	clientID, err := GetIdentity(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if matchIdentity(clientID, transfer.To) {
+		return HashIdentity(txSalt(ctx), clientID), true, nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	return HashIdentity(txSalt(ctx), clientID), mspID == transfer.To, nil
+}
+
+// AcceptTransfer completes the pending transfer of assetID. The caller must
+// be the receiver and owner must still match the proposing owner; the
+// returned request carries the identity the asset now belongs to.
+func AcceptTransfer(ctx contractapi.TransactionContextInterface, entityName, assetID, owner string) (*TransferRequest, error) {
+	transfer, now, err := pendingTransfer(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.From != owner {
+		return nil, fmt.Errorf("asset %s changed owner since the transfer was proposed", assetID)
+	}
+	clientID, ok, err := isReceiver(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ReturnError(UNAUTHORIZE)
+	}
+
+	transfer.Status = TRANSFERACCEPTED
+	transfer.AcceptedBy = clientID
+	transfer.TxID = ctx.GetStub().GetTxID()
+	transfer.UpdatedAt = now
+	return transfer, putTransfer(ctx, entityName, transfer, EVENTTRANSFERACCEPTED)
+}
+
+func RejectTransfer(ctx contractapi.TransactionContextInterface, entityName, assetID string) (*TransferRequest, error) {
+	transfer, now, err := pendingTransfer(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	_, ok, err := isReceiver(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ReturnError(UNAUTHORIZE)
+	}
+
+	transfer.Status = TRANSFERREJECTED
+	transfer.TxID = ctx.GetStub().GetTxID()
+	transfer.UpdatedAt = now
+	return transfer, putTransfer(ctx, entityName, transfer, EVENTTRANSFERREJECTED)
+}
+
+// CancelTransfer withdraws a transfer. Expired requests may be canceled too,
+// so the owner can clear them before proposing again.
+func CancelTransfer(ctx contractapi.TransactionContextInterface, entityName, assetID string) (*TransferRequest, error) {
+	transfer, err := GetTransfer(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != TRANSFERPENDING {
+		return nil, fmt.Errorf("transfer for asset %s is %s", assetID, transfer.Status)
+	}
+	clientID, err := GetIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !matchIdentity(clientID, transfer.From) {
+		return nil, ReturnError(UNAUTHORIZE)
+	}
+	now, err := GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Status = TRANSFERCANCELED
+	transfer.TxID = ctx.GetStub().GetTxID()
+	transfer.UpdatedAt = now
+	return transfer, putTransfer(ctx, entityName, transfer, EVENTTRANSFERCANCELED)
+}
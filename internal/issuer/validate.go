@@ -0,0 +1,332 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entity fields declare their input rules in a validate tag, e.g.
+//
+//	AreaRai float32 `json:"areaRai" validate:"min=0,max=100000"`
+//
+// Supported rules: required, min=<n>, max=<n>, gt=<n> (strictly greater),
+// maxlen=<n>, enum=<a|b|c>, date (one of DATEFORMATS) and dive (validate
+// nested structs).
+const VALIDATETAG string = "validate"
+
+var DATEFORMATS = []string{
+	"2006-01-02",
+	TIMEFORMAT,
+	time.RFC3339,
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every failed rule of the input, not only the first.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	var fieldsJSON strings.Builder
+	encoder := json.NewEncoder(&fieldsJSON)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(e.Fields)
+	return fmt.Sprintf("validation failed: %s", strings.TrimSpace(fieldsJSON.String()))
+}
+
+type fieldRules struct {
+	required bool
+	dive     bool
+	date     bool
+	min      *float64
+	max      *float64
+	gt       *float64
+	maxLen   int
+	enum     []string
+}
+
+func parseRules(tag string) (fieldRules, error) {
+	var rules fieldRules
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+		switch name {
+		case "":
+		case "required":
+			rules.required = true
+		case "dive":
+			rules.dive = true
+		case "date":
+			rules.date = true
+		case "min", "max", "gt":
+			bound, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return rules, fmt.Errorf("invalid %s rule %q", name, rule)
+			}
+			switch name {
+			case "min":
+				rules.min = &bound
+			case "max":
+				rules.max = &bound
+			default:
+				rules.gt = &bound
+			}
+		case "maxlen":
+			maxLen, err := strconv.Atoi(arg)
+			if err != nil {
+				return rules, fmt.Errorf("invalid maxlen rule %q", rule)
+			}
+			rules.maxLen = maxLen
+		case "enum":
+			rules.enum = strings.Split(arg, "|")
+		default:
+			return rules, fmt.Errorf("unknown validate rule %q", rule)
+		}
+	}
+	return rules, nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Validate checks value, a struct or pointer to struct, against its validate
+// tags and returns a *ValidationError listing all failures.
+func Validate(value interface{}) error {
+	var errs []FieldError
+	validateStruct(reflect.ValueOf(value), "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// ValidateEach validates every element of a slice; field names are prefixed
+// with the element index, e.g. [3].areaRai.
+func ValidateEach(values interface{}) error {
+	var errs []FieldError
+	list := reflect.Indirect(reflect.ValueOf(values))
+	for i := 0; i < list.Len(); i++ {
+		validateStruct(list.Index(i), fmt.Sprintf("[%d].", i), &errs)
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, prefix string, errs *[]FieldError) {
+	value = reflect.Indirect(value)
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, ok := field.Tag.Lookup(VALIDATETAG)
+		if !ok {
+			continue
+		}
+		name := prefix + jsonName(field)
+		rules, err := parseRules(tag)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: name, Message: err.Error()})
+			continue
+		}
+		validateField(value.Field(i), name, rules, errs)
+	}
+}
+
+func validateField(value reflect.Value, name string, rules fieldRules, errs *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if rules.required {
+				fail("is required")
+			}
+			return
+		}
+		value = value.Elem()
+	}
+
+	if rules.required && value.IsZero() {
+		fail("is required")
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		text := value.String()
+		if rules.maxLen > 0 && len([]rune(text)) > rules.maxLen {
+			fail("must be at most %d characters", rules.maxLen)
+		}
+		if text == "" {
+			return
+		}
+		if len(rules.enum) > 0 && !containsString(rules.enum, text) {
+			fail("must be one of %s", strings.Join(rules.enum, ", "))
+		}
+		if rules.date && !IsDate(text) {
+			fail("must be a date in one of the formats %s", strings.Join(DATEFORMATS, ", "))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		checkRange(float64(value.Int()), rules, fail)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		checkRange(float64(value.Uint()), rules, fail)
+	case reflect.Float32, reflect.Float64:
+		checkRange(value.Float(), rules, fail)
+	case reflect.Struct:
+		if rules.dive {
+			validateStruct(value, name+".", errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if rules.dive {
+			for i := 0; i < value.Len(); i++ {
+				validateStruct(value.Index(i), fmt.Sprintf("%s[%d].", name, i), errs)
+			}
+		}
+	}
+}
+
+func checkRange(number float64, rules fieldRules, fail func(string, ...interface{})) {
+	if rules.min != nil && number < *rules.min {
+		fail("must be >= %v", *rules.min)
+	}
+	if rules.max != nil && number > *rules.max {
+		fail("must be <= %v", *rules.max)
+	}
+	if rules.gt != nil && number <= *rules.gt {
+		fail("must be > %v", *rules.gt)
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func IsDate(value string) bool {
+	for _, layout := range DATEFORMATS {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSchema describes value's type as a JSON Schema (draft-07) object,
+// including the constraints of its validate tags.
+func JSONSchema(value interface{}) map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(value))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = reflect.Indirect(reflect.ValueOf(value)).Type().Name()
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+				continue
+			}
+			name := jsonName(field)
+			property := typeSchema(field.Type)
+			rules, err := parseRules(field.Tag.Get(VALIDATETAG))
+			if err == nil {
+				applyRules(property, rules)
+				if rules.required {
+					required = append(required, name)
+				}
+			}
+			properties[name] = property
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+func applyRules(property map[string]interface{}, rules fieldRules) {
+	if rules.min != nil {
+		property["minimum"] = *rules.min
+	}
+	if rules.max != nil {
+		property["maximum"] = *rules.max
+	}
+	if rules.gt != nil {
+		property["exclusiveMinimum"] = *rules.gt
+	}
+	if rules.maxLen > 0 {
+		property["maxLength"] = rules.maxLen
+	}
+	if len(rules.enum) > 0 {
+		property["enum"] = rules.enum
+	}
+	if rules.date {
+		property["anyOf"] = []map[string]interface{}{
+			{"format": "date"},
+			{"format": "date-time"},
+		}
+	}
+}
+
+// ValidationSchema renders the JSON Schemas of entities keyed by name, for
+// the GetValidationSchema transaction of each chaincode.
+func ValidationSchema(entities map[string]interface{}) (string, error) {
+	schemas := map[string]interface{}{}
+	for name, value := range entities {
+		schemas[name] = JSONSchema(value)
+	}
+	schemasJSON, err := json.Marshal(schemas)
+	if err != nil {
+		return "", err
+	}
+	return string(schemasJSON), nil
+}
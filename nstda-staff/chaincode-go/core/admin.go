@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/entity"
+)
+
+// TargetChaincodes maps an admin request's targetType to the chaincode that
+// holds the record.
+var TargetChaincodes = map[string]string{
+	"farmer":   issuer.CCFARMER,
+	"packer":   issuer.CCPACKER,
+	"exporter": issuer.CCEXPORTER,
+	"gap":      issuer.CCGAP,
+	"gmp":      issuer.CCGMP,
+	"packing":  issuer.CCPACKING,
+}
+
+// LogAdminAction appends an entry to the admin-action log under
+// adminAction~targetType~targetId~txId. The chaincode has no function that
+// updates or deletes entries.
+func LogAdminAction(ctx contractapi.TransactionContextInterface, action, targetType, targetID, reason, details string) (*entity.AdminAction, []byte, error) {
+	actor, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &entity.AdminAction{
+		DocType:    entity.ADMINACTIONDOCTYPE,
+		Id:         ctx.GetStub().GetTxID(),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    details,
+		Actor:      actor,
+		OrgName:    orgName,
+		CreatedAt:  now,
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(entity.ADMINACTIONDOCTYPE, []string{targetType, targetID, entry.Id})
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return nil, nil, fmt.Errorf("admin action %s is already logged", entry.Id)
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, entryJSON, ctx.GetStub().PutState(key, entryJSON)
+}
+
+// ListAdminActions returns the log entries of one target, or of every target
+// of a type when targetID is empty.
+func ListAdminActions(ctx contractapi.TransactionContextInterface, targetType, targetID string) ([]*entity.AdminAction, error) {
+	attributes := []string{targetType}
+	if targetID != "" {
+		attributes = append(attributes, targetID)
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(entity.ADMINACTIONDOCTYPE, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	actions := []*entity.AdminAction{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var action entity.AdminAction
+		if err := json.Unmarshal(queryResponse.Value, &action); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/entity"
 )
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll) ([]*entity.TransectionReponse, error) {
-	var filter = map[string]interface{}{}
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllNstdaStaff) ([]*entity.TransectionNstdaStaffReponse, error) {
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	selector := map[string]interface{}{
 		"selector": filter,
@@ -30,15 +31,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryNstda.Close()
 
-	var dataNstda []*entity.TransectionReponse
+	var dataNstda []*entity.TransectionNstdaStaffReponse
 	for queryNstda.HasNext() {
 		queryRes, err := queryNstda.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataP entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataP)
+		var dataP entity.TransectionNstdaStaffReponse
+		err = Schema.Unmarshal(ctx, queryRes.Value, &dataP)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,6 @@
+package core
+
+import "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+
+// Schema upgrades stored staff records to the current entity.TransectionNstdaStaff.
+var Schema = issuer.NewSchema()
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+const (
+	ADMINACTIONDOCTYPE string = "adminAction"
+
+	ADMINAPPROVE  string = "APPROVE"
+	ADMINREJECT   string = "REJECT"
+	ADMINSUSPEND  string = "SUSPEND"
+	ADMINOVERRIDE string = "OVERRIDE"
+	ADMINAUDIT    string = "AUDIT"
+)
+
+// RegistrationRequest approves, rejects or suspends a farmer, packer or
+// exporter registration.
+type RegistrationRequest struct {
+	TargetType string `json:"targetType" validate:"required,enum=farmer|packer|exporter"`
+	TargetID   string `json:"targetId" validate:"required,maxlen=128"`
+	Status     string `json:"status" validate:"required,enum=APPROVED|REJECTED|SUSPENDED"`
+	Reason     string `json:"reason" validate:"maxlen=1024"`
+}
+
+// OverrideRequest corrects fields of a record in another chaincode. Patch
+// holds the new field values.
+type OverrideRequest struct {
+	TargetType string                 `json:"targetType" validate:"required,enum=farmer|packer|exporter|gap|gmp|packing"`
+	TargetID   string                 `json:"targetId" validate:"required,maxlen=128"`
+	Patch      map[string]interface{} `json:"patch" validate:"required"`
+	Reason     string                 `json:"reason" validate:"required,maxlen=1024"`
+}
+
+// AuditRequest asks for the audit view of one record in another chaincode.
+type AuditRequest struct {
+	TargetType string `json:"targetType" validate:"required,enum=farmer|packer|exporter|gap|gmp|packing"`
+	TargetID   string `json:"targetId" validate:"required,maxlen=128"`
+	Reason     string `json:"reason" validate:"required,maxlen=1024"`
+}
+
+// AdminAction is one entry of the admin-action log. Entries are keyed by
+// transaction ID and never updated or deleted.
+type AdminAction struct {
+	DocType    string    `json:"docType"`
+	Id         string    `json:"id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetId"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+	Actor      string    `json:"actor"`
+	OrgName    string    `json:"orgName"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// AuditReport is a record as stored by its chaincode (Record is its JSON
+// text), with its latest transfer, regulatory status and admin-action history.
+type AuditReport struct {
+	TargetType       string                   `json:"targetType"`
+	TargetID         string                   `json:"targetId"`
+	Record           string                   `json:"record"`
+	Transfer         *issuer.TransferRequest  `json:"transfer,omitempty" metadata:",optional"`
+	RegulatoryStatus *issuer.RegulatoryStatus `json:"regulatoryStatus,omitempty" metadata:",optional"`
+	AdminActions     []*AdminAction           `json:"adminActions"`
+}
@@ -2,15 +2,15 @@ package entity
 
 import "time"
 
-type TransectionReponse struct {
+type TransectionNstdaStaffReponse struct {
 	Id        string    `json:"id"`
 	CertId    string    `json:"certId"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllNstdaStaffReponse struct {
+	Data  string                          `json:"data"`
+	Obj   []*TransectionNstdaStaffReponse `json:"obj"`
+	Total int                             `json:"total"`
 }
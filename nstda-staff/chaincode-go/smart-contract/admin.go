@@ -0,0 +1,164 @@
+package nstdaStaff
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/entity"
+)
+
+// SetRegistrationStatus approves, rejects or suspends a farmer, packer or
+// exporter registration in its chaincode and logs the action. Only
+// administrators may call it.
+func (s *SmartContract) SetRegistrationStatus(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityRequest := entity.RegistrationRequest{}
+	inputInterface, err := issuer.Unmarshal(args, entityRequest)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.RegistrationRequest)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	_, err = issuer.Invoke(ctx, core.TargetChaincodes[input.TargetType], "SetRegistrationStatus", input.TargetID, input.Status, input.Reason)
+	if err != nil {
+		return err
+	}
+
+	action := map[string]string{
+		issuer.REGAPPROVED:  entity.ADMINAPPROVE,
+		issuer.REGREJECTED:  entity.ADMINREJECT,
+		issuer.REGSUSPENDED: entity.ADMINSUSPEND,
+	}[input.Status]
+	entry, entryJSON, err := core.LogAdminAction(ctx, action, input.TargetType, input.TargetID, input.Reason, "")
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ADMINACTIONDOCTYPE, issuer.EventItem{ID: entry.Id, Payload: entryJSON})
+}
+
+// AdminOverride corrects fields of a record in another chaincode. A reason is
+// required, and the patch is kept in the log next to it.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityRequest := entity.OverrideRequest{}
+	inputInterface, err := issuer.Unmarshal(args, entityRequest)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.OverrideRequest)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	patchJSON, err := json.Marshal(input.Patch)
+	if err != nil {
+		return err
+	}
+	_, err = issuer.Invoke(ctx, core.TargetChaincodes[input.TargetType], "AdminOverride", input.TargetID, string(patchJSON))
+	if err != nil {
+		return err
+	}
+
+	entry, entryJSON, err := core.LogAdminAction(ctx, entity.ADMINOVERRIDE, input.TargetType, input.TargetID, input.Reason, string(patchJSON))
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ADMINACTIONDOCTYPE, issuer.EventItem{ID: entry.Id, Payload: entryJSON})
+}
+
+// AuditRecord collects a record from its chaincode together with its latest
+// transfer, regulatory status and admin-action history. The lookup itself is
+// logged and announced like other admin actions, so staff must submit it
+// rather than evaluate it for the entry to be kept.
+func (s *SmartContract) AuditRecord(ctx contractapi.TransactionContextInterface, args string) (*entity.AuditReport, error) {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entityRequest := entity.AuditRequest{}
+	inputInterface, err := issuer.Unmarshal(args, entityRequest)
+	if err != nil {
+		return nil, err
+	}
+	input := inputInterface.(*entity.AuditRequest)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	chaincodeName := core.TargetChaincodes[input.TargetType]
+	record, err := issuer.InvokeQuery(ctx, chaincodeName, "ReadAsset", input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	report := &entity.AuditReport{
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Record:     string(record),
+	}
+
+	// A record that was never transferred has no transfer request.
+	if transferJSON, err := issuer.InvokeQuery(ctx, chaincodeName, "GetTransfer", input.TargetID); err == nil {
+		var transfer issuer.TransferRequest
+		if err := json.Unmarshal(transferJSON, &transfer); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		report.Transfer = &transfer
+	}
+
+	switch input.TargetType {
+	case issuer.TARGETGAP, issuer.TARGETGMP, issuer.TARGETPACKING:
+		statusJSON, err := issuer.InvokeQuery(ctx, chaincodeName, "GetRegulatoryStatus", input.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		var status issuer.RegulatoryStatus
+		if err := json.Unmarshal(statusJSON, &status); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		report.RegulatoryStatus = &status
+	}
+
+	report.AdminActions, err = core.ListAdminActions(ctx, input.TargetType, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, entryJSON, err := core.LogAdminAction(ctx, entity.ADMINAUDIT, input.TargetType, input.TargetID, input.Reason, "")
+	if err != nil {
+		return nil, err
+	}
+	err = issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ADMINACTIONDOCTYPE, issuer.EventItem{ID: entry.Id, Payload: entryJSON})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetAdminActions returns the admin-action log of one record, or of every
+// record of a type when targetID is empty.
+func (s *SmartContract) GetAdminActions(ctx contractapi.TransactionContextInterface, targetType string, targetID string) ([]*entity.AdminAction, error) {
+	return core.ListAdminActions(ctx, targetType, targetID)
+}
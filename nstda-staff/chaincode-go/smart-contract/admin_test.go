@@ -0,0 +1,223 @@
+package nstdaStaff_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/entity"
+	nstdaStaff "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/nstda-staff/chaincode-go/smart-contract"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+)
+
+// newNetwork deploys the nstda-staff chaincode next to a farmer chaincode
+// holding farmer F-1 and a gap chaincode holding G-1, which was transferred
+// and is suspended.
+func newNetwork(t *testing.T) (*issuertest.Network, *issuertest.Fake) {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&nstdaStaff.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := func(args []string) error {
+		if args[0] != "F-1" && args[0] != "G-1" {
+			return fmt.Errorf("the asset %s does not exist", args[0])
+		}
+		return nil
+	}
+	farmer := issuertest.NewFake().
+		On("SetRegistrationStatus", func(args []string) ([]byte, error) { return nil, known(args) }).
+		On("AdminOverride", func(args []string) ([]byte, error) { return nil, known(args) }).
+		Returns("ReadAsset", `{"id":"F-1"}`).
+		Fails("GetTransfer", "no transfer request for F-1")
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCNSTDASTAFF, chaincode)
+	network.Deploy(issuer.CCFARMER, farmer)
+	network.Deploy(issuer.CCGAP, issuertest.NewFake().
+		Returns("ReadAsset", `{"id":"G-1"}`).
+		Returns("AdminOverride", nil).
+		Returns("GetTransfer", issuer.TransferRequest{AssetID: "G-1", Status: issuer.TRANSFERACCEPTED}).
+		Returns("GetRegulatoryStatus", issuer.RegulatoryStatus{TargetType: issuer.TARGETGAP, TargetID: "G-1", Status: issuer.CERTSUSPENDED}))
+	return network, farmer
+}
+
+func adminActions(t *testing.T, network *issuertest.Network, targetType, targetID string) []entity.AdminAction {
+	t.Helper()
+	payload, err := network.Evaluate(admin, issuer.CCNSTDASTAFF, "GetAdminActions", targetType, targetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []entity.AdminAction
+	if err := json.Unmarshal(payload, &actions); err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
+
+func TestSetRegistrationStatus(t *testing.T) {
+	network, farmer := newNetwork(t)
+
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		args     string
+		wantErr  bool
+	}{
+		{name: "not an admin", identity: alice, args: `{"targetType":"farmer","targetId":"F-1","status":"APPROVED"}`, wantErr: true},
+		{name: "unknown farmer", identity: admin, args: `{"targetType":"farmer","targetId":"F-9","status":"APPROVED"}`, wantErr: true},
+		{name: "not a registration", identity: admin, args: `{"targetType":"gap","targetId":"G-1","status":"APPROVED"}`, wantErr: true},
+		{name: "unknown status", identity: admin, args: `{"targetType":"farmer","targetId":"F-1","status":"PENDING"}`, wantErr: true},
+		{name: "approve", identity: admin, args: `{"targetType":"farmer","targetId":"F-1","status":"APPROVED","reason":"documents checked"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCNSTDASTAFF, "SetRegistrationStatus", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	if calls := farmer.CallsTo("SetRegistrationStatus"); fmt.Sprint(calls[len(calls)-1]) != "[F-1 APPROVED documents checked]" {
+		t.Fatalf("farmer calls = %v", calls)
+	}
+	actions := adminActions(t, network, "farmer", "")
+	if len(actions) != 1 || actions[0].Action != entity.ADMINAPPROVE || actions[0].TargetID != "F-1" || actions[0].OrgName != admin.MSPID {
+		t.Fatalf("admin actions = %+v", actions)
+	}
+}
+
+func TestAdminOverride(t *testing.T) {
+	network, farmer := newNetwork(t)
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{name: "without a reason", args: `{"targetType":"farmer","targetId":"F-1","patch":{"certId":"C-2"}}`, wantErr: true},
+		{name: "unknown target type", args: `{"targetType":"staff","targetId":"F-1","patch":{"certId":"C-2"},"reason":"typo"}`, wantErr: true},
+		{name: "override", args: `{"targetType":"farmer","targetId":"F-1","patch":{"certId":"C-2"},"reason":"typo"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(admin, issuer.CCNSTDASTAFF, "AdminOverride", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	if calls := farmer.CallsTo("AdminOverride"); len(calls) != 1 || calls[0][1] != `{"certId":"C-2"}` {
+		t.Fatalf("farmer calls = %v", calls)
+	}
+	actions := adminActions(t, network, "farmer", "F-1")
+	if len(actions) != 1 || actions[0].Details != `{"certId":"C-2"}` || actions[0].Reason != "typo" {
+		t.Fatalf("admin actions = %+v", actions)
+	}
+}
+
+func TestAuditRecord(t *testing.T) {
+	network, _ := newNetwork(t)
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "AdminOverride",
+		`{"targetType":"gap","targetId":"G-1","patch":{"areaRai":5},"reason":"survey"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := func(args string) *entity.AuditReport {
+		t.Helper()
+		payload, err := network.Submit(admin, issuer.CCNSTDASTAFF, "AuditRecord", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report entity.AuditReport
+		if err := json.Unmarshal(payload, &report); err != nil {
+			t.Fatal(err)
+		}
+		return &report
+	}
+
+	report := audit(`{"targetType":"gap","targetId":"G-1","reason":"complaint"}`)
+	if report.Record != `{"id":"G-1"}` || report.Transfer == nil || report.RegulatoryStatus == nil ||
+		report.RegulatoryStatus.Status != issuer.CERTSUSPENDED || len(report.AdminActions) != 1 {
+		t.Fatalf("gap report = %+v", report)
+	}
+
+	report = audit(`{"targetType":"farmer","targetId":"F-1","reason":"complaint"}`)
+	if report.Transfer != nil || report.RegulatoryStatus != nil {
+		t.Fatalf("farmer report = %+v", report)
+	}
+	if event := network.LastEvent(); event == nil || event.EventName != entity.ADMINACTIONDOCTYPE+"."+issuer.EVENTCREATED {
+		t.Fatalf("event = %v", event)
+	}
+
+	if _, err := network.Submit(alice, issuer.CCNSTDASTAFF, "AuditRecord", `{"targetType":"gap","targetId":"G-1","reason":"curious"}`); err == nil {
+		t.Fatal("a member audited a record")
+	}
+	counts := map[string]int{}
+	for _, action := range adminActions(t, network, issuer.TARGETGAP, "G-1") {
+		counts[action.Action]++
+	}
+	if counts[entity.ADMINOVERRIDE] != 1 || counts[entity.ADMINAUDIT] != 1 || len(counts) != 2 {
+		t.Fatalf("admin actions = %v", counts)
+	}
+}
+
+func TestGetAllNstdaStaff(t *testing.T) {
+	network, _ := newNetwork(t)
+	for _, args := range []string{`{"id":"N-1"}`, `{"id":"N-2"}`} {
+		if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "CreateNstdaStaff", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "SetRegistrationStatus",
+		`{"targetType":"farmer","targetId":"F-1","status":"APPROVED"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "staff only", args: `{"limit":0}`, want: "[N-1 N-2]", wantTotal: 2},
+		{name: "skip", args: `{"skip":1,"limit":0}`, want: "[N-2]", wantTotal: 2},
+		{name: "skip past the total", args: `{"skip":3,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCNSTDASTAFF, "GetAllNstdaStaff", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllNstdaStaffReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("staff = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
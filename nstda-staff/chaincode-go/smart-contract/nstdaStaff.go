@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
@@ -15,6 +16,20 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllNstdaStaff",
+		"FilterNstdaStaff",
+		"GetAdminActions",
+		"GetTransfer",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
 func (s *SmartContract) CreateNstdaStaff(
 	ctx contractapi.TransactionContextInterface,
 	args string,
@@ -26,6 +41,11 @@ func (s *SmartContract) CreateNstdaStaff(
 	}
 	input := inputInterface.(*entity.TransectionNstdaStaff)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("nstdaStaff.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
@@ -38,7 +58,7 @@ func (s *SmartContract) CreateNstdaStaff(
 		return fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	TimeNstda := issuer.GetTimeNow()
@@ -50,11 +70,17 @@ func (s *SmartContract) CreateNstdaStaff(
 		OrgName:   orgName,
 		UpdatedAt: TimeNstda,
 		CreatedAt: TimeNstda,
+		SchemaVersion: core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
@@ -62,17 +88,25 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 
 	entityNstda := entity.TransectionNstdaStaff{}
 	inputInterface, err := issuer.Unmarshal(args, entityNstda)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionNstdaStaff)
 
-	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	asset, err := s.ReadAsset(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
-	if clientID != asset.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
 	}
 
 	UpdatedNstda := issuer.GetTimeNow()
@@ -83,42 +117,86 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	assetJSON, errN := json.Marshal(asset)
 	issuer.HandleError(errN)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetNstda, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDNstda, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetNstda.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDNstda != assetNstda.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetNstda)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetN, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetN.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
+
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetN, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	if clientID != assetN.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetN.Owner)
+	if err != nil {
+		return err
 	}
 
-	assetN.Owner = newOwner
+	assetN.Owner = transfer.AcceptedBy
+	assetN.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetN)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionNstdaStaff, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -130,7 +208,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionNstdaStaff
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -138,16 +216,9 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &asset, nil
 }
 
-func (s *SmartContract) GetAllNstdaStaff(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
-
-	var filterNstda = map[string]interface{}{}
+func (s *SmartContract) GetAllNstdaStaff(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllNstdaStaff) (*entity.GetAllNstdaStaffReponse, error) {
 
-	entityGetAll := entity.FilterGetAll{}
-	interfaceNstda, err := issuer.Unmarshal(args, entityGetAll)
-	if err != nil {
-		return nil, err
-	}
-	input := interfaceNstda.(*entity.FilterGetAll)
+	var filterNstda = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	queryStringNstda, err := issuer.BuildQueryString(filterNstda)
 	if err != nil {
@@ -163,7 +234,7 @@ func (s *SmartContract) GetAllNstdaStaff(ctx contractapi.TransactionContextInter
 		return nil, issuer.ReturnError(issuer.SKIPOVER)
 	}
 
-	arrNstda, err := core.FetchResultsWithPagination(ctx, input)
+	arrNstda, err := core.FetchResultsWithPagination(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -173,10 +244,10 @@ func (s *SmartContract) GetAllNstdaStaff(ctx contractapi.TransactionContextInter
 	})
 
 	if len(arrNstda) == 0 {
-		arrNstda = []*entity.TransectionReponse{}
+		arrNstda = []*entity.TransectionNstdaStaffReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllNstdaStaffReponse{
 		Data:  "All NstdaStaff",
 		Obj:   arrNstda,
 		Total: total,
@@ -197,14 +268,19 @@ func (s *SmartContract) FilterNstdaStaff(ctx contractapi.TransactionContextInter
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionNstdaStaff
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -219,3 +295,36 @@ func (s *SmartContract) FilterNstdaStaff(ctx contractapi.TransactionContextInter
 
 	return assetNstda, nil
 }
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME:     entity.TransectionNstdaStaff{},
+		"registrationRequest": entity.RegistrationRequest{},
+		"overrideRequest":     entity.OverrideRequest{},
+		"auditRequest":        entity.AuditRequest{},
+	})
+}
@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/entity"
 )
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll) ([]*entity.TransectionReponse, error) {
-	var filter = map[string]interface{}{}
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllPacker) ([]*entity.TransectionPackerReponse, error) {
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	selector := map[string]interface{}{
 		"selector": filter,
@@ -30,20 +31,19 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryPacker.Close()
 
-	var dataPacker []*entity.TransectionReponse
+	var dataPacker []*entity.TransectionPackerReponse
 	for queryPacker.HasNext() {
 		queryRes, err := queryPacker.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataP entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataP)
+		dataP, err := ToResponse(ctx, queryRes.Value)
 		if err != nil {
 			return nil, err
 		}
 
-		dataPacker = append(dataPacker, &dataP)
+		dataPacker = append(dataPacker, dataP)
 	}
 
 	return dataPacker, nil
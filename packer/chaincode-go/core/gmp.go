@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/entity"
+)
+
+// PackingHouses returns the GMP registrations a client sent for a packer. A
+// client still sending the single PackerGmp registers that one, as active.
+func PackingHouses(packer *entity.TransectionPacker) []entity.PackerGmp {
+	if len(packer.PackerGmps) > 0 {
+		return packer.PackerGmps
+	}
+	gmps := []entity.PackerGmp{}
+	legacy := packer.PackerGmp
+	if legacy.PackingHouseRegisterNumber != "" || legacy.PackingHouseName != "" {
+		if legacy.Status == "" {
+			legacy.Status = entity.GMPACTIVE
+		}
+		gmps = append(gmps, legacy)
+	}
+	return gmps
+}
+
+// FindPackingHouse returns the index of the registration with registerNumber,
+// or -1.
+func FindPackingHouse(gmps []entity.PackerGmp, registerNumber string) int {
+	for i, gmp := range gmps {
+		if gmp.PackingHouseRegisterNumber == registerNumber {
+			return i
+		}
+	}
+	return -1
+}
+
+// NormalizePackingHouses ties registrations to packerID, defaults their
+// status to ACTIVE and rejects a register number listed twice.
+func NormalizePackingHouses(packerID string, gmps []entity.PackerGmp) ([]entity.PackerGmp, error) {
+	normalized := []entity.PackerGmp{}
+	for _, gmp := range gmps {
+		if gmp.PackingHouseRegisterNumber != "" && FindPackingHouse(normalized, gmp.PackingHouseRegisterNumber) >= 0 {
+			return nil, fmt.Errorf("packing house %s is listed twice", gmp.PackingHouseRegisterNumber)
+		}
+		gmp.PackerId = packerID
+		if gmp.Status == "" {
+			gmp.Status = entity.GMPACTIVE
+		}
+		normalized = append(normalized, gmp)
+	}
+	return normalized, nil
+}
+
+// ToResponse decodes a stored packer into its response form.
+func ToResponse(ctx contractapi.TransactionContextInterface, value []byte) (*entity.TransectionPackerReponse, error) {
+	var response entity.TransectionPackerReponse
+	if err := Schema.Unmarshal(ctx, value, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/entity"
+)
+
+// Schema upgrades stored packers to the current entity.TransectionPacker.
+var Schema = issuer.NewSchema().
+	// 1: packers hold several packing houses in packerGmps; the single
+	// packerGmp of older packers becomes their one, active, registration.
+	Register(1, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+		legacy, _ := record["packerGmp"].(map[string]interface{})
+		delete(record, "packerGmp")
+		if gmps, _ := record["packerGmps"].([]interface{}); len(gmps) > 0 || legacy == nil {
+			return nil
+		}
+		registerNumber, _ := legacy["packingHouseRegisterNumber"].(string)
+		name, _ := legacy["packingHouseName"].(string)
+		if registerNumber == "" && name == "" {
+			return nil
+		}
+		if status, _ := legacy["status"].(string); status == "" {
+			legacy["status"] = entity.GMPACTIVE
+		}
+		legacy["packerId"] = record["id"]
+		record["packerGmps"] = []interface{}{legacy}
+		return nil
+	})
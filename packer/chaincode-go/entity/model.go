@@ -1,32 +1,51 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+const (
+	ENTITYNAME string = "packer"
+
+	GMPACTIVE   string = "ACTIVE"
+	GMPINACTIVE string = "INACTIVE"
+)
 
 type TransectionPacker struct {
-	Id        string    `json:"id"`
-	CertId    string    `json:"certId"`
-	UserId    string    `json:"userId"`
+	Id        string    `json:"id" validate:"required,maxlen=128"`
+	CertId    string    `json:"certId" validate:"maxlen=128"`
+	UserId    string    `json:"userId" validate:"maxlen=128"`
+	Registration issuer.Registration `json:"registration"`
 	Owner     string    `json:"owner"`
 	OrgName   string    `json:"orgName"`
-	PackerGmp PackerGmp `json:"packerGmp"`
+	// PackerGmp is the single packing house clients sent before PackerGmps
+	// existed; it is folded into PackerGmps on create and stored empty.
+	PackerGmp  PackerGmp   `json:"packerGmp" validate:"dive"`
+	PackerGmps []PackerGmp `json:"packerGmps" validate:"dive"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip  int `json:"skip"`
-	Limit int `json:"limit"`
-	PackerGmp string `json:"packerGmp"`
+type FilterGetAllPacker struct {
+	Skip      int    `json:"skip" metadata:",optional"`
+	Limit     int    `json:"limit"`
+	PackerGmp string `json:"packerGmp" metadata:",optional"`
 }
 
 type PackerGmp struct {
-	Id                         string    `json:"id"`
-	PackerId 				   string    `json:"packerId"`
-	PackingHouseRegisterNumber string    `json:"packingHouseRegisterNumber"`
-	Address                    string    `json:"address"`
-	PackingHouseName           string    `json:"packingHouseName"`
-	UpdatedDate                string    `json:"updatedDate"`
-	Source                     string    `json:"source"`
+	Id                         string    `json:"id" validate:"maxlen=128"`
+	PackerId 				   string    `json:"packerId" validate:"maxlen=128"`
+	PackingHouseRegisterNumber string    `json:"packingHouseRegisterNumber" validate:"maxlen=128"`
+	Address                    string    `json:"address" validate:"maxlen=1024"`
+	PackingHouseName           string    `json:"packingHouseName" validate:"maxlen=256"`
+	UpdatedDate                string    `json:"updatedDate" validate:"date"`
+	Source                     string    `json:"source" validate:"maxlen=256"`
+	Status                     string    `json:"status" validate:"enum=ACTIVE|INACTIVE"`
 	Owner                      string    `json:"owner"`
 	OrgName                    string    `json:"orgName"`
 	UpdatedAt                  time.Time `json:"updatedAt"`
@@ -1,17 +1,23 @@
 package entity
 
-import "time"
+import (
+	"time"
 
-type TransectionReponse struct {
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+type TransectionPackerReponse struct {
 	Id        string    `json:"id"`
 	CertId    string    `json:"certId"`
 	UserId    string    `json:"userId"`
+	PackerGmps []PackerGmp `json:"packerGmps"`
+	Registration issuer.Registration `json:"registration"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllPackerReponse struct {
+	Data  string                      `json:"data"`
+	Obj   []*TransectionPackerReponse `json:"obj"`
+	Total int                         `json:"total"`
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
@@ -15,48 +16,94 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllPacker",
+		"FilterPacker",
+		"GetPackerById",
+		"GetLastIdPacker",
+		"GetPackingHouses",
+		"GetTransfer",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
+// CreatePacker creates a packer and returns its ID. Without an id in args the
+// ID is allocated with issuer.NextID.
 func (s *SmartContract) CreatePacker(
 	ctx contractapi.TransactionContextInterface,
 	args string,
-) error {
+) (string, error) {
 	entityPacker := entity.TransectionPacker{}
 	inputInterface, err := issuer.Unmarshal(args, entityPacker)
 	if err != nil {
-		return err
+		return "", err
 	}
 	input := inputInterface.(*entity.TransectionPacker)
 
+	if input.Id == "" {
+		input.Id, err = issuer.NextID(ctx, issuer.IDPREFIXPACKER)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return "", err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("packer.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return fmt.Errorf("submitting client not authorized to create asset, does not have packer.creator role")
+		return "", fmt.Errorf("submitting client not authorized to create asset, does not have packer.creator role")
 	}
 
 	existPacker, err := issuer.AssetExists(ctx, input.Id)
 	issuer.HandleError(err)
 	if existPacker {
-		return fmt.Errorf("the asset %s already exists", input.Id)
+		return "", fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
+	packerGmps, err := core.NormalizePackingHouses(input.Id, core.PackingHouses(input))
+	if err != nil {
+		return "", err
+	}
+
 	TimePacker := issuer.GetTimeNow()
 
 	asset := entity.TransectionPacker{
 		Id:        input.Id,
 		CertId:    input.CertId,
 		UserId:    input.UserId,
-		PackerGmp: input.PackerGmp,
+		PackerGmps: packerGmps,
 		Owner:     clientID,
 		OrgName:   orgName,
+		Registration: issuer.NewRegistration(),
 		UpdatedAt: TimePacker,
 		CreatedAt: TimePacker,
+		SchemaVersion: core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return "", err
+	}
+
+	err = issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
+	if err != nil {
+		return "", err
+	}
+	return input.Id, nil
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
@@ -64,17 +111,25 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 
 	entityPacker := entity.TransectionPacker{}
 	inputInterface, err := issuer.Unmarshal(args, entityPacker)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionPacker)
 
-	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	asset, err := s.ReadAsset(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
-	if clientID != asset.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
 	}
 
 	UpdatedPacker := issuer.GetTimeNow()
@@ -83,47 +138,90 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	asset.CertId = input.CertId
 	asset.UserId = input.UserId
 	asset.UpdatedAt = UpdatedPacker
-	asset.PackerGmp = input.PackerGmp
 
 	assetJSON, errP := json.Marshal(asset)
 	issuer.HandleError(errP)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetPacker, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDPacker, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetPacker.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDPacker != assetPacker.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetPacker)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetP, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetP.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
+
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
 
-	if clientID != assetP.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	assetP, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetP.Owner)
+	if err != nil {
+		return err
 	}
 
-	assetP.Owner = newOwner
+	assetP.Owner = transfer.AcceptedBy
+	assetP.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetP)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionPacker, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -135,7 +233,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionPacker
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +241,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &asset, nil
 }
 
-func (s *SmartContract) GetPackerById(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionReponse, error) {
+func (s *SmartContract) GetPackerById(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionPackerReponse, error) {
 	queryPacker := fmt.Sprintf(`{"selector":{"id":"%s"}}`, id)
 
 	resultsPacker, err := ctx.GetStub().GetQueryResult(queryPacker)
@@ -161,25 +259,17 @@ func (s *SmartContract) GetPackerById(ctx contractapi.TransactionContextInterfac
 		return nil, fmt.Errorf("error getting next query result: %v", err)
 	}
 
-	var asset entity.TransectionReponse
-	err = json.Unmarshal(queryResponse.Value, &asset)
+	asset, err := core.ToResponse(ctx, queryResponse.Value)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling asset JSON: %v", err)
 	}
 
-	return &asset, nil
+	return asset, nil
 }
 
-func (s *SmartContract) GetAllPacker(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
-
-	var filterPacker = map[string]interface{}{}
+func (s *SmartContract) GetAllPacker(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllPacker) (*entity.GetAllPackerReponse, error) {
 
-	entityGetAll := entity.FilterGetAll{}
-	interfacePacker, err := issuer.Unmarshal(args, entityGetAll)
-	if err != nil {
-		return nil, err
-	}
-	input := interfacePacker.(*entity.FilterGetAll)
+	var filterPacker = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	queryStringPacker, err := issuer.BuildQueryString(filterPacker)
 	if err != nil {
@@ -195,7 +285,7 @@ func (s *SmartContract) GetAllPacker(ctx contractapi.TransactionContextInterface
 		return nil, issuer.ReturnError(issuer.SKIPOVER)
 	}
 
-	arrPacker, err := core.FetchResultsWithPagination(ctx, input)
+	arrPacker, err := core.FetchResultsWithPagination(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -205,10 +295,10 @@ func (s *SmartContract) GetAllPacker(ctx contractapi.TransactionContextInterface
 	})
 
 	if len(arrPacker) == 0 {
-		arrPacker = []*entity.TransectionReponse{}
+		arrPacker = []*entity.TransectionPackerReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllPackerReponse{
 		Data:  "All Packer",
 		Obj:   arrPacker,
 		Total: total,
@@ -229,14 +319,19 @@ func (s *SmartContract) FilterPacker(ctx contractapi.TransactionContextInterface
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionPacker
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -252,41 +347,15 @@ func (s *SmartContract) FilterPacker(ctx contractapi.TransactionContextInterface
 	return assetPacker, nil
 }
 
-func (s *SmartContract) GetLastIdPacker(ctx contractapi.TransactionContextInterface) string {
-	// Query to get all records sorted by ID in descending order
-	query := `{
-		"selector": {},
-		"sort": [{"_id": "desc"}],
-		"limit": 1
-	}`
-
-	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
-	if err != nil {
-		return fmt.Sprintf("error querying CouchDB: %v", err)
-	}
-	defer resultsIterator.Close()
-
-	// Check if there is a result
-	if !resultsIterator.HasNext() {
-		return ""
-	}
-
-	// Get the first (and only) result
-	queryResponse, err := resultsIterator.Next()
+// GetLastIdPacker returns the last packer ID allocated this year, or "" if none
+// was. CreatePacker allocates IDs itself; clients should not derive the next ID
+// from this one.
+func (s *SmartContract) GetLastIdPacker(ctx contractapi.TransactionContextInterface) (string, error) {
+	counter, err := issuer.GetIDCounter(ctx, issuer.IDPREFIXPACKER)
 	if err != nil {
-		return "error iterating query results"
-	}
-
-	var result struct {
-		Id string `json:"id"`
-	}
-
-	// Unmarshal the result into the result struct
-	if err := json.Unmarshal(queryResponse.Value, &result); err != nil {
-		return "error unmarshalling document"
+		return "", err
 	}
-
-	return result.Id
+	return counter.LastID, nil
 }
 
 func (s *SmartContract) CreatePackerCsv(
@@ -294,13 +363,18 @@ func (s *SmartContract) CreatePackerCsv(
 	args string,
 ) error {
 	var inputs []entity.TransectionPacker
-	var eventPayloads []entity.TransectionPacker
+	var eventItems []issuer.EventItem
 
 	errPackerInput := json.Unmarshal([]byte(args), &inputs)
 	if errPackerInput != nil {
 		return fmt.Errorf("failed to unmarshal JSON array: %v", errPackerInput)
 	}
 
+	errValidate := issuer.ValidateEach(inputs)
+	if errValidate != nil {
+		return errValidate
+	}
+
 	for _, input := range inputs {
 		orgName, err := ctx.GetClientIdentity().GetMSPID()
 		if err != nil {
@@ -315,26 +389,33 @@ func (s *SmartContract) CreatePackerCsv(
 			return fmt.Errorf("the asset %s already exists", input.Id)
 		}
 
-		clientID, err := issuer.GetIdentity(ctx)
+		clientID, err := issuer.GetOwnerID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to get submitting client's identity: %v", err)
 		}
 
+		packerGmps, err := core.NormalizePackingHouses(input.Id, core.PackingHouses(&input))
+		if err != nil {
+			return err
+		}
+
 		asset := entity.TransectionPacker{
 			Id:        input.Id,
 			CertId:    input.CertId,
-			PackerGmp: input.PackerGmp,
+			PackerGmps: packerGmps,
 			Owner:     clientID,
 			OrgName:   orgName,
+			Registration: issuer.NewRegistration(),
 			UpdatedAt: input.CreatedAt,
 			CreatedAt: input.UpdatedAt,
+			SchemaVersion: core.Schema.Version(),
 		}
 
 		packerAssetJSON, packerErr := json.Marshal(asset)
-		eventPayloads = append(eventPayloads, asset)
 		if packerErr != nil {
-			return fmt.Errorf("failed to marshal asset JSON: %v", err)
+			return fmt.Errorf("failed to marshal asset JSON: %v", packerErr)
 		}
+		eventItems = append(eventItems, issuer.EventItem{ID: input.Id, Payload: packerAssetJSON})
 
 		err = ctx.GetStub().PutState(input.Id, packerAssetJSON)
 		if err != nil {
@@ -344,13 +425,243 @@ func (s *SmartContract) CreatePackerCsv(
 		fmt.Printf("Asset %s created successfully\n", input.Id)
 	}
 
-	eventPayloadJSON, err := json.Marshal(eventPayloads)
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ENTITYNAME, eventItems...)
+}
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to marshal asset JSON: %v", err)
+		return false, err
 	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
 
-	ctx.GetStub().SetEvent("batchCreatedPackerEvent", eventPayloadJSON)
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
 
-	return nil
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
 }
 
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionPacker{},
+	})
+}
+
+// SetRegistrationStatus moves a packer registration through its lifecycle:
+// PENDING to APPROVED or REJECTED, APPROVED to SUSPENDED and back. It may
+// only be called through SetRegistrationStatus in the nstda-staff chaincode,
+// which logs the action, by an administrator.
+func (s *SmartContract) SetRegistrationStatus(ctx contractapi.TransactionContextInterface, id string, status string, reason string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.SetRegistrationStatus(ctx, &asset.Registration, status, reason)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = asset.Registration.DecidedAt
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, before, asset)
+}
+
+// AdminOverride corrects fields of a packer record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionPacker{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
+}
+
+// AddPackerGmp registers another packing house for a packer. args is a
+// PackerGmp with a packingHouseRegisterNumber not yet on the packer; its
+// status defaults to ACTIVE. The packer's owner or an administrator may call it.
+func (s *SmartContract) AddPackerGmp(ctx contractapi.TransactionContextInterface, packerID string, args string) error {
+	entityGmp := entity.PackerGmp{}
+	inputInterface, err := issuer.Unmarshal(args, entityGmp)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.PackerGmp)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+	if input.PackingHouseRegisterNumber == "" {
+		return fmt.Errorf("packingHouseRegisterNumber is required")
+	}
+
+	asset, err := s.ReadAsset(ctx, packerID)
+	if err != nil {
+		return err
+	}
+	err = issuer.AssertOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	owner, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	input.Owner = owner
+	input.OrgName = orgName
+	input.UpdatedAt = now
+	input.CreatedAt = now
+
+	packerGmps, err := core.NormalizePackingHouses(packerID, append(asset.PackerGmps, *input))
+	if err != nil {
+		return err
+	}
+	return s.putPackerGmps(ctx, &before, asset, packerGmps, now)
+}
+
+// RemovePackerGmp removes a packing house from a packer. To keep it on record
+// but stop packing under it, set it INACTIVE with SetPackerGmpStatus instead.
+func (s *SmartContract) RemovePackerGmp(ctx contractapi.TransactionContextInterface, packerID string, registerNumber string) error {
+	asset, err := s.ReadAsset(ctx, packerID)
+	if err != nil {
+		return err
+	}
+	err = issuer.AssertOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	i := core.FindPackingHouse(asset.PackerGmps, registerNumber)
+	if i < 0 {
+		return fmt.Errorf("packer %s has no packing house %s", packerID, registerNumber)
+	}
+	packerGmps := append(append([]entity.PackerGmp{}, asset.PackerGmps[:i]...), asset.PackerGmps[i+1:]...)
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	return s.putPackerGmps(ctx, &before, asset, packerGmps, now)
+}
+
+// SetPackerGmpStatus marks one of a packer's packing houses ACTIVE or
+// INACTIVE. CreatePacking only accepts active packing houses.
+func (s *SmartContract) SetPackerGmpStatus(ctx contractapi.TransactionContextInterface, packerID string, registerNumber string, status string) error {
+	if status != entity.GMPACTIVE && status != entity.GMPINACTIVE {
+		return fmt.Errorf("status must be %s or %s", entity.GMPACTIVE, entity.GMPINACTIVE)
+	}
+
+	asset, err := s.ReadAsset(ctx, packerID)
+	if err != nil {
+		return err
+	}
+	err = issuer.AssertOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	i := core.FindPackingHouse(asset.PackerGmps, registerNumber)
+	if i < 0 {
+		return fmt.Errorf("packer %s has no packing house %s", packerID, registerNumber)
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	packerGmps := append([]entity.PackerGmp{}, asset.PackerGmps...)
+	packerGmps[i].Status = status
+	packerGmps[i].UpdatedAt = now
+
+	return s.putPackerGmps(ctx, &before, asset, packerGmps, now)
+}
+
+// GetPackingHouses lists a packer's GMP registrations, active or not.
+func (s *SmartContract) GetPackingHouses(ctx contractapi.TransactionContextInterface, packerID string) ([]entity.PackerGmp, error) {
+	asset, err := s.ReadAsset(ctx, packerID)
+	if err != nil {
+		return nil, err
+	}
+	return asset.PackerGmps, nil
+}
+
+func (s *SmartContract) putPackerGmps(ctx contractapi.TransactionContextInterface, before, asset *entity.TransectionPacker, packerGmps []entity.PackerGmp, now time.Time) error {
+	asset.PackerGmps = packerGmps
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(asset.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, asset.Id, *before, asset)
+}
@@ -0,0 +1,245 @@
+package packer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/entity"
+	packer "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packer/chaincode-go/smart-contract"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org2MSP", "bob", nil)
+)
+
+func newNetwork(t *testing.T) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&packer.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCPACKER, chaincode)
+	network.Deploy(issuer.CCNSTDASTAFF, issuertest.NewFake().
+		Forwards("SetRegistrationStatus", issuer.CCPACKER).
+		Forwards("AdminOverride", issuer.CCPACKER))
+	return network
+}
+
+// packingHouses lists a packer's packing houses as registerNumber:status.
+func packingHouses(t *testing.T, network *issuertest.Network, id string) string {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCPACKER, "GetPackingHouses", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gmps []entity.PackerGmp
+	if err := json.Unmarshal(payload, &gmps); err != nil {
+		t.Fatal(err)
+	}
+	houses := []string{}
+	for _, gmp := range gmps {
+		houses = append(houses, gmp.PackingHouseRegisterNumber+":"+gmp.Status)
+	}
+	return fmt.Sprint(houses)
+}
+
+func TestCreatePacker(t *testing.T) {
+	network := newNetwork(t)
+
+	tests := []struct {
+		name       string
+		args       string
+		wantID     string
+		wantHouses string
+		wantErr    bool
+	}{
+		{name: "allocated id", args: `{"userId":"U-1"}`, wantID: "PKR-2024-000001", wantHouses: "[]"},
+		{
+			name:       "several packing houses",
+			args:       `{"id":"P-1","packerGmps":[{"packingHouseRegisterNumber":"GMP-1"},{"packingHouseRegisterNumber":"GMP-2","status":"INACTIVE"}]}`,
+			wantID:     "P-1",
+			wantHouses: "[GMP-1:ACTIVE GMP-2:INACTIVE]",
+		},
+		{
+			name:       "legacy single packing house",
+			args:       `{"id":"P-2","packerGmp":{"packingHouseRegisterNumber":"GMP-3"}}`,
+			wantID:     "P-2",
+			wantHouses: "[GMP-3:ACTIVE]",
+		},
+		{name: "packing house listed twice", args: `{"id":"P-3","packerGmps":[{"packingHouseRegisterNumber":"GMP-1"},{"packingHouseRegisterNumber":"GMP-1"}]}`, wantErr: true},
+		{name: "unknown status", args: `{"id":"P-4","packerGmps":[{"packingHouseRegisterNumber":"GMP-1","status":"CLOSED"}]}`, wantErr: true},
+		{name: "existing id", args: `{"id":"P-1"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Submit(alice, issuer.CCPACKER, "CreatePacker", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("created %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != test.wantID {
+				t.Fatalf("id = %s, want %s", payload, test.wantID)
+			}
+			if got := packingHouses(t, network, test.wantID); got != test.wantHouses {
+				t.Fatalf("packing houses = %s, want %s", got, test.wantHouses)
+			}
+		})
+	}
+}
+
+func TestPackingHouses(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCPACKER, "CreatePacker", `{"id":"P-1","packerGmps":[{"packingHouseRegisterNumber":"GMP-1"}]}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		identity   *issuertest.Identity
+		function   string
+		args       []string
+		wantErr    bool
+		wantHouses string
+	}{
+		{name: "owner adds", identity: alice, function: "AddPackerGmp", args: []string{"P-1", `{"packingHouseRegisterNumber":"GMP-2"}`}, wantHouses: "[GMP-1:ACTIVE GMP-2:ACTIVE]"},
+		{name: "added twice", identity: alice, function: "AddPackerGmp", args: []string{"P-1", `{"packingHouseRegisterNumber":"GMP-2"}`}, wantErr: true, wantHouses: "[GMP-1:ACTIVE GMP-2:ACTIVE]"},
+		{name: "not the owner", identity: bob, function: "AddPackerGmp", args: []string{"P-1", `{"packingHouseRegisterNumber":"GMP-3"}`}, wantErr: true, wantHouses: "[GMP-1:ACTIVE GMP-2:ACTIVE]"},
+		{name: "deactivate", identity: alice, function: "SetPackerGmpStatus", args: []string{"P-1", "GMP-1", entity.GMPINACTIVE}, wantHouses: "[GMP-1:INACTIVE GMP-2:ACTIVE]"},
+		{name: "unknown status", identity: alice, function: "SetPackerGmpStatus", args: []string{"P-1", "GMP-1", "CLOSED"}, wantErr: true, wantHouses: "[GMP-1:INACTIVE GMP-2:ACTIVE]"},
+		{name: "admin removes", identity: admin, function: "RemovePackerGmp", args: []string{"P-1", "GMP-2"}, wantHouses: "[GMP-1:INACTIVE]"},
+		{name: "remove unknown", identity: alice, function: "RemovePackerGmp", args: []string{"P-1", "GMP-9"}, wantErr: true, wantHouses: "[GMP-1:INACTIVE]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCPACKER, test.function, test.args...)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := packingHouses(t, network, "P-1"); got != test.wantHouses {
+				t.Fatalf("packing houses = %s, want %s", got, test.wantHouses)
+			}
+		})
+	}
+}
+
+func TestMigratePacker(t *testing.T) {
+	network := newNetwork(t)
+	// A packer stored before packerGmps and schema versions.
+	stub := network.Stub(issuer.CCPACKER)
+	stub.Seed("P-1", []byte(`{"id":"P-1","owner":"`+alice.ClientID()+`","packerGmp":{"packingHouseRegisterNumber":"GMP-1","packingHouseName":"Suan Mamuang"}}`))
+
+	if got := packingHouses(t, network, "P-1"); got != "[GMP-1:ACTIVE]" {
+		t.Errorf("packing houses = %s, want [GMP-1:ACTIVE]", got)
+	}
+	payload, err := network.Submit(admin, issuer.CCPACKER, "MigrateBatch", "", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result issuer.MigrationResult
+	if err := json.Unmarshal(payload, &result); err != nil || result.Migrated != 1 || !result.Done {
+		t.Fatalf("MigrateBatch = %s, %v", payload, err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(stub.State("P-1"), &stored); err != nil {
+		t.Fatal(err)
+	}
+	gmps, _ := stored["packerGmps"].([]interface{})
+	if _, legacy := stored["packerGmp"]; legacy || len(gmps) != 1 || gmps[0].(map[string]interface{})["packerId"] != "P-1" {
+		t.Errorf("stored packer = %s", stub.State("P-1"))
+	}
+}
+
+func TestPackerRegistration(t *testing.T) {
+	network := newNetwork(t)
+	if _, err := network.Submit(alice, issuer.CCPACKER, "CreatePacker", `{"id":"P-1"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(alice, issuer.CCNSTDASTAFF, "SetRegistrationStatus", "P-1", issuer.REGAPPROVED, ""); err == nil {
+		t.Fatal("the owner approved its own registration")
+	}
+	if _, err := network.Submit(admin, issuer.CCPACKER, "SetRegistrationStatus", "P-1", issuer.REGAPPROVED, ""); err == nil {
+		t.Fatal("an administrator approved without going through nstda-staff")
+	}
+	if _, err := network.Submit(admin, issuer.CCPACKER, "AdminOverride", "P-1", `{"certId":"PKR-9"}`); err == nil {
+		t.Fatal("an administrator overrode without going through nstda-staff")
+	}
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "SetRegistrationStatus", "P-1", issuer.REGAPPROVED, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := network.Submit(admin, issuer.CCNSTDASTAFF, "AdminOverride", "P-1", `{"certId":"PKR-9"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCPACKER, "GetPackerById", "P-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response entity.TransectionPackerReponse
+	if err := json.Unmarshal(payload, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Registration.Status != issuer.REGAPPROVED || response.Registration.ApprovedAt.IsZero() || response.CertId != "PKR-9" {
+		t.Fatalf("registration = %+v", response.Registration)
+	}
+}
+
+func TestGetAllPacker(t *testing.T) {
+	network := newNetwork(t)
+	for _, args := range []string{`{"userId":"U-1"}`, `{"userId":"U-2"}`, `{"id":"P-1"}`} {
+		if _, err := network.Submit(alice, issuer.CCPACKER, "CreatePacker", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "all but the ID counter", args: `{"limit":0}`, want: "[P-1 PKR-2024-000001 PKR-2024-000002]", wantTotal: 3},
+		{name: "limit", args: `{"limit":2}`, want: "[P-1 PKR-2024-000001]", wantTotal: 3},
+		{name: "skip", args: `{"skip":2,"limit":0}`, want: "[PKR-2024-000002]", wantTotal: 3},
+		{name: "skip past the total", args: `{"skip":4,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCPACKER, "GetAllPacker", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllPackerReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("packers = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
@@ -4,73 +4,84 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/entity"
 )
 
-func SetFilter(input *entity.FilterGetAll) map[string]interface{} {
+func SetFilter(input *entity.FilterGetAllPacking) map[string]interface{} {
 	var filter = map[string]interface{}{}
 
-	if input.Gap != nil {
-		filter["gap"] = *input.Gap
+	if input.Gap != "" {
+		filter["gap"] = input.Gap
 	}
 
-	if input.CertID != nil {
-		filter["certId"] = *input.CertID
+	if input.CertID != "" {
+		filter["certId"] = input.CertID
 	}
 
-	if input.Search != nil {
-		filter["search"] = *input.Search
+	if input.Search != "" {
+		filter["search"] = input.Search
 	}
 
-	if (input.FarmerID != nil) {
-		filter["farmerId"] = *input.FarmerID
+	if input.FarmerID != "" {
+		filter["farmerId"] = input.FarmerID
 	}
 
-	if input.StartDate != nil && input.EndDate != nil {
-		filter["createdAt"] = map[string]interface{}{
-			"$gte": *input.StartDate,
-			"$lte": *input.EndDate,
+	if input.StartDate != "" || input.EndDate != "" {
+		createdAt := map[string]interface{}{"$gte": input.StartDate}
+		if input.EndDate != "" {
+			createdAt["$lte"] = input.EndDate
 		}
+		filter["createdAt"] = createdAt
 	}
 
-	if input.ForecastWeightFrom != nil && input.ForecastWeightTo != nil {
-		filter["forecastWeight"] = map[string]interface{}{
-			"$gte": *input.ForecastWeightFrom,
-			"$lte": *input.ForecastWeightTo,
+	if input.ForecastWeightFrom != 0 || input.ForecastWeightTo != 0 {
+		forecastWeight := map[string]interface{}{"$gte": input.ForecastWeightFrom}
+		if input.ForecastWeightTo != 0 {
+			forecastWeight["$lte"] = input.ForecastWeightTo
 		}
+		filter["forecastWeight"] = forecastWeight
 	}
 
-	if input.ProcessStatus != nil {
-		filter["processStatus"] = *input.ProcessStatus
+	if input.ProcessStatus != 0 {
+		filter["processStatus"] = input.ProcessStatus
 	}
 
-	return filter
+	return issuer.FilterAssetsOnly(filter)
 }
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll, filter map[string]interface{}) ([]*entity.TransectionReponse, error) {
+// SearchSelector turns the search entry SetFilter leaves in filter into a
+// regex match on gmp or packingHouseName. filter itself is not modified, so
+// the total and the page can be queried with the same selector.
+func SearchSelector(filter map[string]interface{}) map[string]interface{} {
 	search, searchExists := filter["search"]
 
-	if searchExists {
-		delete(filter, "search")
+	fields := map[string]interface{}{}
+	for key, value := range filter {
+		if key != "search" {
+			fields[key] = value
+		}
+	}
+	if !searchExists || search == "" {
+		return fields
 	}
 
-	// Initialize the base selector
-	selector := map[string]interface{}{
-		"selector": filter,
-	}
-
-	if searchExists && search != "" {
-		selector["selector"] = map[string]interface{}{
-			"$and": []map[string]interface{}{
-				filter,
-				{
-					"$or": []map[string]interface{}{
-						{"gmp": map[string]interface{}{"$regex": search}},
-						{"packingHouseName": map[string]interface{}{"$regex": search}},
-					},
+	return map[string]interface{}{
+		"$and": []map[string]interface{}{
+			fields,
+			{
+				"$or": []map[string]interface{}{
+					{"gmp": map[string]interface{}{"$regex": search}},
+					{"packingHouseName": map[string]interface{}{"$regex": search}},
 				},
 			},
-		}
+		},
+	}
+}
+
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllPacking, filter map[string]interface{}) ([]*entity.TransectionPackingReponse, error) {
+	selector := map[string]interface{}{
+		"selector": SearchSelector(filter),
 	}
 
 	if input.Skip != 0 || input.Limit != 0 {
@@ -89,15 +100,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryPacking.Close()
 
-	var dataPacking []*entity.TransectionReponse
+	var dataPacking []*entity.TransectionPackingReponse
 	for queryPacking.HasNext() {
 		queryResponse, err := queryPacking.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var asset entity.TransectionReponse
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		var asset entity.TransectionPackingReponse
+		err = Schema.Unmarshal(ctx, queryResponse.Value, &asset)
 		if err != nil {
 			return nil, err
 		}
@@ -107,3 +118,19 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 
 	return dataPacking, nil
 }
+
+// AggregateSpec is what AggregatePacking may group by, sum and filter on.
+var AggregateSpec = issuer.AggregateSpec{
+	GroupBy:    []string{"packerId", "packingHouseName", "gmp", "gap", "approvedType", "processStatus", "sellingStep", "orgName"},
+	Fields:     []string{"finalWeight", "forecastWeight", "actualWeight"},
+	DateFields: []string{"approvedDate", "savedTime", "createdAt", "updatedAt"},
+}
+
+// IsApproval reports whether an update approves, fully or partially, a
+// packing order that was not approved before.
+func IsApproval(before, after string) bool {
+	approved := func(approvedType string) bool {
+		return approvedType == "APPROVED" || approvedType == "PARTIAL"
+	}
+	return approved(after) && !approved(before)
+}
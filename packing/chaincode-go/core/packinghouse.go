@@ -0,0 +1,69 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Status of an active packing house in the packer chaincode.
+const PACKINGHOUSEACTIVE string = "ACTIVE"
+
+// packingHouse is the part of a packer's GMP registration a packing order
+// needs.
+type packingHouse struct {
+	PackingHouseRegisterNumber string `json:"packingHouseRegisterNumber"`
+	PackingHouseName           string `json:"packingHouseName"`
+	Status                     string `json:"status"`
+}
+
+// ResolvePackingHouse returns the name of the packing house named by gmp (its
+// GMP register number) or, without one, by name, and fails unless it is an
+// active registration of the packer. A name given next to gmp must match the
+// registered one.
+func ResolvePackingHouse(ctx contractapi.TransactionContextInterface, packerID, gmp, name string) (string, error) {
+	if gmp == "" && name == "" {
+		return "", fmt.Errorf("a packing order of packer %s needs its gmp or packingHouseName", packerID)
+	}
+
+	houses, err := getPackingHouses(ctx, packerID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, house := range houses {
+		if gmp != "" && house.PackingHouseRegisterNumber != gmp {
+			continue
+		}
+		if gmp == "" && house.PackingHouseName != name {
+			continue
+		}
+		if house.Status != PACKINGHOUSEACTIVE {
+			return "", fmt.Errorf("packing house %s of packer %s is %s", house.PackingHouseRegisterNumber, packerID, house.Status)
+		}
+		if name != "" && house.PackingHouseName != name {
+			return "", fmt.Errorf("packing house %s of packer %s is %q, not %q", gmp, packerID, house.PackingHouseName, name)
+		}
+		return house.PackingHouseName, nil
+	}
+	if gmp != "" {
+		return "", fmt.Errorf("packing house %s is not registered to packer %s", gmp, packerID)
+	}
+	return "", fmt.Errorf("packing house %q is not registered to packer %s", name, packerID)
+}
+
+// getPackingHouses reads a packer's GMP registrations from the packer
+// chaincode.
+func getPackingHouses(ctx contractapi.TransactionContextInterface, packerID string) ([]packingHouse, error) {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCPACKER, "GetPackingHouses", packerID)
+	if err != nil {
+		return nil, err
+	}
+	var houses []packingHouse
+	if err := json.Unmarshal(payload, &houses); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return houses, nil
+}
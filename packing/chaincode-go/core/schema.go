@@ -0,0 +1,34 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Schema upgrades stored packings to the current entity.TransectionPacking.
+var Schema = issuer.NewSchema().
+	// 1: packingHouseName was added; older packings only named the packing
+	// house by its GMP register number, so the name is looked up from the
+	// packer. A packer that cannot be read leaves the name empty rather than
+	// failing the read.
+	Register(1, func(ctx contractapi.TransactionContextInterface, record map[string]interface{}) error {
+		if name, _ := record["packingHouseName"].(string); name != "" {
+			return nil
+		}
+		record["packingHouseName"] = ""
+		packerID, _ := record["packerId"].(string)
+		gmp, _ := record["gmp"].(string)
+		if packerID == "" || gmp == "" {
+			return nil
+		}
+		houses, err := getPackingHouses(ctx, packerID)
+		if err != nil {
+			return nil
+		}
+		for _, house := range houses {
+			if house.PackingHouseRegisterNumber == gmp {
+				record["packingHouseName"] = house.PackingHouseName
+			}
+		}
+		return nil
+	})
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+// Rounding slack when comparing float32 weight totals, in kilograms.
+const WEIGHTEPSILON float32 = 0.001
+
+// shippedWeight is the part of the exporter chaincode's running total of a
+// packing order a weight change needs.
+type shippedWeight struct {
+	PackingID     string  `json:"packingId"`
+	ShippedWeight float32 `json:"shippedWeight"`
+}
+
+// AssertShippedWeight fails when packing order id would end up with a final
+// weight below what export shipments already took from it.
+func AssertShippedWeight(ctx contractapi.TransactionContextInterface, id string, finalWeight float32) error {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCEXPORTER, "GetShippedWeight", id)
+	if err != nil {
+		return err
+	}
+	var shipped shippedWeight
+	if err := json.Unmarshal(payload, &shipped); err != nil {
+		return fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+
+	if finalWeight+WEIGHTEPSILON < shipped.ShippedWeight {
+		return fmt.Errorf("packing order %s: final weight %.3f is below the %.3f already shipped", id, finalWeight, shipped.ShippedWeight)
+	}
+	return nil
+}
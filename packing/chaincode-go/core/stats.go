@@ -0,0 +1,32 @@
+package core
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/entity"
+)
+
+// STATPACKERMONTH counts packing orders and sums their weights per packer
+// and month of creation.
+const STATPACKERMONTH string = "packingByPackerMonth"
+
+func AddStats(stats *issuer.Stats, packing *entity.TransectionPacking, count int) {
+	stats.Add(STATPACKERMONTH, []string{packing.PackerId, packing.CreatedAt.Format("2006-01")}, count, map[string]float64{
+		"forecastWeight": float64(packing.ForecastWeight) * float64(count),
+		"actualWeight":   float64(packing.ActualWeight) * float64(count),
+		"finalWeight":    float64(packing.FinalWeight) * float64(count),
+	})
+}
+
+// UpdateStats moves one record's contribution from before to after; either
+// may be nil for a create or delete.
+func UpdateStats(ctx contractapi.TransactionContextInterface, before, after *entity.TransectionPacking) error {
+	stats := issuer.NewStats()
+	if before != nil {
+		AddStats(stats, before, -1)
+	}
+	if after != nil {
+		AddStats(stats, after, 1)
+	}
+	return stats.Flush(ctx)
+}
@@ -2,41 +2,49 @@ package entity
 
 import "time"
 
+const ENTITYNAME string = "packing"
+
 type TransectionPacking struct {
-	Id             string    `json:"id"`
-	OrderID        string    `json:"orderId"`
-	FarmerID       string    `json:"farmerId"`
-	ForecastWeight float32   `json:"forecastWeight"`
-	ActualWeight   float32   `json:"actualWeight"`
-	SavedTime      string    `json:"savedTime"`
-	ApprovedDate   string    `json:"approvedDate"`
-	ApprovedType   string    `json:"approvedType"`
-	FinalWeight    float32   `json:"finalWeight"`
-	Remark         string    `json:"remark"`
-	PackerId       string    `json:"packerId"`
-	Gmp            string    `json:"gmp"`
-	PackingHouseName            string    `json:"packingHouseName"`
-	Gap            string    `json:"gap"` // รหัสซื้อขาย
-	ProcessStatus  int       `json:"processStatus"`
-	SellingStep				   int       `json:"sellingStep"`
+	Id             string    `json:"id" validate:"required,maxlen=128"`
+	OrderID        string    `json:"orderId" validate:"maxlen=128"`
+	FarmerID       string    `json:"farmerId" validate:"maxlen=128"`
+	ForecastWeight float32   `json:"forecastWeight" validate:"min=0"`
+	ActualWeight   float32   `json:"actualWeight" validate:"min=0"`
+	SavedTime      string    `json:"savedTime" validate:"date"`
+	ApprovedDate   string    `json:"approvedDate" validate:"date"`
+	ApprovedType   string    `json:"approvedType" validate:"enum=APPROVED|PARTIAL|REJECTED"`
+	FinalWeight    float32   `json:"finalWeight" validate:"min=0"`
+	Remark         string    `json:"remark" validate:"maxlen=1024"`
+	PackerId       string    `json:"packerId" validate:"required,maxlen=128"`
+	Gmp            string    `json:"gmp" validate:"maxlen=128"`
+	PackingHouseName            string    `json:"packingHouseName" validate:"maxlen=256"`
+	Gap            string    `json:"gap" validate:"maxlen=128"` // รหัสซื้อขาย
+	ProcessStatus  int       `json:"processStatus" validate:"min=0"`
+	SellingStep				   int       `json:"sellingStep" validate:"min=0"`
 	Owner          string    `json:"owner"`
 	OrgName        string    `json:"orgName"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 	CreatedAt      time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip               int      `json:"skip"`
-	Limit              int      `json:"limit"`
-	Search             *string  `json:"search"`
-	PackerId           *string  `json:"packerId"`
-	FarmerID					 *string  `json:"farmerId"` 
-	CertID					 	 *string  `json:"certId"` 
-	Gap                *string  `json:"gap"`
-	StartDate          *string  `json:"startDate"`
-	EndDate            *string  `json:"endDate"`
-	PackingHouseName            string    `json:"packingHouseName"`
-	ForecastWeightFrom *float32 `json:"forecastWeightFrom"`
-	ForecastWeightTo   *float32 `json:"forecastWeightTo"`
-	ProcessStatus      *int     `json:"processStatus"`
+// FilterGetAllPacking is the GetAllPacking parameter. Only limit is
+// required. Empty and zero fields are not filtered on, so processStatus 0
+// cannot be selected; a range applies once either bound is set.
+type FilterGetAllPacking struct {
+	Skip               int     `json:"skip" metadata:",optional"`
+	Limit              int     `json:"limit"`
+	Search             string  `json:"search" metadata:",optional"`
+	PackerId           string  `json:"packerId" metadata:",optional"`
+	FarmerID           string  `json:"farmerId" metadata:",optional"`
+	CertID             string  `json:"certId" metadata:",optional"`
+	Gap                string  `json:"gap" metadata:",optional"`
+	StartDate          string  `json:"startDate" metadata:",optional"`
+	EndDate            string  `json:"endDate" metadata:",optional"`
+	PackingHouseName   string  `json:"packingHouseName" metadata:",optional"`
+	ForecastWeightFrom float32 `json:"forecastWeightFrom" metadata:",optional"`
+	ForecastWeightTo   float32 `json:"forecastWeightTo" metadata:",optional"`
+	ProcessStatus      int     `json:"processStatus" metadata:",optional"`
 }
@@ -2,7 +2,7 @@ package entity
 
 import "time"
 
-type TransectionReponse struct {
+type TransectionPackingReponse struct {
 	Id             string  `json:"id"`
 	OrderID        string  `json:"orderId"`
 	FarmerID       string  `json:"farmerId"`
@@ -25,15 +25,15 @@ type TransectionReponse struct {
 	CreatedAt     time.Time `json:"createdAt"`
 }
 
-type GetAllReponse struct {
-	Data  string                `json:"data"`
-	Obj   []*TransectionReponse `json:"obj"`
-	Total int                   `json:"total"`
+type GetAllPackingReponse struct {
+	Data  string                       `json:"data"`
+	Obj   []*TransectionPackingReponse `json:"obj"`
+	Total int                          `json:"total"`
 }
 
 type TransactionHistory struct {
-	TxId      string                `json:"tx_id"`
-	IsDelete  bool                  `json:"isDelete"`
-	Value     []*TransectionReponse `json:"value"`
-	Timestamp string                `json:"timestamp"`
+	TxId      string                       `json:"tx_id"`
+	IsDelete  bool                         `json:"isDelete"`
+	Value     []*TransectionPackingReponse `json:"value"`
+	Timestamp string                       `json:"timestamp"`
 }
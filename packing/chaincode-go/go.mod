@@ -33,3 +33,6 @@ require (
 	google.golang.org/protobuf v1.28.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
+
+// The chaincode is built against the issuer package in this tree.
+replace github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer => ../../internal/issuer
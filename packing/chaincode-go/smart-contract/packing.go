@@ -16,28 +16,85 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllPacking",
+		"FilterPacking",
+		"GetHistoryForKey",
+		"GetLatestHistoryForKey",
+		"AggregatePacking",
+		"GetStatistics",
+		"GetTransfer",
+		"GetRegulatoryStatus",
+		"GetEndorsementPolicy",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
+// CreatePacking creates a packing order and returns its ID. Without an id in args the
+// ID is allocated with issuer.NextID. The order is filed under an approved
+// packer the client owns; its farmer, if any, must be approved too.
 func (s *SmartContract) CreatePacking(
 	ctx contractapi.TransactionContextInterface,
 	args string,
-) error {
+) (string, error) {
 	entityPacking := entity.TransectionPacking{}
 	inputInterface, err := issuer.Unmarshal(args, entityPacking)
-	issuer.HandleError(err)
+	if err != nil {
+		return "", err
+	}
 	input := inputInterface.(*entity.TransectionPacking)
 
+	if input.Id == "" {
+		input.Id, err = issuer.NextID(ctx, issuer.IDPREFIXPACKING)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return "", err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("packing.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return fmt.Errorf("submitting client not authorized to create asset, does not have packing.creator role")
+		return "", fmt.Errorf("submitting client not authorized to create asset, does not have packing.creator role")
+	}
+
+	err = issuer.AssertApprovedOwner(ctx, issuer.CCPACKER, input.PackerId)
+	if err != nil {
+		return "", err
+	}
+	err = issuer.AssertApproved(ctx, issuer.CCFARMER, input.FarmerID)
+	if err != nil {
+		return "", err
+	}
+	packingHouseName, err := core.ResolvePackingHouse(ctx, input.PackerId, input.Gmp, input.PackingHouseName)
+	if err != nil {
+		return "", err
+	}
+	err = issuer.AssertCertificateActive(ctx, issuer.TARGETGAP, input.Gap)
+	if err != nil {
+		return "", err
+	}
+	err = issuer.AssertCertificateActive(ctx, issuer.TARGETGMP, input.Gmp)
+	if err != nil {
+		return "", err
 	}
 
 	existsPacking, err := issuer.AssetExists(ctx, input.Id)
 	issuer.HandleError(err)
 	if existsPacking {
-		return fmt.Errorf("the asset %s already exists", input.Id)
+		return "", fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientIDPacking, err := issuer.GetIdentity(ctx)
+	clientIDPacking, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	TimePacking := issuer.GetTimeNow()
@@ -47,7 +104,7 @@ func (s *SmartContract) CreatePacking(
 		Id:             input.Id,
 		OrderID:        input.OrderID,
 		FarmerID:       input.FarmerID,
-		PackingHouseName: input.PackingHouseName,
+		PackingHouseName: packingHouseName,
 		ForecastWeight: input.ForecastWeight,
 		ActualWeight:   input.ActualWeight,
 		SavedTime:      input.SavedTime,
@@ -64,11 +121,31 @@ func (s *SmartContract) CreatePacking(
 		OrgName:        orgName,
 		UpdatedAt:      TimePacking,
 		CreatedAt:      TimePacking,
+		SchemaVersion:  core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return "", err
+	}
+
+	err = core.UpdateStats(ctx, nil, &asset)
+	if err != nil {
+		return "", err
+	}
+
+	err = issuer.SetOwnerEndorsement(ctx, input.Id)
+	if err != nil {
+		return "", err
+	}
+
+	err = issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
+	if err != nil {
+		return "", err
+	}
+	return input.Id, nil
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
@@ -76,11 +153,62 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 
 	entityPacking := entity.TransectionPacking{}
 	inputInterface, err := issuer.Unmarshal(args, entityPacking)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionPacking)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
+	before := *asset
+
+	err = issuer.AssertApprovedOwner(ctx, issuer.CCPACKER, input.PackerId)
+	if err != nil {
+		return err
+	}
+	if input.FarmerID != before.FarmerID {
+		err = issuer.AssertApproved(ctx, issuer.CCFARMER, input.FarmerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if core.IsApproval(before.ApprovedType, input.ApprovedType) {
+		err = issuer.AssertInspectionPassed(ctx, issuer.TARGETPACKING, input.Id)
+		if err != nil {
+			return err
+		}
+		err = issuer.AssertCertificateActive(ctx, issuer.TARGETGAP, input.Gap)
+		if err != nil {
+			return err
+		}
+		err = issuer.AssertCertificateActive(ctx, issuer.TARGETGMP, input.Gmp)
+		if err != nil {
+			return err
+		}
+	}
+
+	packingHouseName := before.PackingHouseName
+	if input.PackerId != before.PackerId || input.Gmp != before.Gmp || input.PackingHouseName != before.PackingHouseName {
+		packingHouseName, err = core.ResolvePackingHouse(ctx, input.PackerId, input.Gmp, input.PackingHouseName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if input.FinalWeight < before.FinalWeight {
+		err = core.AssertShippedWeight(ctx, input.Id, input.FinalWeight)
+		if err != nil {
+			return err
+		}
+	}
 
 	UpdatedPacking := issuer.GetTimeNow()
 
@@ -96,6 +224,7 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	asset.Remark = input.Remark
 	asset.PackerId = input.PackerId // not update
 	asset.Gmp = input.Gmp
+	asset.PackingHouseName = packingHouseName
 	asset.Gap = input.Gap
 	asset.ProcessStatus = input.ProcessStatus
 	asset.SellingStep = input.SellingStep
@@ -104,9 +233,17 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	assetJSON, errPacking := json.Marshal(asset)
 	issuer.HandleError(errPacking)
 
-	ctx.GetStub().SetEvent("UpdateAsset", assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	err = core.UpdateStats(ctx, &before, asset)
+	if err != nil {
+		return err
+	}
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
@@ -116,32 +253,80 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 
-	clientIDPacking, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetPacking.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDPacking != assetPacking.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	err = core.UpdateStats(ctx, assetPacking, nil)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetPacking)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetPacking, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetPacking.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
+
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetPacking, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	if clientID != assetPacking.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetPacking.Owner)
+	if err != nil {
+		return err
 	}
 
-	assetPacking.Owner = newOwner
+	assetPacking.Owner = transfer.AcceptedBy
+	assetPacking.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetPacking)
-	issuer.HandleError(err)
-	return ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	// From now on the new owner's org endorses changes instead of the old one.
+	return issuer.SetOwnerEndorsement(ctx, id)
+}
+
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
 }
 
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionPacking, error) {
@@ -155,24 +340,19 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionPacking
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
 
 	return &asset, nil
 }
-func (s *SmartContract) GetAllPacking(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllPacking(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllPacking) (*entity.GetAllPackingReponse, error) {
 
-	entityGetAllPacking := entity.FilterGetAll{}
-	interfacePacking, err := issuer.Unmarshal(args, entityGetAllPacking)
-	if err != nil {
-		return nil, err
-	}
-	inputPacking := interfacePacking.(*entity.FilterGetAll)
+	inputPacking := &input
 	filterPacking := core.SetFilter(inputPacking)
 
-	queryStringPacking, err := issuer.BuildQueryString(filterPacking)
+	queryStringPacking, err := issuer.BuildQueryString(core.SearchSelector(filterPacking))
 	if err != nil {
 		return nil, err
 	}
@@ -196,10 +376,10 @@ func (s *SmartContract) GetAllPacking(ctx contractapi.TransactionContextInterfac
 	})
 
 	if len(arrPacking) == 0 {
-		arrPacking = []*entity.TransectionReponse{}
+		arrPacking = []*entity.TransectionPackingReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllPackingReponse{
 		Data:  "All Packing",
 		Obj:   arrPacking,
 		Total: total,
@@ -220,14 +400,19 @@ func (s *SmartContract) FilterPacking(ctx contractapi.TransactionContextInterfac
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var asset entity.TransectionPacking
-		err = json.Unmarshal(queryResponse.Value, &asset)
+		err = json.Unmarshal(record, &asset)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -251,7 +436,7 @@ func (s *SmartContract) GetLatestHistoryForKey(ctx contractapi.TransactionContex
 	defer resultsIterator.Close()
 
 	var latestHistory *entity.TransactionHistory
-	var packingAsset []*entity.TransectionReponse
+	var packingAsset []*entity.TransectionPackingReponse
 
 	for resultsIterator.HasNext() {
 		// Get the next history record
@@ -260,7 +445,7 @@ func (s *SmartContract) GetLatestHistoryForKey(ctx contractapi.TransactionContex
 			return nil, fmt.Errorf("failed to get next history record for key %s: %v", key, err)
 		}
 
-		var asset entity.TransectionReponse
+		var asset entity.TransectionPackingReponse
 		if !record.IsDelete {
 			err = json.Unmarshal(record.Value, &asset)
 			if err != nil {
@@ -268,7 +453,7 @@ func (s *SmartContract) GetLatestHistoryForKey(ctx contractapi.TransactionContex
 			}
 			packingAsset = append(packingAsset, &asset)
 		} else {
-			packingAsset = []*entity.TransectionReponse{}
+			packingAsset = []*entity.TransectionPackingReponse{}
 		}
 
 		// Sort packingAsset by SellingStep
@@ -304,7 +489,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 	defer resultsIterator.Close()
 
 	var history []*entity.TransactionHistory
-	var assetsValue []*entity.TransectionReponse
+	var assetsValue []*entity.TransectionPackingReponse
 
 	for resultsIterator.HasNext() {
 		// Get the next history record
@@ -313,7 +498,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 			return nil, fmt.Errorf("failed to get next history record for key %s: %v", key, err)
 		}
 
-		var asset entity.TransectionReponse
+		var asset entity.TransectionPackingReponse
 		if !record.IsDelete {
 			err = json.Unmarshal(record.Value, &asset)
 			if err != nil {
@@ -322,7 +507,7 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 			assetsValue = append(assetsValue, &asset)
 
 		} else {
-			assetsValue = []*entity.TransectionReponse{}
+			assetsValue = []*entity.TransectionPackingReponse{}
 		}
 		// Convert the timestamp to string in the desired format
 		timestampStr := time.Unix(record.Timestamp.Seconds, int64(record.Timestamp.Nanos)).Format(issuer.TIMEFORMAT)
@@ -339,3 +524,128 @@ func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInter
 
 	return history, nil
 }
+
+// AggregatePacking groups records for dashboards and totals, counts and averages
+// the requested fields, e.g. {"groupBy":["packerId","createdAt:month"],"fields":["finalWeight"]}.
+// It reads one bounded page per call; repeat with the returned bookmark and
+// groups until done.
+func (s *SmartContract) AggregatePacking(ctx contractapi.TransactionContextInterface, args string) (*issuer.AggregateResult, error) {
+	entityQuery := issuer.AggregateQuery{}
+	inputInterface, err := issuer.Unmarshal(args, entityQuery)
+	if err != nil {
+		return nil, err
+	}
+	input := inputInterface.(*issuer.AggregateQuery)
+
+	return issuer.Aggregate(ctx, core.AggregateSpec, input)
+}
+
+// GetStatistics sums the running counters of a statistic, e.g.
+// core.STATPACKERMONTH ("packingByPackerMonth"), per group.
+func (s *SmartContract) GetStatistics(ctx contractapi.TransactionContextInterface, name string) ([]*issuer.Statistic, error) {
+	return issuer.GetStatistics(ctx, name)
+}
+
+// CompactStatistics folds the delta rows of a statistic into one row per
+// group and announces the result as a stat.compacted event. Only
+// administrators may call it.
+func (s *SmartContract) CompactStatistics(ctx contractapi.TransactionContextInterface, name string) error {
+	err := issuer.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	statistics, err := issuer.CompactStatistics(ctx, name)
+	if err != nil {
+		return err
+	}
+	item, err := issuer.EventPayload(name, statistics)
+	if err != nil {
+		return err
+	}
+	return issuer.EmitEvent(ctx, issuer.EVENTCOMPACTED, issuer.STATDOCTYPE, item)
+}
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetEndorsementPolicy lists the orgs that must endorse changes to an asset:
+// the owner's org and the regulator.
+func (s *SmartContract) GetEndorsementPolicy(ctx contractapi.TransactionContextInterface, id string) (*issuer.EndorsementPolicy, error) {
+	return issuer.GetAssetEndorsement(ctx, id)
+}
+
+// GetRegulatoryStatus returns the regulator's status of a packing order,
+// including its latest inspection.
+func (s *SmartContract) GetRegulatoryStatus(ctx contractapi.TransactionContextInterface, id string) (*issuer.RegulatoryStatus, error) {
+	return issuer.GetRegulatoryStatus(ctx, issuer.TARGETPACKING, id)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME: entity.TransectionPacking{},
+	})
+}
+
+// AdminOverride corrects fields of a packing record with patch, a JSON object
+// of field values. It may only be called through AdminOverride in the
+// nstda-staff chaincode, which logs it with a reason, by an administrator.
+func (s *SmartContract) AdminOverride(ctx contractapi.TransactionContextInterface, id string, patch string) error {
+	err := issuer.AssertCalledThrough(ctx, issuer.CCNSTDASTAFF)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := &entity.TransectionPacking{}
+	err = issuer.ApplyOverride(ctx, core.Schema, id, patch, asset)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.UpdatedAt = now
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	err = core.UpdateStats(ctx, before, asset)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, id, *before, asset)
+}
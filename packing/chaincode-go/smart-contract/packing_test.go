@@ -0,0 +1,339 @@
+package packing_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/entity"
+	packing "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/smart-contract"
+)
+
+var (
+	admin = issuertest.NewIdentity("Org1MSP", "admin", map[string]string{issuer.ADMINROLE: "true"})
+	alice = issuertest.NewIdentity("Org1MSP", "alice", nil)
+	bob   = issuertest.NewIdentity("Org1MSP", "bob", nil)
+)
+
+// newNetwork deploys the packing chaincode next to a packer chaincode holding
+// alice's approved packer P-1 and pending packer P-2, a farmer chaincode
+// holding an approved farmer F-1 and a pending farmer F-2, a regulator reporting
+// statuses, keyed by target ID, with ACTIVE as the default, and an exporter
+// that shipped 60 kg from K-1.
+func newNetwork(t *testing.T, statuses map[string]issuer.RegulatoryStatus) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&packing.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCPACKING, chaincode)
+	network.Deploy(issuer.CCPACKER, issuertest.NewFake().
+		On("ReadAsset", func(args []string) ([]byte, error) {
+			status := map[string]string{"P-1": issuer.REGAPPROVED, "P-2": issuer.REGPENDING}[args[0]]
+			return json.Marshal(map[string]interface{}{"id": args[0], "owner": alice.ClientID(), "registration": map[string]string{"status": status}})
+		}).
+		Returns("GetPackingHouses", []map[string]string{
+			{"packingHouseRegisterNumber": "GMP-1", "packingHouseName": "Suan Mamuang", "status": "ACTIVE"},
+			{"packingHouseRegisterNumber": "GMP-2", "packingHouseName": "Old House", "status": "INACTIVE"},
+			{"packingHouseRegisterNumber": "GMP-3", "packingHouseName": "Ban Rai", "status": "ACTIVE"},
+		}))
+	network.Deploy(issuer.CCFARMER, issuertest.NewFake().
+		On("ReadAsset", func(args []string) ([]byte, error) {
+			status := map[string]string{"F-1": issuer.REGAPPROVED, "F-2": issuer.REGPENDING}[args[0]]
+			return json.Marshal(map[string]interface{}{"id": args[0], "registration": map[string]string{"status": status}})
+		}))
+	network.Deploy(issuer.CCREGULATOR, issuertest.NewFake().
+		On("GetRegulatoryStatus", func(args []string) ([]byte, error) {
+			status, ok := statuses[args[1]]
+			if !ok {
+				status = issuer.RegulatoryStatus{Status: issuer.CERTACTIVE}
+			}
+			status.TargetType, status.TargetID = args[0], args[1]
+			return json.Marshal(status)
+		}))
+	network.Deploy(issuer.CCEXPORTER, issuertest.NewFake().
+		On("GetShippedWeight", func(args []string) ([]byte, error) {
+			shipped := map[string]float32{"K-1": 60}[args[0]]
+			return json.Marshal(map[string]interface{}{"packingId": args[0], "shippedWeight": shipped})
+		}))
+	return network
+}
+
+func readPacking(t *testing.T, network *issuertest.Network, id string) *entity.TransectionPacking {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCPACKING, "ReadAsset", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionPacking
+	if err := json.Unmarshal(payload, &asset); err != nil {
+		t.Fatal(err)
+	}
+	return &asset
+}
+
+func TestCreatePacking(t *testing.T) {
+	network := newNetwork(t, map[string]issuer.RegulatoryStatus{
+		"GAP-SUSPENDED": {Status: issuer.CERTSUSPENDED},
+	})
+
+	tests := []struct {
+		name    string
+		args    string
+		wantID  string
+		wantErr bool
+	}{
+		{name: "allocated id", args: `{"orderId":"O-1","packerId":"P-1","gmp":"GMP-1","forecastWeight":100}`, wantID: "PKG-2024-000001"},
+		{name: "approved packer and active packing house", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-1","gap":"GAP-1"}`, wantID: "K-1"},
+		{name: "packing house by name", args: `{"id":"K-2","packerId":"P-1","packingHouseName":"Suan Mamuang"}`, wantID: "K-2"},
+		{name: "pending packer", args: `{"id":"K-3","packerId":"P-2","gmp":"GMP-1"}`, wantErr: true},
+		{name: "inactive packing house", args: `{"id":"K-4","packerId":"P-1","gmp":"GMP-2"}`, wantErr: true},
+		{name: "packing house name of another gmp", args: `{"id":"K-4","packerId":"P-1","gmp":"GMP-1","packingHouseName":"Ban Rai"}`, wantErr: true},
+		{name: "packing house of another packer", args: `{"id":"K-5","packerId":"P-1","gmp":"GMP-9"}`, wantErr: true},
+		{name: "packer without packing house", args: `{"id":"K-6","packerId":"P-1"}`, wantErr: true},
+		{name: "suspended GAP", args: `{"id":"K-7","packerId":"P-1","gmp":"GMP-1","gap":"GAP-SUSPENDED"}`, wantErr: true},
+		{name: "approved farmer", args: `{"id":"K-8","packerId":"P-1","gmp":"GMP-1","farmerId":"F-1"}`, wantID: "K-8"},
+		{name: "pending farmer", args: `{"id":"K-9","packerId":"P-1","gmp":"GMP-1","farmerId":"F-2"}`, wantErr: true},
+		{name: "no packer", args: `{"id":"K-11","gmp":"GMP-1"}`, wantErr: true},
+		{name: "negative weight", args: `{"id":"K-12","packerId":"P-1","gmp":"GMP-1","forecastWeight":-1}`, wantErr: true},
+		{name: "existing id", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-1"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Submit(alice, issuer.CCPACKING, "CreatePacking", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("created %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != test.wantID {
+				t.Fatalf("id = %s, want %s", payload, test.wantID)
+			}
+		})
+	}
+
+	if asset := readPacking(t, network, "K-1"); asset.PackingHouseName != "Suan Mamuang" {
+		t.Fatalf("packingHouseName = %q, want the name registered for GMP-1", asset.PackingHouseName)
+	}
+	if payload, err := network.Submit(bob, issuer.CCPACKING, "CreatePacking", `{"id":"K-10","packerId":"P-1","gmp":"GMP-1"}`); err == nil {
+		t.Fatalf("bob created %s under alice's packer", payload)
+	}
+	if _, err := network.Submit(bob, issuer.CCPACKING, "UpdateAsset", `{"id":"K-1","packerId":"P-1","gmp":"GMP-1","forecastWeight":10}`); err == nil {
+		t.Fatal("bob updated a packing under alice's packer")
+	}
+}
+
+func TestApprovePacking(t *testing.T) {
+	network := newNetwork(t, map[string]issuer.RegulatoryStatus{
+		"K-FAILED": {Status: issuer.CERTACTIVE, LastInspectionID: "I-1", LastInspectionResult: issuer.INSPECTIONFAIL},
+	})
+	for _, args := range []string{
+		`{"id":"K-1","packerId":"P-1","gmp":"GMP-1","forecastWeight":100}`,
+		`{"id":"K-FAILED","packerId":"P-1","gmp":"GMP-1","forecastWeight":100}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCPACKING, "CreatePacking", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{name: "passed inspection", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-1","approvedType":"APPROVED","finalWeight":95}`},
+		{name: "failed inspection", args: `{"id":"K-FAILED","packerId":"P-1","gmp":"GMP-1","approvedType":"PARTIAL","finalWeight":50}`, wantErr: true},
+		{name: "unknown approval type", args: `{"id":"K-1","packerId":"P-1","approvedType":"MAYBE"}`, wantErr: true},
+		{name: "pending farmer", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-1","farmerId":"F-2","approvedType":"APPROVED","finalWeight":95}`, wantErr: true},
+		{name: "pending packer", args: `{"id":"K-1","packerId":"P-2","approvedType":"APPROVED"}`, wantErr: true},
+		{name: "inactive packing house", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-2","approvedType":"APPROVED","finalWeight":95}`, wantErr: true},
+		{name: "unregistered packing house", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-9","approvedType":"APPROVED","finalWeight":95}`, wantErr: true},
+		{name: "final weight below shipped", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-1","approvedType":"APPROVED","finalWeight":50}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCPACKING, "UpdateAsset", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCPACKING, "GetHistoryForKey", "K-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history []entity.TransactionHistory
+	if err := json.Unmarshal(payload, &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(history))
+	}
+	if latest := history[0].Value[0]; latest.ApprovedType != "APPROVED" || latest.FinalWeight != 95 {
+		t.Fatalf("latest value = %+v", latest)
+	}
+}
+
+func TestUpdatePackingHouse(t *testing.T) {
+	network := newNetwork(t, nil)
+	if _, err := network.Submit(alice, issuer.CCPACKING, "CreatePacking", `{"id":"K-1","packerId":"P-1","gmp":"GMP-1"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    string
+		want    string
+		wantErr bool
+	}{
+		{name: "another gmp", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-3"}`, want: "Ban Rai"},
+		{name: "name of another gmp", args: `{"id":"K-1","packerId":"P-1","gmp":"GMP-3","packingHouseName":"Suan Mamuang"}`, want: "Ban Rai", wantErr: true},
+		{name: "unregistered name", args: `{"id":"K-1","packerId":"P-1","packingHouseName":"Elsewhere"}`, want: "Ban Rai", wantErr: true},
+		{name: "by name", args: `{"id":"K-1","packerId":"P-1","packingHouseName":"Suan Mamuang"}`, want: "Suan Mamuang"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(alice, issuer.CCPACKING, "UpdateAsset", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := readPacking(t, network, "K-1").PackingHouseName; got != test.want {
+				t.Fatalf("packingHouseName = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMigratePacking(t *testing.T) {
+	network := newNetwork(t, nil)
+	// Packings stored before packingHouseName and schema versions.
+	stub := network.Stub(issuer.CCPACKING)
+	stub.Seed("K-1", []byte(`{"id":"K-1","packerId":"P-1","gmp":"GMP-1"}`))
+	stub.Seed("K-2", []byte(`{"id":"K-2","packerId":"P-1","gmp":"GMP-9"}`))
+
+	payload, err := network.Evaluate(alice, issuer.CCPACKING, "ReadAsset", "K-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asset entity.TransectionPacking
+	if err := json.Unmarshal(payload, &asset); err != nil || asset.PackingHouseName != "Suan Mamuang" {
+		t.Fatalf("ReadAsset = %s, %v", payload, err)
+	}
+
+	if _, err := network.Submit(alice, issuer.CCPACKING, "MigrateBatch", "", "10"); err == nil {
+		t.Fatal("a member migrated packings")
+	}
+	payload, err = network.Submit(admin, issuer.CCPACKING, "MigrateBatch", "", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result issuer.MigrationResult
+	if err := json.Unmarshal(payload, &result); err != nil || result.Migrated != 2 || !result.Done {
+		t.Fatalf("MigrateBatch = %s, %v", payload, err)
+	}
+	for id, want := range map[string]string{"K-1": "Suan Mamuang", "K-2": ""} {
+		var stored entity.TransectionPacking
+		if err := json.Unmarshal(stub.State(id), &stored); err != nil || stored.PackingHouseName != want || stored.SchemaVersion != 1 {
+			t.Errorf("stored packing = %s, %v", stub.State(id), err)
+		}
+	}
+}
+
+func TestCompactStatistics(t *testing.T) {
+	network := newNetwork(t, nil)
+	for _, args := range []string{
+		`{"id":"K-1","packerId":"P-1","gmp":"GMP-1","forecastWeight":100}`,
+		`{"id":"K-2","packerId":"P-1","gmp":"GMP-1","forecastWeight":50}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCPACKING, "CreatePacking", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := network.Submit(alice, issuer.CCPACKING, "CompactStatistics", core.STATPACKERMONTH); err == nil {
+		t.Fatal("a member compacted statistics")
+	}
+	if _, err := network.Submit(admin, issuer.CCPACKING, "CompactStatistics", core.STATPACKERMONTH); err != nil {
+		t.Fatal(err)
+	}
+	event := network.LastEvent()
+	if event == nil || event.EventName != issuer.STATDOCTYPE+"."+issuer.EVENTCOMPACTED {
+		t.Fatalf("event = %v, want stat.compacted", event)
+	}
+	var change issuer.ChangeEvent
+	if err := json.Unmarshal(event.Payload, &change); err != nil {
+		t.Fatal(err)
+	}
+	var stats []issuer.Statistic
+	if err := json.Unmarshal(change.Payload, &stats); err != nil || len(stats) != 1 || stats[0].Count != 2 {
+		t.Fatalf("compacted statistics = %s, %v", change.Payload, err)
+	}
+}
+
+func TestGetAllPacking(t *testing.T) {
+	network := newNetwork(t, nil)
+	for _, args := range []string{
+		`{"id":"K-1","packerId":"P-1","gmp":"GMP-1","forecastWeight":100,"processStatus":1}`,
+		`{"id":"K-2","packerId":"P-1","packingHouseName":"Suan Mamuang","forecastWeight":50,"processStatus":1}`,
+		`{"id":"K-3","packerId":"P-1","gmp":"GMP-3","forecastWeight":200,"processStatus":2}`,
+	} {
+		if _, err := network.Submit(alice, issuer.CCPACKING, "CreatePacking", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "all", args: `{"limit":0}`, want: "[K-1 K-2 K-3]", wantTotal: 3},
+		{name: "search gmp", args: `{"search":"GMP","limit":0}`, want: "[K-1 K-3]", wantTotal: 2},
+		{name: "search packing house name", args: `{"search":"Mamuang","limit":0}`, want: "[K-1 K-2]", wantTotal: 2},
+		{name: "search either", args: `{"search":"^(GMP|Suan)","limit":0}`, want: "[K-1 K-2 K-3]", wantTotal: 3},
+		{name: "weight range", args: `{"forecastWeightFrom":50,"forecastWeightTo":100,"limit":0}`, want: "[K-1 K-2]", wantTotal: 2},
+		{name: "date range", args: `{"startDate":"2000-01-01","endDate":"2001-01-01","limit":0}`, want: "[]", wantTotal: 0},
+		{name: "process status", args: `{"processStatus":2,"limit":0}`, want: "[K-3]", wantTotal: 1},
+		{name: "skip and limit", args: `{"skip":2,"limit":5}`, want: "[K-3]", wantTotal: 3},
+		{name: "skip past the total", args: `{"skip":4,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCPACKING, "GetAllPacking", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllPackingReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("packings = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
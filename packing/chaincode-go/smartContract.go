@@ -8,6 +8,7 @@ import (
 	"log"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	packing "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/packing/chaincode-go/smart-contract"
 )
 
@@ -17,7 +18,7 @@ func main() {
 		log.Panicf("Error creating packing chaincode: %v", err)
 	}
 
-	if err := abacSmartContract.Start(); err != nil {
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
 		log.Panicf("Error starting packing chaincode: %v", err)
 	}
 }
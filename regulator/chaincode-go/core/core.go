@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/entity"
 )
 
-func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAll) ([]*entity.TransectionReponse, error) {
-	var filter = map[string]interface{}{}
+func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, input *entity.FilterGetAllRegulator) ([]*entity.TransectionRegulatorReponse, error) {
+	var filter = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	selector := map[string]interface{}{
 		"selector": filter,
@@ -30,15 +31,15 @@ func FetchResultsWithPagination(ctx contractapi.TransactionContextInterface, inp
 	}
 	defer queryRegulator.Close()
 
-	var dataRegulator []*entity.TransectionReponse
+	var dataRegulator []*entity.TransectionRegulatorReponse
 	for queryRegulator.HasNext() {
 		queryRes, err := queryRegulator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var dataR entity.TransectionReponse
-		err = json.Unmarshal(queryRes.Value, &dataR)
+		var dataR entity.TransectionRegulatorReponse
+		err = Schema.Unmarshal(ctx, queryRes.Value, &dataR)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/entity"
+)
+
+// PHYTOSHIPMENTDOCTYPE indexes the certificate in force for a shipment, so a
+// shipment cannot hold two at once.
+const PHYTOSHIPMENTDOCTYPE string = "phytoCertShipment"
+
+type phytoShipment struct {
+	DocType    string `json:"docType"`
+	ShipmentID string `json:"shipmentId"`
+	CertNo     string `json:"certNo"`
+}
+
+func phytoKey(ctx contractapi.TransactionContextInterface, certNo string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(entity.PHYTOCERTDOCTYPE, []string{certNo})
+}
+
+func phytoShipmentKey(ctx contractapi.TransactionContextInterface, shipmentID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(PHYTOSHIPMENTDOCTYPE, []string{shipmentID})
+}
+
+// GetPhytoCertificate returns nil without an error if certNo is unknown.
+func GetPhytoCertificate(ctx contractapi.TransactionContextInterface, certNo string) (*entity.PhytoCertificate, error) {
+	key, err := phytoKey(ctx, certNo)
+	if err != nil {
+		return nil, err
+	}
+	certJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if certJSON == nil {
+		return nil, nil
+	}
+
+	var cert entity.PhytoCertificate
+	if err := json.Unmarshal(certJSON, &cert); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &cert, nil
+}
+
+func PutPhytoCertificate(ctx contractapi.TransactionContextInterface, cert *entity.PhytoCertificate) ([]byte, error) {
+	key, err := phytoKey(ctx, cert.CertNo)
+	if err != nil {
+		return nil, err
+	}
+	certJSON, err := json.Marshal(cert)
+	if err != nil {
+		return nil, err
+	}
+	return certJSON, ctx.GetStub().PutState(key, certJSON)
+}
+
+// GetShipmentCertNo returns the number of the certificate in force for a
+// shipment, or "" if there is none.
+func GetShipmentCertNo(ctx contractapi.TransactionContextInterface, shipmentID string) (string, error) {
+	key, err := phytoShipmentKey(ctx, shipmentID)
+	if err != nil {
+		return "", err
+	}
+	indexJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if indexJSON == nil {
+		return "", nil
+	}
+
+	var index phytoShipment
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return "", fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return index.CertNo, nil
+}
+
+// SetShipmentCertNo records certNo as the certificate in force for a
+// shipment; an empty certNo clears it.
+func SetShipmentCertNo(ctx contractapi.TransactionContextInterface, shipmentID, certNo string) error {
+	key, err := phytoShipmentKey(ctx, shipmentID)
+	if err != nil {
+		return err
+	}
+	if certNo == "" {
+		return ctx.GetStub().DelState(key)
+	}
+	indexJSON, err := json.Marshal(phytoShipment{DocType: PHYTOSHIPMENTDOCTYPE, ShipmentID: shipmentID, CertNo: certNo})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, indexJSON)
+}
+
+// GetShipment reads a shipment from the exporter chaincode.
+func GetShipment(ctx contractapi.TransactionContextInterface, shipmentID string) (*entity.Shipment, error) {
+	payload, err := issuer.InvokeQuery(ctx, issuer.CCEXPORTER, "ReadShipment", shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	var shipment entity.Shipment
+	if err := json.Unmarshal(payload, &shipment); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &shipment, nil
+}
+
+// FindInspection looks for inspectionID among the inspections of the given
+// packing orders.
+func FindInspection(ctx contractapi.TransactionContextInterface, packingIDs []string, inspectionID string) (*entity.Inspection, error) {
+	for _, packingID := range packingIDs {
+		key, err := ctx.GetStub().CreateCompositeKey(entity.INSPECTIONDOCTYPE, []string{issuer.TARGETPACKING, packingID, inspectionID})
+		if err != nil {
+			return nil, err
+		}
+		inspectionJSON, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if inspectionJSON == nil {
+			continue
+		}
+		var inspection entity.Inspection
+		if err := json.Unmarshal(inspectionJSON, &inspection); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		return &inspection, nil
+	}
+	return nil, fmt.Errorf("inspection %s is not recorded for any packing order of the shipment", inspectionID)
+}
+
+// PhytoStatusAt is the status of a certificate at now, taking its validity
+// period into account.
+func PhytoStatusAt(cert *entity.PhytoCertificate, now time.Time) (string, error) {
+	if cert.Status == entity.PHYTOREVOKED {
+		return entity.PHYTOREVOKED, nil
+	}
+	validFrom, err := issuer.ParseDate(cert.ValidFrom)
+	if err != nil {
+		return "", err
+	}
+	validUntil, err := issuer.ParseDate(cert.ValidUntil)
+	if err != nil {
+		return "", err
+	}
+	// A bare date in validUntil includes the whole day.
+	if len(cert.ValidUntil) == len("2006-01-02") {
+		validUntil = validUntil.AddDate(0, 0, 1)
+	}
+	switch {
+	case now.Before(validFrom):
+		return entity.PHYTONOTYETVALID, nil
+	case !now.Before(validUntil):
+		return entity.PHYTOEXPIRED, nil
+	}
+	return entity.PHYTOVALID, nil
+}
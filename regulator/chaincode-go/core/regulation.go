@@ -0,0 +1,125 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+)
+
+func statusKey(ctx contractapi.TransactionContextInterface, targetType, targetID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(issuer.REGULATORYSTATUSDOCTYPE, []string{targetType, targetID})
+}
+
+// GetStatus returns the stored status of a target, ACTIVE if the regulator
+// never acted on it.
+func GetStatus(ctx contractapi.TransactionContextInterface, targetType, targetID string) (*issuer.RegulatoryStatus, error) {
+	key, err := statusKey(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	statusJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if statusJSON == nil {
+		return &issuer.RegulatoryStatus{
+			DocType:        issuer.REGULATORYSTATUSDOCTYPE,
+			TargetType:     targetType,
+			TargetID:       targetID,
+			Status:         issuer.CERTACTIVE,
+			PreviousStatus: issuer.CERTACTIVE,
+		}, nil
+	}
+
+	var status issuer.RegulatoryStatus
+	if err := json.Unmarshal(statusJSON, &status); err != nil {
+		return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+	}
+	return &status, nil
+}
+
+func PutStatus(ctx contractapi.TransactionContextInterface, status *issuer.RegulatoryStatus) error {
+	key, err := statusKey(ctx, status.TargetType, status.TargetID)
+	if err != nil {
+		return err
+	}
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, statusJSON)
+}
+
+// ParseEffectiveDate reads an action's effective date; empty means now.
+func ParseEffectiveDate(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	return issuer.ParseDate(value)
+}
+
+// PutRecord stores an inspection or action under docType~targetType~targetId~id
+// so the history of a target can be listed with a partial key.
+func PutRecord(ctx contractapi.TransactionContextInterface, docType, targetType, targetID, id string, record interface{}) ([]byte, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(docType, []string{targetType, targetID, id})
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%s %s already exists", docType, id)
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return recordJSON, ctx.GetStub().PutState(key, recordJSON)
+}
+
+// ListRecords returns the raw records of a docType for one target.
+func ListRecords(ctx contractapi.TransactionContextInterface, docType, targetType, targetID string) ([][]byte, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(docType, []string{targetType, targetID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var records [][]byte
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, queryResponse.Value)
+	}
+	return records, nil
+}
+
+// ListStatuses returns every status the regulator recorded for a target type.
+func ListStatuses(ctx contractapi.TransactionContextInterface, targetType string) ([]*issuer.RegulatoryStatus, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(issuer.REGULATORYSTATUSDOCTYPE, []string{targetType})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	statuses := []*issuer.RegulatoryStatus{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var status issuer.RegulatoryStatus
+		if err := json.Unmarshal(queryResponse.Value, &status); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		statuses = append(statuses, &status)
+	}
+	return statuses, nil
+}
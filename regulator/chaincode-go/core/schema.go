@@ -0,0 +1,6 @@
+package core
+
+import "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+
+// Schema upgrades stored regulators to the current entity.TransectionRegulator.
+var Schema = issuer.NewSchema()
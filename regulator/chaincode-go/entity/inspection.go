@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+const (
+	INSPECTIONDOCTYPE string = "inspection"
+	ACTIONDOCTYPE     string = "regulatoryAction"
+
+	ACTIONSUSPEND   string = "SUSPEND"
+	ACTIONREVOKE    string = "REVOKE"
+	ACTIONREINSTATE string = "REINSTATE"
+
+	REASONNONCOMPLIANCE    string = "NONCOMPLIANCE"
+	REASONRESIDUE          string = "RESIDUE"
+	REASONFAILEDINSPECTION string = "FAILED_INSPECTION"
+	REASONFRAUD            string = "FRAUD"
+	REASONEXPIRED          string = "EXPIRED"
+	REASONVOLUNTARY        string = "VOLUNTARY"
+	REASONOTHER            string = "OTHER"
+)
+
+// Inspection is a regulator's visit to a packing order or a GAP/GMP
+// certificate holder. Attachments stay off-chain; AttachmentsHash pins them.
+type Inspection struct {
+	DocType         string    `json:"docType"`
+	Id              string    `json:"id" validate:"required,maxlen=128"`
+	TargetType      string    `json:"targetType" validate:"required,enum=packing|gap|gmp"`
+	TargetID        string    `json:"targetId" validate:"required,maxlen=128"`
+	Result          string    `json:"result" validate:"required,enum=PASS|CONDITIONAL|FAIL"`
+	Inspector       string    `json:"inspector" validate:"required,maxlen=256"`
+	InspectionDate  string    `json:"inspectionDate" validate:"required,date"`
+	Findings        string    `json:"findings" validate:"maxlen=4096"`
+	AttachmentsHash string    `json:"attachmentsHash" validate:"maxlen=128"`
+	RecordedBy      string    `json:"recordedBy"`
+	OrgName         string    `json:"orgName"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// RegulatoryAction suspends, revokes or reinstates a GAP or GMP certificate.
+// Suspensions and revocations need a ReasonCode; a suspension may name the
+// ReinstateDate on which it lapses.
+type RegulatoryAction struct {
+	DocType       string    `json:"docType"`
+	Id            string    `json:"id" validate:"required,maxlen=128"`
+	TargetType    string    `json:"targetType" validate:"required,enum=gap|gmp"`
+	TargetID      string    `json:"targetId" validate:"required,maxlen=128"`
+	Action        string    `json:"action" validate:"required,enum=SUSPEND|REVOKE|REINSTATE"`
+	ReasonCode    string    `json:"reasonCode" validate:"enum=NONCOMPLIANCE|RESIDUE|FAILED_INSPECTION|FRAUD|EXPIRED|VOLUNTARY|OTHER"`
+	Reason        string    `json:"reason" validate:"maxlen=1024"`
+	EffectiveDate string    `json:"effectiveDate" validate:"date"`
+	ReinstateDate string    `json:"reinstateDate" validate:"date"`
+	RecordedBy    string    `json:"recordedBy"`
+	OrgName       string    `json:"orgName"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
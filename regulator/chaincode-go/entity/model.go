@@ -2,16 +2,21 @@ package entity
 
 import "time"
 
+const ENTITYNAME string = "regulator"
+
 type TransectionRegulator struct {
-	Id        string    `json:"id"`
-	CertId    string    `json:"certId"`
+	Id        string    `json:"id" validate:"required,maxlen=128"`
+	CertId    string    `json:"certId" validate:"maxlen=128"`
 	Owner     string    `json:"owner"`
 	OrgName   string    `json:"orgName"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// SchemaVersion is the core.Schema version the record is stored at.
+	SchemaVersion int `json:"schemaVersion"`
 }
 
-type FilterGetAll struct {
-	Skip  int `json:"skip"`
+type FilterGetAllRegulator struct {
+	Skip  int `json:"skip" metadata:",optional"`
 	Limit int `json:"limit"`
 }
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+const (
+	PHYTOCERTDOCTYPE string = "phytoCertificate"
+
+	PHYTOVALID       string = "VALID"
+	PHYTOREVOKED     string = "REVOKED"
+	PHYTOEXPIRED     string = "EXPIRED"
+	PHYTONOTYETVALID string = "NOT_YET_VALID"
+	PHYTONOTFOUND    string = "NOT_FOUND"
+	// A certificate whose shipment the exporter canceled.
+	PHYTOSHIPMENTCANCELED string = "SHIPMENT_CANCELED"
+
+	// Shipment status set by the exporter chaincode.
+	SHIPMENTCANCELED string = "CANCELED"
+)
+
+// PhytoCertificate is a phytosanitary certificate issued by the regulator for
+// one exporter shipment, after an inspection of its packing orders.
+type PhytoCertificate struct {
+	DocType            string    `json:"docType"`
+	CertNo             string    `json:"certNo" validate:"required,maxlen=128"`
+	ShipmentID         string    `json:"shipmentId" validate:"required,maxlen=128"`
+	IssuingOfficer     string    `json:"issuingOfficer" validate:"required,maxlen=256"`
+	InspectionID       string    `json:"inspectionId" validate:"required,maxlen=128"`
+	DestinationCountry string    `json:"destinationCountry" validate:"required,maxlen=64"`
+	Commodity          string    `json:"commodity" validate:"required,maxlen=256"`
+	Weight             float32   `json:"weight" validate:"min=0"`
+	ValidFrom          string    `json:"validFrom" validate:"required,date"`
+	ValidUntil         string    `json:"validUntil" validate:"required,date"`
+	Status             string    `json:"status"`
+	RevokedReason      string    `json:"revokedReason"`
+	RevokedAt          time.Time `json:"revokedAt"`
+	IssuedBy           string    `json:"issuedBy"`
+	OrgName            string    `json:"orgName"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// PhytoVerification answers an importing country's check of a certificate
+// number. Certificate is nil when the number is unknown.
+type PhytoVerification struct {
+	CertNo      string            `json:"certNo"`
+	Valid       bool              `json:"valid"`
+	Status      string            `json:"status"`
+	Certificate *PhytoCertificate `json:"certificate,omitempty" metadata:",optional"`
+}
+
+// Shipment is the part of an exporter chaincode shipment a certificate needs.
+type Shipment struct {
+	Id                 string         `json:"id"`
+	DestinationCountry string         `json:"destinationCountry"`
+	Items              []ShipmentItem `json:"items"`
+	Status             string         `json:"status"`
+}
+
+type ShipmentItem struct {
+	PackingID string  `json:"packingId"`
+	Weight    float32 `json:"weight"`
+}
@@ -0,0 +1,239 @@
+package regulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/entity"
+)
+
+// RecordInspection stores the result of an inspection of a packing order or
+// a GAP/GMP certificate. A FAIL result blocks approval of the packing order.
+func (s *SmartContract) RecordInspection(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertRegulator(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityInspection := entity.Inspection{}
+	inputInterface, err := issuer.Unmarshal(args, entityInspection)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.Inspection)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	recordedBy, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	input.DocType = entity.INSPECTIONDOCTYPE
+	input.RecordedBy = recordedBy
+	input.OrgName = orgName
+	input.CreatedAt = now
+	inspectionJSON, err := core.PutRecord(ctx, entity.INSPECTIONDOCTYPE, input.TargetType, input.TargetID, input.Id, input)
+	if err != nil {
+		return err
+	}
+
+	status, err := core.GetStatus(ctx, input.TargetType, input.TargetID)
+	if err != nil {
+		return err
+	}
+	status.LastInspectionID = input.Id
+	status.LastInspectionResult = input.Result
+	status.UpdatedAt = now
+	err = core.PutStatus(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.INSPECTIONDOCTYPE, issuer.EventItem{ID: input.Id, Payload: inspectionJSON})
+}
+
+func (s *SmartContract) GetInspections(ctx contractapi.TransactionContextInterface, targetType string, targetID string) ([]*entity.Inspection, error) {
+	records, err := core.ListRecords(ctx, entity.INSPECTIONDOCTYPE, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	inspections := []*entity.Inspection{}
+	for _, record := range records {
+		var inspection entity.Inspection
+		if err := json.Unmarshal(record, &inspection); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		inspections = append(inspections, &inspection)
+	}
+	return inspections, nil
+}
+
+// RecordAction suspends, revokes or reinstates a GAP or GMP certificate.
+// Revocation is final; only a suspended certificate can be reinstated.
+func (s *SmartContract) RecordAction(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertRegulator(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityAction := entity.RegulatoryAction{}
+	inputInterface, err := issuer.Unmarshal(args, entityAction)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.RegulatoryAction)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	effectiveAt, err := core.ParseEffectiveDate(input.EffectiveDate, now)
+	if err != nil {
+		return err
+	}
+
+	var reinstateAt time.Time
+	if input.ReinstateDate != "" {
+		if input.Action != entity.ACTIONSUSPEND {
+			return fmt.Errorf("reinstateDate only applies to %s", entity.ACTIONSUSPEND)
+		}
+		reinstateAt, err = issuer.ParseDate(input.ReinstateDate)
+		if err != nil {
+			return err
+		}
+		if !reinstateAt.After(effectiveAt) {
+			return fmt.Errorf("reinstateDate must be after the effective date")
+		}
+	}
+	if input.Action != entity.ACTIONREINSTATE && input.ReasonCode == "" {
+		return fmt.Errorf("reasonCode is required to %s a certificate", strings.ToLower(input.Action))
+	}
+
+	status, err := core.GetStatus(ctx, input.TargetType, input.TargetID)
+	if err != nil {
+		return err
+	}
+	current := status.StatusAt(now)
+	switch {
+	case current == issuer.CERTREVOKED || status.Status == issuer.CERTREVOKED:
+		return fmt.Errorf("%s %s is revoked", input.TargetType, input.TargetID)
+	case input.Action == entity.ACTIONREINSTATE && current != issuer.CERTSUSPENDED:
+		return fmt.Errorf("%s %s is not suspended", input.TargetType, input.TargetID)
+	case input.Action == entity.ACTIONSUSPEND && current == issuer.CERTSUSPENDED:
+		return fmt.Errorf("%s %s is already suspended", input.TargetType, input.TargetID)
+	}
+
+	recordedBy, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+
+	input.DocType = entity.ACTIONDOCTYPE
+	input.RecordedBy = recordedBy
+	input.OrgName = orgName
+	input.CreatedAt = now
+	actionJSON, err := core.PutRecord(ctx, entity.ACTIONDOCTYPE, input.TargetType, input.TargetID, input.Id, input)
+	if err != nil {
+		return err
+	}
+
+	status.PreviousStatus = current
+	status.Status = map[string]string{
+		entity.ACTIONSUSPEND:   issuer.CERTSUSPENDED,
+		entity.ACTIONREVOKE:    issuer.CERTREVOKED,
+		entity.ACTIONREINSTATE: issuer.CERTACTIVE,
+	}[input.Action]
+	status.EffectiveAt = effectiveAt
+	status.ReinstateAt = reinstateAt
+	status.ActionID = input.Id
+	status.ReasonCode = input.ReasonCode
+	status.Reason = input.Reason
+	status.RecordedBy = recordedBy
+	status.UpdatedAt = now
+	err = core.PutStatus(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.ACTIONDOCTYPE, issuer.EventItem{ID: input.Id, Payload: actionJSON})
+}
+
+func (s *SmartContract) GetActions(ctx contractapi.TransactionContextInterface, targetType string, targetID string) ([]*entity.RegulatoryAction, error) {
+	records, err := core.ListRecords(ctx, entity.ACTIONDOCTYPE, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	actions := []*entity.RegulatoryAction{}
+	for _, record := range records {
+		var action entity.RegulatoryAction
+		if err := json.Unmarshal(record, &action); err != nil {
+			return nil, fmt.Errorf("%s: %v", issuer.DATAUNMARSHAL, err)
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+// GetRegulatoryStatus returns the status of a target in force at the time of
+// the transaction. The gap, gmp and packing chaincodes call it to enforce
+// suspensions, revocations and failed inspections.
+func (s *SmartContract) GetRegulatoryStatus(ctx contractapi.TransactionContextInterface, targetType string, targetID string) (*issuer.RegulatoryStatus, error) {
+	status, err := core.GetStatus(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.Status = status.StatusAt(now)
+	return status, nil
+}
+
+// GetRevocationList returns the certificates of a target type (gap or gmp)
+// that are suspended or revoked at the time of the transaction.
+func (s *SmartContract) GetRevocationList(ctx contractapi.TransactionContextInterface, targetType string) ([]*issuer.RegulatoryStatus, error) {
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := core.ListStatuses(ctx, targetType)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := []*issuer.RegulatoryStatus{}
+	for _, status := range statuses {
+		status.Status = status.StatusAt(now)
+		if status.Status != issuer.CERTACTIVE {
+			revoked = append(revoked, status)
+		}
+	}
+	return revoked, nil
+}
@@ -0,0 +1,261 @@
+package regulator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/core"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/entity"
+)
+
+// Rounding slack when comparing float32 weight totals, in kilograms.
+const WEIGHTEPSILON float32 = 0.001
+
+// IssuePhytoCertificate issues a phytosanitary certificate for an exporter
+// shipment. The referenced inspection must be one of a packing order in the
+// shipment and must not have failed, and a shipment holds at most one
+// certificate that is not revoked.
+func (s *SmartContract) IssuePhytoCertificate(ctx contractapi.TransactionContextInterface, args string) error {
+	err := issuer.AssertRegulator(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityCert := entity.PhytoCertificate{}
+	inputInterface, err := issuer.Unmarshal(args, entityCert)
+	if err != nil {
+		return err
+	}
+	input := inputInterface.(*entity.PhytoCertificate)
+
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
+	existing, err := core.GetPhytoCertificate(ctx, input.CertNo)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("phytosanitary certificate %s already exists", input.CertNo)
+	}
+
+	validFrom, err := issuer.ParseDate(input.ValidFrom)
+	if err != nil {
+		return err
+	}
+	validUntil, err := issuer.ParseDate(input.ValidUntil)
+	if err != nil {
+		return err
+	}
+	if validUntil.Before(validFrom) {
+		return fmt.Errorf("validUntil must not be before validFrom")
+	}
+
+	shipment, err := core.GetShipment(ctx, input.ShipmentID)
+	if err != nil {
+		return err
+	}
+	if shipment.Status == entity.SHIPMENTCANCELED {
+		return fmt.Errorf("shipment %s is canceled", shipment.Id)
+	}
+	if !strings.EqualFold(shipment.DestinationCountry, input.DestinationCountry) {
+		return fmt.Errorf("shipment %s goes to %s, not %s", shipment.Id, shipment.DestinationCountry, input.DestinationCountry)
+	}
+
+	certNo, err := core.GetShipmentCertNo(ctx, shipment.Id)
+	if err != nil {
+		return err
+	}
+	if certNo != "" {
+		return fmt.Errorf("shipment %s already has phytosanitary certificate %s", shipment.Id, certNo)
+	}
+
+	var packingIDs []string
+	var shipped float32
+	for _, item := range shipment.Items {
+		packingIDs = append(packingIDs, item.PackingID)
+		shipped += item.Weight
+	}
+	if input.Weight == 0 {
+		input.Weight = shipped
+	} else if input.Weight > shipped+WEIGHTEPSILON {
+		return fmt.Errorf("certificate weight %.3f exceeds the %.3f shipped", input.Weight, shipped)
+	}
+
+	inspection, err := core.FindInspection(ctx, packingIDs, input.InspectionID)
+	if err != nil {
+		return err
+	}
+	if inspection.Result == issuer.INSPECTIONFAIL {
+		return fmt.Errorf("inspection %s failed", inspection.Id)
+	}
+
+	issuedBy, err := issuer.GetOwnerID(ctx)
+	if err != nil {
+		return err
+	}
+	orgName, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get submitting client's MSP ID: %v", err)
+	}
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	input.DocType = entity.PHYTOCERTDOCTYPE
+	input.Status = entity.PHYTOVALID
+	input.RevokedReason = ""
+	input.IssuedBy = issuedBy
+	input.OrgName = orgName
+	input.UpdatedAt = now
+	input.CreatedAt = now
+	certJSON, err := core.PutPhytoCertificate(ctx, input)
+	if err != nil {
+		return err
+	}
+	err = core.SetShipmentCertNo(ctx, shipment.Id, input.CertNo)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitEvent(ctx, issuer.EVENTCREATED, entity.PHYTOCERTDOCTYPE, issuer.EventItem{ID: input.CertNo, Payload: certJSON})
+}
+
+// RevokePhytoCertificate withdraws a certificate. Revocation is final; the
+// shipment can then be issued a new certificate.
+func (s *SmartContract) RevokePhytoCertificate(ctx contractapi.TransactionContextInterface, certNo string, reason string) error {
+	err := issuer.AssertRegulator(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required to revoke a phytosanitary certificate")
+	}
+
+	cert, err := core.GetPhytoCertificate(ctx, certNo)
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return fmt.Errorf("phytosanitary certificate %s does not exist", certNo)
+	}
+	if cert.Status == entity.PHYTOREVOKED {
+		return fmt.Errorf("phytosanitary certificate %s is already revoked", certNo)
+	}
+	before := *cert
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	cert.Status = entity.PHYTOREVOKED
+	cert.RevokedReason = reason
+	cert.RevokedAt = now
+	cert.UpdatedAt = now
+	_, err = core.PutPhytoCertificate(ctx, cert)
+	if err != nil {
+		return err
+	}
+
+	current, err := core.GetShipmentCertNo(ctx, cert.ShipmentID)
+	if err != nil {
+		return err
+	}
+	if current == certNo {
+		err = core.SetShipmentCertNo(ctx, cert.ShipmentID, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return issuer.EmitUpdated(ctx, entity.PHYTOCERTDOCTYPE, certNo, before, cert)
+}
+
+func (s *SmartContract) ReadPhytoCertificate(ctx contractapi.TransactionContextInterface, certNo string) (*entity.PhytoCertificate, error) {
+	cert, err := core.GetPhytoCertificate(ctx, certNo)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("phytosanitary certificate %s does not exist", certNo)
+	}
+	return cert, nil
+}
+
+// VerifyPhytoCertificate tells an importing country whether a certificate
+// number is valid at the time of the query. It never fails for an unknown
+// number; the answer is NOT_FOUND instead. A certificate of a shipment the
+// exporter canceled is not valid.
+func (s *SmartContract) VerifyPhytoCertificate(ctx contractapi.TransactionContextInterface, certNo string) (*entity.PhytoVerification, error) {
+	cert, err := core.GetPhytoCertificate(ctx, certNo)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return &entity.PhytoVerification{CertNo: certNo, Status: entity.PHYTONOTFOUND}, nil
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := core.PhytoStatusAt(cert, now)
+	if err != nil {
+		return nil, err
+	}
+	if status != entity.PHYTOREVOKED {
+		shipment, err := core.GetShipment(ctx, cert.ShipmentID)
+		if err != nil {
+			return nil, err
+		}
+		if shipment.Status == entity.SHIPMENTCANCELED {
+			status = entity.PHYTOSHIPMENTCANCELED
+		}
+	}
+	return &entity.PhytoVerification{
+		CertNo:      certNo,
+		Valid:       status == entity.PHYTOVALID,
+		Status:      status,
+		Certificate: cert,
+	}, nil
+}
+
+// GetShipmentPhytoCertificate returns the status of the certificate in force
+// for a shipment, NOT_FOUND if there is none. The exporter chaincode asks it
+// before canceling a shipment, so it does not read the shipment back.
+func (s *SmartContract) GetShipmentPhytoCertificate(ctx contractapi.TransactionContextInterface, shipmentID string) (*entity.PhytoVerification, error) {
+	certNo, err := core.GetShipmentCertNo(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if certNo == "" {
+		return &entity.PhytoVerification{Status: entity.PHYTONOTFOUND}, nil
+	}
+	cert, err := core.GetPhytoCertificate(ctx, certNo)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return &entity.PhytoVerification{CertNo: certNo, Status: entity.PHYTONOTFOUND}, nil
+	}
+
+	now, err := issuer.GetTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := core.PhytoStatusAt(cert, now)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PhytoVerification{
+		CertNo:      certNo,
+		Valid:       status == entity.PHYTOVALID,
+		Status:      status,
+		Certificate: cert,
+	}, nil
+}
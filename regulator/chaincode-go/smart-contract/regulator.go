@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
@@ -15,6 +16,26 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions lists the read-only transactions. The contract
+// metadata tags them "evaluate" so clients query them instead of submitting.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"ReadAsset",
+		"GetAllRegulator",
+		"FilterRegulator",
+		"GetInspections",
+		"GetActions",
+		"GetRegulatoryStatus",
+		"GetRevocationList",
+		"ReadPhytoCertificate",
+		"VerifyPhytoCertificate",
+		"GetShipmentPhytoCertificate",
+		"GetTransfer",
+		"GetValidationSchema",
+		"VerifyOwnership",
+	}
+}
+
 func (s *SmartContract) CreateRegulator(
 	ctx contractapi.TransactionContextInterface,
 	args string,
@@ -26,6 +47,11 @@ func (s *SmartContract) CreateRegulator(
 	}
 	input := inputInterface.(*entity.TransectionRegulator)
 
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
+
 	// err := ctx.GetClientIdentity().AssertAttributeValue("regulator.creator", "true")
 	orgName, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
@@ -38,7 +64,7 @@ func (s *SmartContract) CreateRegulator(
 		return fmt.Errorf("the asset %s already exists", input.Id)
 	}
 
-	clientID, err := issuer.GetIdentity(ctx)
+	clientID, err := issuer.GetOwnerID(ctx)
 	issuer.HandleError(err)
 
 	CreatedR := issuer.GetTimeNow()
@@ -50,11 +76,17 @@ func (s *SmartContract) CreateRegulator(
 		OrgName:   orgName,
 		UpdatedAt: CreatedR,
 		CreatedAt: CreatedR,
+		SchemaVersion: core.Schema.Version(),
 	}
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitCreated(ctx, entity.ENTITYNAME, input.Id, asset)
 }
 
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
@@ -62,17 +94,25 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 
 	entityRegulator := entity.TransectionRegulator{}
 	inputInterface, err := issuer.Unmarshal(args, entityRegulator)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	input := inputInterface.(*entity.TransectionRegulator)
 
-	asset, err := s.ReadAsset(ctx, input.Id)
-	issuer.HandleError(err)
+	err = issuer.Validate(input)
+	if err != nil {
+		return err
+	}
 
-	clientID, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	asset, err := s.ReadAsset(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	before := *asset
 
-	if clientID != asset.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+	err = issuer.AssertIdentity(ctx, asset.Owner)
+	if err != nil {
+		return err
 	}
 
 	UpdatedR := issuer.GetTimeNow()
@@ -84,42 +124,86 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	assetJSON, err := json.Marshal(asset)
 	issuer.HandleError(err)
 
-	return ctx.GetStub().PutState(input.Id, assetJSON)
+	err = ctx.GetStub().PutState(input.Id, assetJSON)
+	if err != nil {
+		return err
+	}
+
+	return issuer.EmitUpdated(ctx, entity.ENTITYNAME, input.Id, before, asset)
 }
 
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, id string) error {
 
 	assetRegulator, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDRegulator, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	err = issuer.AssertIdentity(ctx, assetRegulator.Owner)
+	if err != nil {
+		return err
+	}
 
-	if clientIDRegulator != assetRegulator.Owner {
-		return fmt.Errorf(issuer.UNAUTHORIZE)
+	err = ctx.GetStub().DelState(id)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(id)
+	return issuer.EmitDeleted(ctx, entity.ENTITYNAME, id, assetRegulator)
 }
 
+// TransferAsset is kept for existing clients. It only proposes the transfer;
+// the new owner still has to call AcceptTransfer.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, id string, newOwner string) error {
+	return s.ProposeTransfer(ctx, id, newOwner, 0)
+}
+
+func (s *SmartContract) ProposeTransfer(ctx contractapi.TransactionContextInterface, id string, newOwner string, expireHours int) error {
 
 	assetR, err := s.ReadAsset(ctx, id)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 
-	clientIDR, err := issuer.GetIdentity(ctx)
-	issuer.HandleError(err)
+	_, err = issuer.ProposeTransfer(ctx, entity.ENTITYNAME, id, assetR.Owner, newOwner, time.Duration(expireHours)*time.Hour)
+	return err
+}
 
-	if clientIDR != assetR.Owner {
-		return issuer.ReturnError(issuer.UNAUTHORIZE)
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+
+	assetR, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	assetR.Owner = newOwner
+	transfer, err := issuer.AcceptTransfer(ctx, entity.ENTITYNAME, id, assetR.Owner)
+	if err != nil {
+		return err
+	}
+
+	assetR.Owner = transfer.AcceptedBy
+	assetR.UpdatedAt = transfer.UpdatedAt
 	assetJSON, err := json.Marshal(assetR)
-	issuer.HandleError(err)
+	if err != nil {
+		return err
+	}
 	return ctx.GetStub().PutState(id, assetJSON)
 }
 
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.RejectTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, id string) error {
+	_, err := issuer.CancelTransfer(ctx, entity.ENTITYNAME, id)
+	return err
+}
+
+func (s *SmartContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*issuer.TransferRequest, error) {
+	return issuer.GetTransfer(ctx, id)
+}
+
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*entity.TransectionRegulator, error) {
 
 	assetJSON, err := ctx.GetStub().GetState(id)
@@ -131,7 +215,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	}
 
 	var asset entity.TransectionRegulator
-	err = json.Unmarshal(assetJSON, &asset)
+	err = core.Schema.Unmarshal(ctx, assetJSON, &asset)
 	if err != nil {
 		return nil, err
 	}
@@ -139,16 +223,9 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &asset, nil
 }
 
-func (s *SmartContract) GetAllRegulator(ctx contractapi.TransactionContextInterface, args string) (*entity.GetAllReponse, error) {
+func (s *SmartContract) GetAllRegulator(ctx contractapi.TransactionContextInterface, input entity.FilterGetAllRegulator) (*entity.GetAllRegulatorReponse, error) {
 
-	var filterRegulator = map[string]interface{}{}
-
-	entityGetAll := entity.FilterGetAll{}
-	interfaceRegulator, err := issuer.Unmarshal(args, entityGetAll)
-	if err != nil {
-		return nil, err
-	}
-	input := interfaceRegulator.(*entity.FilterGetAll)
+	var filterRegulator = issuer.FilterAssetsOnly(map[string]interface{}{})
 
 	queryStringRegulator, err := issuer.BuildQueryString(filterRegulator)
 	if err != nil {
@@ -164,7 +241,7 @@ func (s *SmartContract) GetAllRegulator(ctx contractapi.TransactionContextInterf
 		return nil, issuer.ReturnError(issuer.SKIPOVER)
 	}
 
-	arrRegulator, err := core.FetchResultsWithPagination(ctx, input)
+	arrRegulator, err := core.FetchResultsWithPagination(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -174,10 +251,10 @@ func (s *SmartContract) GetAllRegulator(ctx contractapi.TransactionContextInterf
 	})
 
 	if len(arrRegulator) == 0 {
-		arrRegulator = []*entity.TransectionReponse{}
+		arrRegulator = []*entity.TransectionRegulatorReponse{}
 	}
 
-	return &entity.GetAllReponse{
+	return &entity.GetAllRegulatorReponse{
 		Data:  "All Regulator",
 		Obj:   arrRegulator,
 		Total: total,
@@ -198,14 +275,19 @@ func (s *SmartContract) FilterRegulator(ctx contractapi.TransactionContextInterf
 			return nil, err
 		}
 
+		record, _, err := core.Schema.Upgrade(ctx, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		var dataR entity.TransectionRegulator
-		err = json.Unmarshal(queryResponse.Value, &dataR)
+		err = json.Unmarshal(record, &dataR)
 		if err != nil {
 			return nil, err
 		}
 
 		var m map[string]interface{}
-		if err := json.Unmarshal(queryResponse.Value, &m); err != nil {
+		if err := json.Unmarshal(record, &m); err != nil {
 			return nil, err
 		}
 
@@ -220,3 +302,36 @@ func (s *SmartContract) FilterRegulator(ctx contractapi.TransactionContextInterf
 
 	return assetRegulator, nil
 }
+
+// VerifyOwnership reports whether the submitting client owns the asset.
+func (s *SmartContract) VerifyOwnership(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	asset, err := s.ReadAsset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return issuer.IsIdentity(ctx, asset.Owner)
+}
+
+// MigrateOwners replaces raw owner IDs left by older versions with hashed
+// ones, pageSize records per call. Only administrators may call it.
+func (s *SmartContract) MigrateOwners(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*issuer.MigrationResult, error) {
+	return issuer.MigrateOwners(ctx, entity.ENTITYNAME, pageSize, bookmark)
+}
+
+// MigrateBatch stores up to size records written under an older schema
+// upgraded to the current one; call it again with the returned bookmark
+// until done. Only administrators may call it.
+func (s *SmartContract) MigrateBatch(ctx contractapi.TransactionContextInterface, bookmark string, size int) (*issuer.MigrationResult, error) {
+	return issuer.MigrateBatch(ctx, entity.ENTITYNAME, core.Schema, bookmark, size)
+}
+
+// GetValidationSchema returns the JSON Schema of the records accepted by this
+// chaincode, built from the same rules the write transactions enforce.
+func (s *SmartContract) GetValidationSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return issuer.ValidationSchema(map[string]interface{}{
+		entity.ENTITYNAME:        entity.TransectionRegulator{},
+		entity.INSPECTIONDOCTYPE: entity.Inspection{},
+		entity.ACTIONDOCTYPE:     entity.RegulatoryAction{},
+		entity.PHYTOCERTDOCTYPE:  entity.PhytoCertificate{},
+	})
+}
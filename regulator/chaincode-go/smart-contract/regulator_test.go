@@ -0,0 +1,305 @@
+package regulator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer/issuertest"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/entity"
+	regulator "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/smart-contract"
+)
+
+var (
+	officer = issuertest.NewIdentity(issuer.REGULATORMSP, "officer", nil)
+	alice   = issuertest.NewIdentity("Org1MSP", "alice", nil)
+)
+
+// newNetwork deploys the regulator chaincode next to an exporter chaincode
+// holding shipment S-1 of packing orders K-1 and K-2 to Japan and the
+// canceled shipment S-2.
+func newNetwork(t *testing.T) *issuertest.Network {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&regulator.SmartContract{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shipments := map[string]entity.Shipment{
+		"S-1": {Id: "S-1", DestinationCountry: "JP", Status: "CREATED", Items: []entity.ShipmentItem{
+			{PackingID: "K-1", Weight: 60}, {PackingID: "K-2", Weight: 40},
+		}},
+		"S-2": {Id: "S-2", DestinationCountry: "JP", Status: entity.SHIPMENTCANCELED, Items: []entity.ShipmentItem{
+			{PackingID: "K-1", Weight: 10},
+		}},
+	}
+	network := issuertest.NewNetwork()
+	network.Deploy(issuer.CCREGULATOR, chaincode)
+	network.Deploy(issuer.CCEXPORTER, issuertest.NewFake().
+		On("ReadShipment", func(args []string) ([]byte, error) {
+			shipment, ok := shipments[args[0]]
+			if !ok {
+				return nil, fmt.Errorf("the shipment %s does not exist", args[0])
+			}
+			return json.Marshal(shipment)
+		}))
+	return network
+}
+
+func regulatoryStatus(t *testing.T, network *issuertest.Network, targetType, targetID string) *issuer.RegulatoryStatus {
+	t.Helper()
+	payload, err := network.Evaluate(alice, issuer.CCREGULATOR, "GetRegulatoryStatus", targetType, targetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status issuer.RegulatoryStatus
+	if err := json.Unmarshal(payload, &status); err != nil {
+		t.Fatal(err)
+	}
+	return &status
+}
+
+func TestRecordInspection(t *testing.T) {
+	network := newNetwork(t)
+
+	tests := []struct {
+		name       string
+		identity   *issuertest.Identity
+		args       string
+		wantErr    bool
+		wantResult string
+	}{
+		{name: "not a regulator", identity: alice, args: `{"id":"I-1","targetType":"packing","targetId":"K-1","result":"PASS","inspector":"Somsak","inspectionDate":"2024-01-01"}`, wantErr: true},
+		{name: "pass", identity: officer, args: `{"id":"I-1","targetType":"packing","targetId":"K-1","result":"PASS","inspector":"Somsak","inspectionDate":"2024-01-01"}`, wantResult: issuer.INSPECTIONPASS},
+		{name: "fail", identity: officer, args: `{"id":"I-2","targetType":"packing","targetId":"K-1","result":"FAIL","inspector":"Somsak","inspectionDate":"2024-01-01"}`, wantResult: issuer.INSPECTIONFAIL},
+		{name: "unknown result", identity: officer, args: `{"id":"I-3","targetType":"packing","targetId":"K-1","result":"MAYBE","inspector":"Somsak","inspectionDate":"2024-01-01"}`, wantErr: true, wantResult: issuer.INSPECTIONFAIL},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCREGULATOR, "RecordInspection", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := regulatoryStatus(t, network, issuer.TARGETPACKING, "K-1").LastInspectionResult; got != test.wantResult {
+				t.Fatalf("last inspection result = %q, want %q", got, test.wantResult)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCREGULATOR, "GetInspections", issuer.TARGETPACKING, "K-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inspections []entity.Inspection
+	if err := json.Unmarshal(payload, &inspections); err != nil || len(inspections) != 2 {
+		t.Fatalf("inspections = %s, %v", payload, err)
+	}
+}
+
+func TestRecordAction(t *testing.T) {
+	network := newNetwork(t)
+
+	tests := []struct {
+		name       string
+		args       string
+		wantErr    bool
+		wantStatus string
+	}{
+		{name: "reinstate an active certificate", args: `{"id":"A-1","targetType":"gap","targetId":"GAP-1","action":"REINSTATE"}`, wantErr: true, wantStatus: issuer.CERTACTIVE},
+		{name: "suspend without a reason", args: `{"id":"A-2","targetType":"gap","targetId":"GAP-1","action":"SUSPEND"}`, wantErr: true, wantStatus: issuer.CERTACTIVE},
+		{name: "suspend", args: `{"id":"A-3","targetType":"gap","targetId":"GAP-1","action":"SUSPEND","reasonCode":"RESIDUE","reinstateDate":"2024-02-01"}`, wantStatus: issuer.CERTSUSPENDED},
+		{name: "suspend twice", args: `{"id":"A-4","targetType":"gap","targetId":"GAP-1","action":"SUSPEND","reasonCode":"RESIDUE"}`, wantErr: true, wantStatus: issuer.CERTSUSPENDED},
+		{name: "reinstate", args: `{"id":"A-5","targetType":"gap","targetId":"GAP-1","action":"REINSTATE"}`, wantStatus: issuer.CERTACTIVE},
+		{name: "revoke", args: `{"id":"A-6","targetType":"gap","targetId":"GAP-1","action":"REVOKE","reasonCode":"FRAUD"}`, wantStatus: issuer.CERTREVOKED},
+		{name: "reinstate a revoked certificate", args: `{"id":"A-7","targetType":"gap","targetId":"GAP-1","action":"REINSTATE"}`, wantErr: true, wantStatus: issuer.CERTREVOKED},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(officer, issuer.CCREGULATOR, "RecordAction", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if got := regulatoryStatus(t, network, issuer.TARGETGAP, "GAP-1").Status; got != test.wantStatus {
+				t.Fatalf("status = %s, want %s", got, test.wantStatus)
+			}
+		})
+	}
+
+	payload, err := network.Evaluate(alice, issuer.CCREGULATOR, "GetRevocationList", issuer.TARGETGAP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var revoked []issuer.RegulatoryStatus
+	if err := json.Unmarshal(payload, &revoked); err != nil || len(revoked) != 1 || revoked[0].TargetID != "GAP-1" {
+		t.Fatalf("revocation list = %s, %v", payload, err)
+	}
+}
+
+func TestSuspensionLapses(t *testing.T) {
+	network := newNetwork(t)
+	_, err := network.Submit(officer, issuer.CCREGULATOR, "RecordAction",
+		`{"id":"A-1","targetType":"gmp","targetId":"GMP-1","action":"SUSPEND","reasonCode":"NONCOMPLIANCE","reinstateDate":"2024-02-01"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := regulatoryStatus(t, network, issuer.TARGETGMP, "GMP-1").Status; got != issuer.CERTSUSPENDED {
+		t.Fatalf("status = %s, want %s", got, issuer.CERTSUSPENDED)
+	}
+	network.Clock = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := regulatoryStatus(t, network, issuer.TARGETGMP, "GMP-1").Status; got != issuer.CERTACTIVE {
+		t.Fatalf("status after reinstateDate = %s, want %s", got, issuer.CERTACTIVE)
+	}
+}
+
+func TestPhytoCertificate(t *testing.T) {
+	network := newNetwork(t)
+	for _, args := range []string{
+		`{"id":"I-1","targetType":"packing","targetId":"K-2","result":"PASS","inspector":"Somsak","inspectionDate":"2024-01-01"}`,
+		`{"id":"I-2","targetType":"packing","targetId":"K-1","result":"FAIL","inspector":"Somsak","inspectionDate":"2024-01-01"}`,
+	} {
+		if _, err := network.Submit(officer, issuer.CCREGULATOR, "RecordInspection", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certificate := func(certNo, shipmentID, inspectionID, country string, weight float32) string {
+		return fmt.Sprintf(`{"certNo":%q,"shipmentId":%q,"issuingOfficer":"Somsak","inspectionId":%q,"destinationCountry":%q,"commodity":"Mango","weight":%v,"validFrom":"2024-01-01","validUntil":"2024-01-31"}`,
+			certNo, shipmentID, inspectionID, country, weight)
+	}
+	tests := []struct {
+		name     string
+		identity *issuertest.Identity
+		args     string
+		wantErr  bool
+	}{
+		{name: "not a regulator", identity: alice, args: certificate("PC-1", "S-1", "I-1", "JP", 0), wantErr: true},
+		{name: "failed inspection", identity: officer, args: certificate("PC-1", "S-1", "I-2", "JP", 0), wantErr: true},
+		{name: "inspection of another shipment", identity: officer, args: certificate("PC-1", "S-1", "I-9", "JP", 0), wantErr: true},
+		{name: "other destination", identity: officer, args: certificate("PC-1", "S-1", "I-1", "CN", 0), wantErr: true},
+		{name: "more than shipped", identity: officer, args: certificate("PC-1", "S-1", "I-1", "JP", 101), wantErr: true},
+		{name: "canceled shipment", identity: officer, args: certificate("PC-1", "S-2", "I-1", "JP", 0), wantErr: true},
+		{name: "valid", identity: officer, args: certificate("PC-1", "S-1", "I-1", "jp", 0)},
+		{name: "second certificate for the shipment", identity: officer, args: certificate("PC-2", "S-1", "I-1", "JP", 0), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.Submit(test.identity, issuer.CCREGULATOR, "IssuePhytoCertificate", test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	query := func(function, id string) *entity.PhytoVerification {
+		t.Helper()
+		payload, err := network.Evaluate(alice, issuer.CCREGULATOR, function, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var verification entity.PhytoVerification
+		if err := json.Unmarshal(payload, &verification); err != nil {
+			t.Fatal(err)
+		}
+		return &verification
+	}
+	verify := func(certNo string) *entity.PhytoVerification {
+		t.Helper()
+		return query("VerifyPhytoCertificate", certNo)
+	}
+	shipmentCertificate := func(shipmentID string) *entity.PhytoVerification {
+		t.Helper()
+		return query("GetShipmentPhytoCertificate", shipmentID)
+	}
+	if got := verify("PC-1"); !got.Valid || got.Certificate.Weight != 100 {
+		t.Fatalf("verification = %+v", got)
+	}
+	if got := verify("PC-9").Status; got != entity.PHYTONOTFOUND {
+		t.Fatalf("unknown certificate status = %s", got)
+	}
+	if got := shipmentCertificate("S-1"); got.CertNo != "PC-1" || got.Status != entity.PHYTOVALID {
+		t.Fatalf("shipment certificate = %+v", got)
+	}
+
+	if _, err := network.Submit(officer, issuer.CCREGULATOR, "RevokePhytoCertificate", "PC-1", "wrong weight"); err != nil {
+		t.Fatal(err)
+	}
+	if got := verify("PC-1"); got.Valid || got.Status != entity.PHYTOREVOKED {
+		t.Fatalf("verification after revocation = %+v", got)
+	}
+	if got := shipmentCertificate("S-1").Status; got != entity.PHYTONOTFOUND {
+		t.Fatalf("shipment certificate status after revocation = %s", got)
+	}
+	if _, err := network.Submit(officer, issuer.CCREGULATOR, "IssuePhytoCertificate", certificate("PC-2", "S-1", "I-1", "JP", 90)); err != nil {
+		t.Fatalf("replacement certificate: %v", err)
+	}
+
+	// A certificate issued before the exporter canceled S-2.
+	stub := network.Stub(issuer.CCREGULATOR)
+	key, err := stub.CreateCompositeKey(entity.PHYTOCERTDOCTYPE, []string{"PC-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.Seed(key, []byte(`{"certNo":"PC-3","shipmentId":"S-2","status":"VALID","validFrom":"2024-01-01","validUntil":"2024-01-31"}`))
+	if got := verify("PC-3"); got.Valid || got.Status != entity.PHYTOSHIPMENTCANCELED {
+		t.Fatalf("verification of a canceled shipment's certificate = %+v", got)
+	}
+
+	network.Clock = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := verify("PC-2").Status; got != entity.PHYTOEXPIRED {
+		t.Fatalf("status after validUntil = %s, want %s", got, entity.PHYTOEXPIRED)
+	}
+}
+
+func TestGetAllRegulator(t *testing.T) {
+	network := newNetwork(t)
+	for _, args := range []string{`{"id":"R-1"}`, `{"id":"R-2"}`} {
+		if _, err := network.Submit(officer, issuer.CCREGULATOR, "CreateRegulator", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := network.Submit(officer, issuer.CCREGULATOR, "RecordInspection",
+		`{"id":"I-1","targetType":"packing","targetId":"K-1","result":"PASS","inspector":"Somsak","inspectionDate":"2024-01-01"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantTotal int
+		wantErr   bool
+	}{
+		{name: "regulators only", args: `{"limit":0}`, want: "[R-1 R-2]", wantTotal: 2},
+		{name: "limit", args: `{"limit":1}`, want: "[R-1]", wantTotal: 2},
+		{name: "skip past the total", args: `{"skip":3,"limit":0}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := network.Evaluate(alice, issuer.CCREGULATOR, "GetAllRegulator", test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s", payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response entity.GetAllRegulatorReponse
+			if err := json.Unmarshal(payload, &response); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, asset := range response.Obj {
+				ids = append(ids, asset.Id)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != test.want || response.Total != test.wantTotal {
+				t.Fatalf("regulators = %s of %d, want %s of %d", got, response.Total, test.want, test.wantTotal)
+			}
+		})
+	}
+}
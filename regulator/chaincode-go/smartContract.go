@@ -8,6 +8,7 @@ import (
 	"log"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/zeabix-cloud-native/nstda-blockchain-chaincode/internal/issuer"
 	regulator "github.com/zeabix-cloud-native/nstda-blockchain-chaincode/regulator/chaincode-go/smart-contract"
 )
 
@@ -17,7 +18,7 @@ func main() {
 		log.Panicf("Error creating nstda staff chaincode: %v", err)
 	}
 
-	if err := abacSmartContract.Start(); err != nil {
+	if err := issuer.StartChaincode(abacSmartContract); err != nil {
 		log.Panicf("Error starting nstda staff chaincode: %v", err)
 	}
 }
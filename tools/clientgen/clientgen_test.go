@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func load(t *testing.T) []*Chaincode {
+	t.Helper()
+	chaincodes, err := loadChaincodes([]string{"gap=testdata/metadata.json", "nstda-staff=testdata/metadata.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chaincodes
+}
+
+func TestLoadChaincodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no chaincode name", args: []string{"testdata/metadata.json"}},
+		{name: "chaincode given twice", args: []string{"gap=testdata/metadata.json", "gap=testdata/metadata.json"}},
+		{name: "missing file", args: []string{"gap=testdata/missing.json"}},
+		{name: "not metadata", args: []string{"gap=clientgen_test.go"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := loadChaincodes(test.args); err == nil {
+				t.Fatal("want an error")
+			}
+		})
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	document, err := OpenAPI("gateway", load(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Paths map[string]struct {
+			Post struct {
+				OperationID string                 `json:"operationId"`
+				Function    string                 `json:"x-fabric-function"`
+				Type        string                 `json:"x-fabric-transaction-type"`
+				RequestBody map[string]interface{} `json:"requestBody"`
+				Responses   map[string]interface{} `json:"responses"`
+			} `json:"post"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{}
+	for path, item := range parsed.Paths {
+		paths = append(paths, fmt.Sprintf("%s %s %s %s", path, item.Post.OperationID, item.Post.Function, item.Post.Type))
+	}
+	for _, want := range []string{
+		"/gap/GetAllGAP gapGetAllGAP GetAllGAP evaluate",
+		"/gap/CreateGAP gapCreateGAP CreateGAP submit",
+		"/gap/Audit/GetLabels gapAuditGetLabels Audit:GetLabels evaluate",
+		"/nstda-staff/GetAllGAP nstdaStaffGetAllGAP GetAllGAP evaluate",
+	} {
+		if !strings.Contains(strings.Join(paths, "\n"), want) {
+			t.Errorf("paths %v miss %s", paths, want)
+		}
+	}
+	if len(paths) != 6 {
+		t.Errorf("paths = %v, want 6 without the system contract", paths)
+	}
+
+	getAll := parsed.Paths["/gap/GetAllGAP"].Post
+	if _, ok := getAll.Responses["200"]; !ok || getAll.RequestBody == nil {
+		t.Errorf("GetAllGAP = %+v", getAll)
+	}
+	create := parsed.Paths["/gap/CreateGAP"].Post
+	if _, ok := create.Responses["204"]; !ok {
+		t.Errorf("CreateGAP responses = %v", create.Responses)
+	}
+	if labels := parsed.Paths["/gap/Audit/GetLabels"].Post; labels.RequestBody != nil {
+		t.Errorf("GetLabels has a request body: %v", labels.RequestBody)
+	}
+
+	schema, ok := parsed.Components.Schemas["NstdaStaffGetAllReponse"]
+	if !ok {
+		t.Fatalf("schemas = %v", parsed.Components.Schemas)
+	}
+	if _, ok := schema["$id"]; ok {
+		t.Errorf("$id kept in %v", schema)
+	}
+	if data, _ := json.Marshal(schema); !strings.Contains(string(data), `"#/components/schemas/NstdaStaffTransectionReponse"`) {
+		t.Errorf("$ref not rewritten: %s", data)
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	client := string(TypeScript(load(t)))
+	for _, want := range []string{
+		"export interface GapFilterGetAll {\n  certId?: string;\n  limit: number;\n}\n",
+		"export interface NstdaStaffGetAllReponse {\n  data: NstdaStaffTransectionReponse[];\n  total: number;\n}\n",
+		"export class NstdaStaffClient {\n",
+		"  getAllGAP(param0: GapFilterGetAll): Promise<GapGetAllReponse> {\n",
+		"  createGAP(param0: string): Promise<void> {\n",
+		"  auditGetLabels(): Promise<Record<string, string>> {\n",
+		"      function: \"Audit:GetLabels\",\n      path: \"/gap/Audit/GetLabels\",\n      type: \"evaluate\",\n      body: {},\n",
+	} {
+		if !strings.Contains(client, want) {
+			t.Errorf("client misses:\n%s", want)
+		}
+	}
+	if strings.Contains(client, "getMetadata") {
+		t.Error("client calls the system contract")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/zeabix-cloud-native/nstda-blockchain-chaincode/tools/clientgen
+
+go 1.17
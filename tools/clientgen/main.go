@@ -0,0 +1,57 @@
+// Command clientgen turns the contract metadata of deployed chaincodes into an
+// OpenAPI document and a TypeScript client for the gateway service.
+//
+// Each argument names a chaincode and the file holding its
+// org.hyperledger.fabric:GetMetadata response, which a peer returns for
+//
+//	peer chaincode query -C <channel> -n gap -c '{"Args":["org.hyperledger.fabric:GetMetadata"]}' > gap.json
+//
+// The generated files then cover every chaincode given:
+//
+//	clientgen -openapi gateway.json -ts client.ts gap=gap.json farmer=farmer.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+func main() {
+	openAPIPath := flag.String("openapi", "", "write the OpenAPI document to `file`")
+	tsPath := flag.String("ts", "", "write the TypeScript client to `file`")
+	title := flag.String("title", "NSTDA blockchain gateway", "title of the OpenAPI document")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: clientgen [-openapi file] [-ts file] chaincode=metadata.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 || (*openAPIPath == "" && *tsPath == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	chaincodes, err := loadChaincodes(flag.Args())
+	if err != nil {
+		log.Fatalf("clientgen: %v", err)
+	}
+
+	if *openAPIPath != "" {
+		document, err := OpenAPI(*title, chaincodes)
+		if err != nil {
+			log.Fatalf("clientgen: %v", err)
+		}
+		if err := ioutil.WriteFile(*openAPIPath, append(document, '\n'), 0644); err != nil {
+			log.Fatalf("clientgen: %v", err)
+		}
+	}
+	if *tsPath != "" {
+		if err := ioutil.WriteFile(*tsPath, TypeScript(chaincodes), 0644); err != nil {
+			log.Fatalf("clientgen: %v", err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SYSTEMCONTRACT is the contract contractapi adds to every chaincode to serve
+// GetMetadata. Clients never call it, so it is left out.
+const SYSTEMCONTRACT = "org.hyperledger.fabric"
+
+const SCHEMAREF = "#/components/schemas/"
+
+// Metadata is the part of the org.hyperledger.fabric:GetMetadata response
+// clients are generated from. Schemas stay generic JSON so that keywords the
+// generator does not interpret still reach the OpenAPI document.
+type Metadata struct {
+	Contracts  map[string]*Contract `json:"contracts"`
+	Components struct {
+		Schemas map[string]map[string]interface{} `json:"schemas"`
+	} `json:"components"`
+}
+
+type Contract struct {
+	Name         string        `json:"name"`
+	Default      bool          `json:"default"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+type Transaction struct {
+	Name       string                 `json:"name"`
+	Tag        []string               `json:"tag"`
+	Parameters []Parameter            `json:"parameters"`
+	Returns    map[string]interface{} `json:"returns"`
+}
+
+type Parameter struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
+// Chaincode is the metadata of one chaincode, under the name it is deployed
+// with on the channel.
+type Chaincode struct {
+	Name     string
+	Metadata *Metadata
+}
+
+// Operation is one transaction as the gateway exposes it.
+type Operation struct {
+	Chaincode   *Chaincode
+	Contract    *Contract
+	Transaction *Transaction
+}
+
+// loadChaincodes reads name=path arguments, path being a file holding the
+// chaincode's GetMetadata response.
+func loadChaincodes(args []string) ([]*Chaincode, error) {
+	chaincodes := []*Chaincode{}
+	seen := map[string]bool{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("argument %s must be chaincode=metadata.json", arg)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("chaincode %s is given twice", parts[0])
+		}
+		seen[parts[0]] = true
+
+		data, err := ioutil.ReadFile(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		var metadata Metadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata in %s: %v", parts[1], err)
+		}
+		chaincodes = append(chaincodes, &Chaincode{Name: parts[0], Metadata: &metadata})
+	}
+	return chaincodes, nil
+}
+
+// Operations lists the transactions of every contract but the system one,
+// sorted by contract and transaction name.
+func (c *Chaincode) Operations() []Operation {
+	names := []string{}
+	for name := range c.Metadata.Contracts {
+		if name != SYSTEMCONTRACT {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	operations := []Operation{}
+	for _, name := range names {
+		contract := c.Metadata.Contracts[name]
+		if contract.Name == "" {
+			contract.Name = name
+		}
+		transactions := contract.Transactions
+		sort.SliceStable(transactions, func(i, j int) bool {
+			return transactions[i].Name < transactions[j].Name
+		})
+		for i := range transactions {
+			operations = append(operations, Operation{Chaincode: c, Contract: contract, Transaction: &transactions[i]})
+		}
+	}
+	return operations
+}
+
+// SchemaNames lists the chaincode's component schemas in order.
+func (c *Chaincode) SchemaNames() []string {
+	names := []string{}
+	for name := range c.Metadata.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TypeName prefixes a component name with the chaincode name, as every
+// chaincode declares its own GetAllReponse, FilterGetAll and so on.
+func (c *Chaincode) TypeName(component string) string {
+	return pascalCase(c.Name) + component
+}
+
+// Function is the name the transaction is invoked with. Functions of a
+// contract other than the default one are qualified with the contract name.
+func (o Operation) Function() string {
+	if o.Contract.Default {
+		return o.Transaction.Name
+	}
+	return o.Contract.Name + ":" + o.Transaction.Name
+}
+
+func (o Operation) Path() string {
+	if o.Contract.Default {
+		return "/" + o.Chaincode.Name + "/" + o.Transaction.Name
+	}
+	return "/" + o.Chaincode.Name + "/" + o.Contract.Name + "/" + o.Transaction.Name
+}
+
+// Method is the operation name unique within its chaincode.
+func (o Operation) Method() string {
+	if o.Contract.Default {
+		return lowerFirst(o.Transaction.Name)
+	}
+	return lowerFirst(pascalCase(o.Contract.Name) + o.Transaction.Name)
+}
+
+// Type is "evaluate" for transactions the contract tags as read-only and
+// "submit" otherwise.
+func (o Operation) Type() string {
+	for _, tag := range o.Transaction.Tag {
+		if strings.EqualFold(tag, "evaluate") {
+			return "evaluate"
+		}
+	}
+	return "submit"
+}
+
+// localSchema copies schema with the $refs pointing at the chaincode's
+// prefixed component names. The $id contractapi adds to components is
+// dropped, as OpenAPI does not allow it.
+func (c *Chaincode) localSchema(schema interface{}) interface{} {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		local := map[string]interface{}{}
+		for key, value := range schema {
+			switch {
+			case key == "$id":
+			case key == "$ref":
+				ref, _ := value.(string)
+				local[key] = SCHEMAREF + c.TypeName(strings.TrimPrefix(ref, SCHEMAREF))
+			default:
+				local[key] = c.localSchema(value)
+			}
+		}
+		return local
+	case []interface{}:
+		local := make([]interface{}, len(schema))
+		for i, value := range schema {
+			local[i] = c.localSchema(value)
+		}
+		return local
+	}
+	return schema
+}
+
+// pascalCase turns a chaincode or contract name such as nstda-staff into
+// NstdaStaff.
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
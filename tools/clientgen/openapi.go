@@ -0,0 +1,94 @@
+package main
+
+import "encoding/json"
+
+// OpenAPI describes the gateway as one POST per transaction. The request body
+// holds the transaction arguments under their parameter names; the gateway
+// passes them in parameter order, strings as they are and anything else as
+// JSON. The response body is the transaction's return value.
+func OpenAPI(title string, chaincodes []*Chaincode) ([]byte, error) {
+	paths := map[string]interface{}{}
+	schemas := map[string]interface{}{}
+	tags := []interface{}{}
+
+	for _, chaincode := range chaincodes {
+		tags = append(tags, map[string]interface{}{"name": chaincode.Name})
+		for _, name := range chaincode.SchemaNames() {
+			schemas[chaincode.TypeName(name)] = chaincode.localSchema(chaincode.Metadata.Components.Schemas[name])
+		}
+		for _, operation := range chaincode.Operations() {
+			paths[operation.Path()] = map[string]interface{}{"post": openAPIOperation(operation)}
+		}
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": "latest",
+		},
+		"tags":  tags,
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+func openAPIOperation(operation Operation) map[string]interface{} {
+	chaincode := operation.Chaincode
+	transaction := operation.Transaction
+
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "The transaction was rejected or failed",
+		},
+	}
+	if transaction.Returns == nil {
+		responses["204"] = map[string]interface{}{
+			"description": "The transaction succeeded",
+		}
+	} else {
+		responses["200"] = map[string]interface{}{
+			"description": "The transaction result",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": chaincode.localSchema(transaction.Returns),
+				},
+			},
+		}
+	}
+
+	result := map[string]interface{}{
+		"operationId":               lowerFirst(pascalCase(chaincode.Name)) + pascalCase(operation.Method()),
+		"tags":                      []string{chaincode.Name},
+		"x-fabric-chaincode":        chaincode.Name,
+		"x-fabric-function":         operation.Function(),
+		"x-fabric-transaction-type": operation.Type(),
+		"responses":                 responses,
+	}
+
+	if len(transaction.Parameters) > 0 {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, parameter := range transaction.Parameters {
+			properties[parameter.Name] = chaincode.localSchema(parameter.Schema)
+			required = append(required, parameter.Name)
+		}
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":                 "object",
+						"properties":           properties,
+						"required":             required,
+						"additionalProperties": false,
+					},
+				},
+			},
+		}
+	}
+	return result
+}
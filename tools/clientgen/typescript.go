@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+const tsRuntime = `export type TransactionType = "submit" | "evaluate";
+
+export interface Transaction {
+  chaincode: string;
+  function: string;
+  path: string;
+  type: TransactionType;
+  body: Record<string, unknown>;
+}
+
+// Call sends a transaction to the gateway and resolves to its result.
+export type Call = (transaction: Transaction) => Promise<unknown>;
+
+// httpCall posts transactions to the gateway at baseUrl.
+export function httpCall(baseUrl: string, init: RequestInit = {}): Call {
+  return async (transaction) => {
+    const response = await fetch(baseUrl.replace(/\/+$/, "") + transaction.path, {
+      ...init,
+      method: "POST",
+      headers: { "Content-Type": "application/json", ...(init.headers as Record<string, string>) },
+      body: JSON.stringify(transaction.body),
+    });
+    const text = await response.text();
+    if (!response.ok) {
+      throw new Error(transaction.chaincode + " " + transaction.function + ": " + response.status + " " + text);
+    }
+    return text === "" ? undefined : JSON.parse(text);
+  };
+}
+`
+
+// TypeScript writes an interface per component schema and a client class per
+// chaincode whose methods send transactions through a Call.
+func TypeScript(chaincodes []*Chaincode) []byte {
+	var b strings.Builder
+	b.WriteString("// Code generated by clientgen from contract metadata. DO NOT EDIT.\n\n")
+	b.WriteString(tsRuntime)
+
+	for _, chaincode := range chaincodes {
+		for _, name := range chaincode.SchemaNames() {
+			schema := chaincode.Metadata.Components.Schemas[name]
+			fmt.Fprintf(&b, "\nexport interface %s %s\n", chaincode.TypeName(name), tsObject(chaincode, schema, ""))
+		}
+	}
+
+	for _, chaincode := range chaincodes {
+		fmt.Fprintf(&b, "\nexport class %sClient {\n", pascalCase(chaincode.Name))
+		b.WriteString("  constructor(private readonly call: Call) {}\n")
+		for _, operation := range chaincode.Operations() {
+			tsMethod(&b, operation)
+		}
+		b.WriteString("}\n")
+	}
+	return []byte(b.String())
+}
+
+func tsMethod(b *strings.Builder, operation Operation) {
+	chaincode := operation.Chaincode
+	transaction := operation.Transaction
+
+	params := []string{}
+	body := []string{}
+	for _, parameter := range transaction.Parameters {
+		params = append(params, parameter.Name+": "+tsType(chaincode, parameter.Schema, "  "))
+		body = append(body, parameter.Name)
+	}
+	result := "void"
+	if transaction.Returns != nil {
+		result = tsType(chaincode, transaction.Returns, "  ")
+	}
+
+	fmt.Fprintf(b, "\n  %s(%s): Promise<%s> {\n", operation.Method(), strings.Join(params, ", "), result)
+	fmt.Fprintf(b, "    return this.call({\n")
+	fmt.Fprintf(b, "      chaincode: %s,\n", tsString(chaincode.Name))
+	fmt.Fprintf(b, "      function: %s,\n", tsString(operation.Function()))
+	fmt.Fprintf(b, "      path: %s,\n", tsString(operation.Path()))
+	fmt.Fprintf(b, "      type: %s,\n", tsString(operation.Type()))
+	if len(body) == 0 {
+		b.WriteString("      body: {},\n")
+	} else {
+		fmt.Fprintf(b, "      body: { %s },\n", strings.Join(body, ", "))
+	}
+	fmt.Fprintf(b, "    }) as Promise<%s>;\n", result)
+	b.WriteString("  }\n")
+}
+
+// tsType maps a JSON schema to a TypeScript type. indent is the indentation
+// of the line the type starts on, for inline object types.
+func tsType(chaincode *Chaincode, schema map[string]interface{}, indent string) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return chaincode.TypeName(strings.TrimPrefix(ref, SCHEMAREF))
+	}
+	switch schema["type"] {
+	case "string":
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		item := tsType(chaincode, items, indent)
+		if strings.HasPrefix(item, "{") {
+			return "Array<" + item + ">"
+		}
+		return item + "[]"
+	case "object":
+		if _, ok := schema["properties"]; ok {
+			return tsObject(chaincode, schema, indent)
+		}
+		if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			return "Record<string, " + tsType(chaincode, values, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// tsObject writes an object schema as a TypeScript object type whose
+// properties not listed as required are optional.
+func tsObject(chaincode *Chaincode, schema map[string]interface{}, indent string) string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if names, ok := schema["required"].([]interface{}); ok {
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		property, _ := properties[name].(map[string]interface{})
+		key := name
+		if !identifier.MatchString(name) {
+			key = tsString(name)
+		}
+		if !required[name] {
+			key += "?"
+		}
+		fmt.Fprintf(&b, "%s  %s: %s;\n", indent, key, tsType(chaincode, property, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func tsString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}